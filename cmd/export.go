@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"training/proj/internal/db"
+	"training/proj/internal/db/repositories"
+)
+
+type exportedItem struct {
+	ItemID     int64    `json:"item_id"`
+	Item       string   `json:"item"`
+	Price      int64    `json:"price"`
+	Currency   string   `json:"currency"`
+	Categories []string `json:"categories"`
+}
+
+func runExport(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	output := fs.String("o", "-", "Output file ('-' for stdout)")
+	format := fs.String("format", "json", "Output format (json|ndjson)")
+
+	cfg, err := loadConfig(fs, args)
+	if err != nil {
+		return err
+	}
+
+	if *format != "json" && *format != "ndjson" {
+		return fmt.Errorf("unknown export format %q", *format)
+	}
+
+	conn, err := db.Connect(ctx, cfg)
+	if err != nil {
+		return fmt.Errorf("connect to the database: %w", err)
+	}
+	defer conn.Close()
+
+	repos := cfg.InitializeRepositories(conn)
+
+	var exported []exportedItem
+
+	err = repos.TxManager.WithinTx(ctx, func(ctx context.Context, r *repositories.Repositories) error {
+		items, getErr := r.ItemRepository.GetAll(ctx)
+		if getErr != nil {
+			return getErr
+		}
+
+		exported = make([]exportedItem, 0, len(items))
+
+		for _, item := range items {
+			categories, catErr := r.ItemRepository.GetItemCategories(ctx, item.ItemID)
+			if catErr != nil {
+				return catErr
+			}
+
+			names := make([]string, 0, len(categories))
+			for _, c := range categories {
+				names = append(names, c.Category)
+			}
+
+			exported = append(exported, exportedItem{
+				ItemID:     item.ItemID,
+				Item:       item.Item,
+				Price:      item.Price,
+				Currency:   item.Currency,
+				Categories: names,
+			})
+		}
+
+		return nil
+	}, repositories.WithIsolation(repositories.RepeatableRead), repositories.WithReadOnly())
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+
+	if *output != "-" {
+		f, createErr := os.Create(*output)
+		if createErr != nil {
+			return createErr
+		}
+		defer f.Close()
+		w = f
+	}
+
+	enc := json.NewEncoder(w)
+
+	if *format == "ndjson" {
+		for _, item := range exported {
+			if err := enc.Encode(item); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	enc.SetIndent("", "\t")
+	return enc.Encode(exported)
+}
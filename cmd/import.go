@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sync"
+	"training/proj/internal/db"
+	"training/proj/internal/logger"
+	"training/proj/internal/scheduler"
+	"training/proj/internal/tracing"
+)
+
+func runImport(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	timeout := fs.Duration("timeout", 0, "Abort the import after this duration (0 means no limit)")
+
+	cfg, err := loadConfig(fs, args)
+	if err != nil {
+		return err
+	}
+
+	importers, err := cfg.InitializeImporters()
+	if err != nil {
+		return err
+	}
+
+	tp, err := tracing.InitTracer(ctx, cfg.Tracing.Exporter, cfg.Tracing.OtlpEndpoint)
+	if err != nil {
+		return fmt.Errorf("initialize tracing: %w", err)
+	}
+	defer tp.Shutdown(context.Background())
+
+	if *timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
+	}
+
+	conn, err := db.Connect(ctx, cfg)
+	if err != nil {
+		return fmt.Errorf("connect to the database: %w", err)
+	}
+	defer conn.Close()
+
+	repos := cfg.InitializeRepositories(conn)
+	sch := scheduler.NewScheduler(repos, importers, logger.Logger, &sync.WaitGroup{})
+	sch.Currency = cfg.Pricing.Currency
+
+	res, err := sch.ExternalDbFill(ctx, scheduler.RunOptions{DryRun: cfg.Scheduler.DryRun})
+
+	for _, f := range res.Failures {
+		logger.Logger.Warnw("Skipped record", "importer", f.Source, "ref", f.Ref, "name", f.Name, "error", f.Error)
+	}
+
+	if res.Report != nil {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if encErr := enc.Encode(res.Report); encErr != nil {
+			return errors.Join(err, encErr)
+		}
+	}
+
+	return err
+}
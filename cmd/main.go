@@ -1,60 +1,96 @@
 package main
 
 import (
-	"time"
-	"training/proj/internal/api"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
 	"training/proj/internal/config"
-	"training/proj/internal/db"
 	"training/proj/internal/logger"
-	"training/proj/internal/scheduler"
 
 	"github.com/joho/godotenv"
 	"go.uber.org/zap"
 )
 
+type command struct {
+	usage string
+	run   func(ctx context.Context, args []string) error
+}
+
+var commands = map[string]command{
+	"serve":   {usage: "serve [flags]", run: runServe},
+	"migrate": {usage: "migrate up|down|goto N|version|force N [flags]", run: runMigrate},
+	"seed":    {usage: "seed [flags]", run: runSeed},
+	"user":    {usage: "user create-admin [flags]", run: runUser},
+	"import":  {usage: "import [flags]", run: runImport},
+	"export":  {usage: "export [flags]", run: runExport},
+}
+
 func main() {
 	logger.InitLogger()
 	defer logger.CloseLogger()
 
 	err := godotenv.Load()
-	if err != nil {
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
 		logger.Logger.Fatal("Error loading .env file", zap.Error(err))
 	}
 
-	cfg := config.NewConfig()
+	name, args := "serve", os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	}
 
-	err = cfg.ParseFlags()
-	if err != nil {
-		logger.Logger.Fatal("Failed to parse command-line flags", zap.Error(err))
+	cmd, ok := commands[name]
+	if !ok {
+		usage()
+		os.Exit(2)
 	}
 
-	conn, err := db.Connect(cfg)
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	err = cmd.run(ctx, args)
 	if err != nil {
-		logger.Logger.Fatal("Failed to connect to the database", zap.Error(err))
-		panic(err)
+		logger.Logger.Fatalw("Command failed", "command", name, "error", err)
 	}
-	defer conn.Close()
+}
 
-	migrationErr := db.CreateTables(conn, cfg)
-	if migrationErr != nil {
-		logger.Logger.Fatal("Failed to apply migrations", zap.Error(err))
-		panic(migrationErr)
+func usage() {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
 	}
+	sort.Strings(names)
 
-	repositories := cfg.InitializeRepositories(conn)
-	handlers := cfg.InitializeHandlers(repositories)
-	srv := api.NewAPI(logger.Logger, cfg, handlers)
+	fmt.Fprintf(os.Stderr, "Usage: %s <command> [flags]\n\nCommands:\n", os.Args[0])
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %s\n", commands[name].usage)
+	}
+	fmt.Fprintf(os.Stderr, "\nRun '%s <command> -h' for command flags.\n", os.Args[0])
+}
+
+func loadConfig(flags *flag.FlagSet, args []string) (*config.Config, error) {
+	cfg, err := config.Load(flags, args)
+	if err != nil {
+		return nil, err
+	}
 
-	sch := scheduler.NewScheduler(repositories, logger.Logger, srv.Wg)
-	go func() {
-		for {
-			sch.ExternalDbFill()
-			time.Sleep(1 * time.Hour)
+	if cfg.PrintConfig {
+		if err := cfg.Print(os.Stdout); err != nil {
+			return nil, err
 		}
-	}()
+		os.Exit(0)
+	}
 
-	err = srv.Run()
-	if err != nil {
-		logger.Logger.Fatal("Failed to start the server", zap.Error(err))
+	if err := logger.Configure(cfg.Log.Options()); err != nil {
+		return nil, err
 	}
+
+	return cfg, nil
 }
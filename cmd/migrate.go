@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"strconv"
+	"training/proj/internal/db"
+
+	"github.com/golang-migrate/migrate/v4"
+)
+
+func runMigrate(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: migrate up|down|goto N|version|force N [flags]")
+	}
+
+	action := args[0]
+
+	fs := flag.NewFlagSet("migrate "+action, flag.ExitOnError)
+	steps := fs.Int("steps", 0, "Number of migrations to apply (up) or revert (down, default 1)")
+	all := fs.Bool("all", false, "Revert all migrations (down only)")
+
+	cfg, err := loadConfig(fs, args[1:])
+	if err != nil {
+		return err
+	}
+
+	conn, err := db.Connect(ctx, cfg)
+	if err != nil {
+		return fmt.Errorf("connect to the database: %w", err)
+	}
+	defer conn.Close()
+
+	m, err := db.NewMigrator(conn, cfg)
+	if err != nil {
+		return fmt.Errorf("create migrator: %w", err)
+	}
+	defer m.Close()
+
+	err = db.WithMigrationLock(ctx, conn, func() error {
+		switch action {
+		case "up":
+			if *steps > 0 {
+				return m.Steps(*steps)
+			}
+			return m.Up()
+		case "down":
+			switch {
+			case *all:
+				return m.Down()
+			case *steps > 0:
+				return m.Steps(-*steps)
+			default:
+				return m.Steps(-1)
+			}
+		case "goto":
+			version, parseErr := versionArg(fs)
+			if parseErr != nil {
+				return parseErr
+			}
+			return m.Migrate(uint(version))
+		case "force":
+			version, parseErr := versionArg(fs)
+			if parseErr != nil {
+				return parseErr
+			}
+			return m.Force(version)
+		case "version":
+			return nil
+		default:
+			return fmt.Errorf("unknown migrate action %q", action)
+		}
+	})
+
+	if errors.Is(err, migrate.ErrNoChange) {
+		fmt.Println("no change")
+	} else if err != nil {
+		return err
+	}
+
+	version, dirty, err := m.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		fmt.Println("version: none")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("version: %d, dirty: %t\n", version, dirty)
+	return nil
+}
+
+func versionArg(fs *flag.FlagSet) (int, error) {
+	if fs.NArg() != 1 {
+		return 0, errors.New("expected exactly one version argument")
+	}
+
+	version, err := strconv.Atoi(fs.Arg(0))
+	if err != nil || version < 0 {
+		return 0, fmt.Errorf("invalid version %q", fs.Arg(0))
+	}
+
+	return version, nil
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"time"
+	"training/proj/internal/api/models"
+	"training/proj/internal/db"
+	"training/proj/internal/db/repositories"
+	"training/proj/internal/logger"
+)
+
+var seedCategories = []string{"Electronics", "Books", "Garden", "Toys", "Groceries"}
+
+var seedNouns = []string{"Lamp", "Novel", "Shovel", "Robot", "Coffee", "Headphones", "Atlas", "Planter", "Puzzle", "Tea"}
+
+func runSeed(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("seed", flag.ExitOnError)
+	seed := fs.Uint64("seed", 42, "Random seed used to generate prices")
+	itemCount := fs.Int("items", 50, "Number of demo items")
+
+	cfg, err := loadConfig(fs, args)
+	if err != nil {
+		return err
+	}
+
+	conn, err := db.Connect(ctx, cfg)
+	if err != nil {
+		return fmt.Errorf("connect to the database: %w", err)
+	}
+	defer conn.Close()
+
+	repos := cfg.InitializeRepositories(conn)
+	items := demoItems(*seed, *itemCount, cfg.Pricing.Currency)
+
+	var created int64
+
+	err = repos.TxManager.WithinTx(ctx, func(ctx context.Context, r *repositories.Repositories) error {
+		categoryIds := make(map[string]int64, len(seedCategories))
+
+		for _, name := range seedCategories {
+			category, getErr := r.CategoryRepository.GetByName(ctx, name)
+			if errors.Is(getErr, repositories.ErrNotFound) {
+				category, getErr = r.CategoryRepository.Create(ctx, &models.Category{Category: name})
+				if getErr == nil {
+					getErr = seedRevision(ctx, r, "category", category.CategoryID, models.NewCategorySnapshot(category))
+				}
+			}
+			if getErr != nil {
+				return getErr
+			}
+			categoryIds[name] = category.CategoryID
+		}
+
+		missing := make([]models.Item, 0, len(items))
+		isMissing := make(map[string]bool, len(items))
+
+		for _, item := range items {
+			_, getErr := r.ItemRepository.GetByName(ctx, item.Item)
+			if errors.Is(getErr, repositories.ErrNotFound) {
+				missing = append(missing, item)
+				isMissing[item.Item] = true
+				continue
+			}
+			if getErr != nil {
+				return getErr
+			}
+		}
+
+		var copyErr error
+		created, copyErr = r.ItemRepository.CreateMany(ctx, missing)
+		if copyErr != nil {
+			return copyErr
+		}
+
+		for i, item := range items {
+			dbItem, getErr := r.ItemRepository.GetByName(ctx, item.Item)
+			if getErr != nil {
+				return getErr
+			}
+
+			if isMissing[item.Item] {
+				if revErr := seedRevision(ctx, r, "item", dbItem.ItemID, models.NewItemSnapshot(dbItem)); revErr != nil {
+					return revErr
+				}
+
+				now := time.Now()
+				priceErr := r.PriceRepository.Create(ctx, &models.PriceChange{
+					ItemID: dbItem.ItemID, Price: dbItem.Price, EffectiveAt: now, AppliedAt: &now, Actor: "seed",
+				})
+				if priceErr != nil {
+					return priceErr
+				}
+			}
+
+			categoryId := categoryIds[seedCategories[i%len(seedCategories)]]
+
+			linked, getErr := r.ItemRepository.GetItemCategories(ctx, dbItem.ItemID)
+			if getErr != nil {
+				return getErr
+			}
+
+			if slices.ContainsFunc(linked, func(c models.Category) bool { return c.CategoryID == categoryId }) {
+				continue
+			}
+
+			if linkErr := r.CategoryItemRepository.Create(ctx, categoryId, dbItem.ItemID); linkErr != nil {
+				return linkErr
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	logger.Logger.Infow("Seeded demo data", "categories", len(seedCategories), "items", len(items), "items_created", created)
+	return nil
+}
+
+func seedRevision(ctx context.Context, r *repositories.Repositories, entityType string, id int64, data any) error {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	return r.RevisionRepository.Create(ctx, &models.Revision{EntityType: entityType, EntityID: id, Data: raw, Actor: "seed"})
+}
+
+func demoItems(seed uint64, count int, currency string) []models.Item {
+	rnd := rand.New(rand.NewPCG(seed, seed))
+	items := make([]models.Item, 0, count)
+
+	for i := 0; i < count; i++ {
+		items = append(items, models.Item{
+			Item:     fmt.Sprintf("Demo %s #%03d", seedNouns[i%len(seedNouns)], i+1),
+			Price:    rnd.Int64N(99900) + 1000,
+			Currency: currency,
+		})
+	}
+
+	return items
+}
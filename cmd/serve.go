@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"time"
+	"training/proj/internal/api"
+	"training/proj/internal/db"
+	"training/proj/internal/jobs"
+	"training/proj/internal/logger"
+	"training/proj/internal/metrics"
+	"training/proj/internal/scheduler"
+	"training/proj/internal/tracing"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+func runServe(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	skipMigrations := fs.Bool("skipMigrations", false, "Do not apply pending migrations on startup")
+
+	cfg, err := loadConfig(fs, args)
+	if err != nil {
+		return err
+	}
+
+	importers, err := cfg.InitializeImporters()
+	if err != nil {
+		return err
+	}
+
+	tp, err := tracing.InitTracer(ctx, cfg.Tracing.Exporter, cfg.Tracing.OtlpEndpoint)
+	if err != nil {
+		return fmt.Errorf("initialize tracing: %w", err)
+	}
+	defer tp.Shutdown(context.Background())
+
+	conn, err := db.Connect(ctx, cfg)
+	if err != nil {
+		return fmt.Errorf("connect to the database: %w", err)
+	}
+	defer conn.Close()
+
+	metrics.RegisterDBStats(conn, cfg.DB.Name)
+
+	if !*skipMigrations {
+		err = db.CreateTables(ctx, conn, cfg)
+		if err != nil {
+			return fmt.Errorf("apply migrations: %w", err)
+		}
+	}
+
+	repositories := cfg.InitializeRepositories(conn)
+	handlers := cfg.InitializeHandlers(repositories)
+	srv := api.NewAPI(logger.Logger, cfg, handlers)
+
+	sch := scheduler.NewScheduler(repositories, importers, logger.Logger, srv.Wg)
+	sch.Currency = cfg.Pricing.Currency
+
+	handlers.HealthHandler.AddCheck("database", func(ctx context.Context) (interface{}, error) {
+		return nil, db.Ping(ctx, conn)
+	})
+	handlers.HealthHandler.AddCheck("migrations", func(ctx context.Context) (interface{}, error) {
+		return db.GetMigrationStatus(ctx, conn)
+	})
+	handlers.HealthHandler.AddAdvisoryCheck("scheduler", sch.Check)
+
+	runner := jobs.NewRunner(repositories.JobRunRepository, logger.Logger, srv.Wg)
+	handlers.AdminHandler.Jobs = runner
+
+	if cfg.Scheduler.Enabled {
+		err = runner.Register(jobs.Job{
+			Name:     "import",
+			Schedule: cfg.Scheduler.Schedule,
+			Jitter:   cfg.Scheduler.Jitter,
+			Timeout:  cfg.Scheduler.Timeout,
+			Run: func(ctx context.Context) (any, error) {
+				res, err := sch.ExternalDbFill(ctx, scheduler.RunOptions{DryRun: cfg.Scheduler.DryRun})
+				return res.Summary(), err
+			},
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	err = runner.Register(jobs.Job{
+		Name:     "apply-prices",
+		Schedule: cfg.Pricing.Schedule,
+		Run:      jobs.ApplyScheduledPrices(repositories.TxManager),
+	})
+	if err != nil {
+		return err
+	}
+
+	if cfg.Pricing.RatesURL != "" {
+		client := &http.Client{Transport: otelhttp.NewTransport(http.DefaultTransport), Timeout: time.Minute}
+
+		err = runner.Register(jobs.Job{
+			Name:     "import-exchange-rates",
+			Schedule: cfg.Pricing.RatesSchedule,
+			Run:      jobs.ImportExchangeRates(repositories.TxManager, client, cfg.Pricing.RatesURL),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if cfg.Trash.Retention > 0 {
+		err = runner.Register(jobs.Job{
+			Name:     "purge-trash",
+			Schedule: cfg.Trash.Schedule,
+			Run:      jobs.PurgeTrash(repositories.TxManager, cfg.Trash.Retention),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	runner.Start(ctx)
+
+	return srv.Run(ctx)
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"training/proj/internal/api/models"
+	"training/proj/internal/db"
+	"training/proj/internal/db/repositories"
+	"training/proj/internal/logger"
+
+	"github.com/go-playground/validator/v10"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func runUser(ctx context.Context, args []string) error {
+	if len(args) == 0 || args[0] != "create-admin" {
+		return errors.New("usage: user create-admin [flags]")
+	}
+
+	fs := flag.NewFlagSet("user create-admin", flag.ExitOnError)
+	email := fs.String("email", "", "Admin email")
+	username := fs.String("username", "", "Admin username")
+	password := fs.String("password", os.Getenv("ADMIN_PASSWORD"), "Admin password (defaults to $ADMIN_PASSWORD)")
+	firstName := fs.String("firstName", "Admin", "Admin first name")
+	lastName := fs.String("lastName", "Admin", "Admin last name")
+
+	cfg, err := loadConfig(fs, args[1:])
+	if err != nil {
+		return err
+	}
+
+	user := models.User{
+		Email:     *email,
+		Username:  *username,
+		FirstName: *firstName,
+		LastName:  *lastName,
+		Password:  *password,
+	}
+
+	if err := validator.New().Struct(user); err != nil {
+		return err
+	}
+
+	conn, err := db.Connect(ctx, cfg)
+	if err != nil {
+		return fmt.Errorf("connect to the database: %w", err)
+	}
+	defer conn.Close()
+
+	repos := cfg.InitializeRepositories(conn)
+
+	err = repos.TxManager.WithinTx(ctx, func(ctx context.Context, r *repositories.Repositories) error {
+		_, getErr := r.UserRepository.GetByUsername(ctx, user.Username)
+
+		if errors.Is(getErr, repositories.ErrNotFound) {
+			hashedPassword, hashErr := bcrypt.GenerateFromPassword([]byte(user.Password), 12)
+			if hashErr != nil {
+				return hashErr
+			}
+
+			if _, createErr := r.UserRepository.Create(ctx, &user, hashedPassword); createErr != nil {
+				return createErr
+			}
+		} else if getErr != nil {
+			return getErr
+		}
+
+		_, setErr := r.UserRepository.SetAdmin(ctx, user.Username, true)
+		return setErr
+	})
+	if err != nil {
+		return err
+	}
+
+	logger.Logger.Infow("Admin user ready", "username", user.Username)
+	return nil
+}
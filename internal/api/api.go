@@ -4,10 +4,7 @@ import (
 	"context"
 	"errors"
 	"net/http"
-	"os"
-	"os/signal"
 	"sync"
-	"syscall"
 	"time"
 	"training/proj/internal/api/handlers"
 	"training/proj/internal/api/routes"
@@ -23,6 +20,7 @@ type API struct {
 	Config *config.Config
 	Logger *zap.SugaredLogger
 	Wg     *sync.WaitGroup
+	Health *handlers.HealthHandler
 }
 
 func NewAPI(log *zap.SugaredLogger, cfg *config.Config, h *handlers.Handlers) *API {
@@ -38,31 +36,36 @@ func NewAPI(log *zap.SugaredLogger, cfg *config.Config, h *handlers.Handlers) *A
 		Logger: log,
 		Config: cfg,
 		Wg:     &sync.WaitGroup{},
+		Health: h.HealthHandler,
 	}
 }
 
-func (a *API) Run() error {
+// Run serves HTTP until ctx is cancelled, then stops reporting ready, drains
+// in-flight requests and waits for background work tracked in Wg.
+func (a *API) Run(ctx context.Context) error {
 	srv := &http.Server{
-		Addr:         a.Config.Address,
+		Addr:         a.Config.Server.Address,
 		Handler:      a.Router,
-		IdleTimeout:  time.Minute,
-		ReadTimeout:  10 * time.Second,
-		WriteTimeout: 30 * time.Second,
+		IdleTimeout:  a.Config.Server.IdleTimeout,
+		ReadTimeout:  a.Config.Server.ReadTimeout,
+		WriteTimeout: a.Config.Server.WriteTimeout,
 	}
 
 	shutdownError := make(chan error)
 
 	go func() {
-		quit := make(chan os.Signal, 1)
-		signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-		sign := <-quit
+		<-ctx.Done()
 
-		a.Logger.Infow("Caught signal", "signal", sign.String())
+		a.Logger.Infow("Shutting down", "reason", context.Cause(ctx).Error())
 
-		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		a.Health.SetReady(false)
+		a.Logger.Infow("Draining traffic", "delay", a.Config.Server.DrainDelay.String())
+		time.Sleep(a.Config.Server.DrainDelay)
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), a.Config.Server.ShutdownTimeout)
 		defer cancel()
 
-		err := srv.Shutdown(ctx)
+		err := srv.Shutdown(shutdownCtx)
 		if err != nil {
 			shutdownError <- err
 		}
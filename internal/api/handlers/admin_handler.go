@@ -0,0 +1,430 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+	"training/proj/internal/api/models"
+	"training/proj/internal/customerrors"
+	"training/proj/internal/db/repositories"
+	"training/proj/internal/jobs"
+	"training/proj/internal/logger"
+	"training/proj/internal/money"
+	"training/proj/internal/utils"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/jwtauth/v5"
+)
+
+type JobRunner interface {
+	Jobs(ctx context.Context) ([]jobs.Info, error)
+	Runs(ctx context.Context, name string, limit int) ([]models.JobRun, error)
+	Trigger(name string) (models.JobRun, error)
+}
+
+type AdminHandler struct {
+	ItemRepository         repositories.ItemRepositoryInterface
+	CategoryRepository     repositories.CategoryRepositoryInterface
+	AuditRepository        repositories.AuditRepositoryInterface
+	ExchangeRateRepository repositories.ExchangeRateRepositoryInterface
+	CouponRepository       repositories.CouponRepositoryInterface
+	SaleRepository         repositories.SaleRepositoryInterface
+	TaxRepository          repositories.TaxRepositoryInterface
+	ShippingRepository     repositories.ShippingRepositoryInterface
+	TxManager              repositories.TxManager
+	Jobs                   JobRunner
+}
+
+func NewAdminHandler(ir repositories.ItemRepositoryInterface, cr repositories.CategoryRepositoryInterface,
+	ar repositories.AuditRepositoryInterface, er repositories.ExchangeRateRepositoryInterface, cor repositories.CouponRepositoryInterface,
+	sr repositories.SaleRepositoryInterface, tr repositories.TaxRepositoryInterface, shr repositories.ShippingRepositoryInterface,
+	tm repositories.TxManager) *AdminHandler {
+	return &AdminHandler{
+		ItemRepository:         ir,
+		CategoryRepository:     cr,
+		AuditRepository:        ar,
+		ExchangeRateRepository: er,
+		CouponRepository:       cor,
+		SaleRepository:         sr,
+		TaxRepository:          tr,
+		ShippingRepository:     shr,
+		TxManager:              tm,
+	}
+}
+
+type logLevelRequest struct {
+	Level string `json:"level"`
+}
+
+func (h *AdminHandler) GetLogLevel(w http.ResponseWriter, r *http.Request) {
+	err := utils.WriteJSON(w, http.StatusOK, utils.Envelope{"level": logger.Level.String()}, nil, logger.Logger)
+	if err != nil {
+		customerrors.ServerErrorResponse(w, r, err)
+	}
+}
+
+func (h *AdminHandler) PutLogLevel(w http.ResponseWriter, r *http.Request) {
+	var req logLevelRequest
+
+	if err := utils.ReadJSON(w, r, &req, logger.Logger); err != nil {
+		customerrors.BadRequestResponse(w, r, err)
+		return
+	}
+
+	previous := logger.Level.String()
+
+	if err := logger.SetLevel(req.Level); err != nil {
+		customerrors.BadRequestResponse(w, r, err)
+		return
+	}
+
+	_, claims, _ := jwtauth.FromContext(r.Context())
+	logger.Logger.Infow("Log level changed", "from", previous, "to", logger.Level.String(), "user_id", claims["user_id"])
+
+	err := utils.WriteJSON(w, http.StatusOK, utils.Envelope{"level": logger.Level.String()}, nil, logger.Logger)
+	if err != nil {
+		customerrors.ServerErrorResponse(w, r, err)
+	}
+}
+
+func (h *AdminHandler) GetJobs(w http.ResponseWriter, r *http.Request) {
+	if h.Jobs == nil {
+		customerrors.NotFoundResponse(w, r)
+		return
+	}
+
+	infos, err := h.Jobs.Jobs(r.Context())
+	if err != nil {
+		customerrors.ServerErrorResponse(w, r, err)
+		return
+	}
+
+	err = utils.WriteJSON(w, http.StatusOK, utils.Envelope{"jobs": infos}, nil, logger.Logger)
+	if err != nil {
+		customerrors.ServerErrorResponse(w, r, err)
+	}
+}
+
+func (h *AdminHandler) GetJobRuns(w http.ResponseWriter, r *http.Request) {
+	if h.Jobs == nil {
+		customerrors.NotFoundResponse(w, r)
+		return
+	}
+
+	limit := 20
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > 500 {
+			customerrors.BadRequestResponse(w, r, errors.New("limit must be an integer between 1 and 500"))
+			return
+		}
+		limit = n
+	}
+
+	runs, err := h.Jobs.Runs(r.Context(), chi.URLParam(r, "name"), limit)
+	if errors.Is(err, jobs.ErrUnknownJob) {
+		customerrors.NotFoundResponse(w, r)
+		return
+	}
+	if err != nil {
+		customerrors.ServerErrorResponse(w, r, err)
+		return
+	}
+
+	err = utils.WriteJSON(w, http.StatusOK, utils.Envelope{"runs": runs}, nil, logger.Logger)
+	if err != nil {
+		customerrors.ServerErrorResponse(w, r, err)
+	}
+}
+
+func (h *AdminHandler) PostJobRun(w http.ResponseWriter, r *http.Request) {
+	if h.Jobs == nil {
+		customerrors.NotFoundResponse(w, r)
+		return
+	}
+
+	run, err := h.Jobs.Trigger(chi.URLParam(r, "name"))
+	switch {
+	case errors.Is(err, jobs.ErrUnknownJob):
+		customerrors.NotFoundResponse(w, r)
+		return
+	case errors.Is(err, jobs.ErrJobRunning):
+		customerrors.ErrorResponse(w, r, http.StatusConflict, err.Error())
+		return
+	case errors.Is(err, jobs.ErrNotStarted):
+		customerrors.ErrorResponse(w, r, http.StatusServiceUnavailable, err.Error())
+		return
+	case err != nil:
+		customerrors.ServerErrorResponse(w, r, err)
+		return
+	}
+
+	_, claims, _ := jwtauth.FromContext(r.Context())
+	logger.Logger.Infow("Job triggered", "job", run.Job, "job_run_id", run.JobRunID, "user_id", claims["user_id"])
+
+	err = utils.WriteJSON(w, http.StatusAccepted, utils.Envelope{"run": run}, nil, logger.Logger)
+	if err != nil {
+		customerrors.ServerErrorResponse(w, r, err)
+	}
+}
+
+func (h *AdminHandler) GetAudit(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	filter := repositories.AuditFilter{
+		Actor:      query.Get("actor"),
+		EntityType: query.Get("entity_type"),
+		EntityID:   query.Get("entity_id"),
+		Limit:      100,
+	}
+
+	for _, bound := range []struct {
+		param string
+		dst   **time.Time
+	}{{"from", &filter.From}, {"to", &filter.To}} {
+		raw := query.Get(bound.param)
+		if raw == "" {
+			continue
+		}
+
+		t, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			customerrors.BadRequestResponse(w, r, fmt.Errorf("%s must be an RFC 3339 timestamp", bound.param))
+			return
+		}
+		*bound.dst = &t
+	}
+
+	if raw := query.Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > 1000 {
+			customerrors.BadRequestResponse(w, r, errors.New("limit must be an integer between 1 and 1000"))
+			return
+		}
+		filter.Limit = n
+	}
+
+	entries, err := h.AuditRepository.GetAll(r.Context(), filter)
+	if err != nil {
+		customerrors.ServerErrorResponse(w, r, err)
+		return
+	}
+
+	err = utils.WriteJSON(w, http.StatusOK, utils.Envelope{"audit": entries}, nil, logger.Logger)
+	if err != nil {
+		customerrors.ServerErrorResponse(w, r, err)
+	}
+}
+
+func (h *AdminHandler) GetTrash(w http.ResponseWriter, r *http.Request) {
+	items, err := h.ItemRepository.GetDeleted(r.Context())
+	if err != nil {
+		customerrors.ServerErrorResponse(w, r, err)
+		return
+	}
+
+	categories, err := h.CategoryRepository.GetDeleted(r.Context())
+	if err != nil {
+		customerrors.ServerErrorResponse(w, r, err)
+		return
+	}
+
+	err = utils.WriteJSON(w, http.StatusOK, utils.Envelope{"items": items, "categories": categories}, nil, logger.Logger)
+	if err != nil {
+		customerrors.ServerErrorResponse(w, r, err)
+	}
+}
+
+func (h *AdminHandler) PostRestoreItem(w http.ResponseWriter, r *http.Request) {
+	id, convErr := strconv.ParseInt(chi.URLParam(r, "item_id"), 10, 64)
+
+	if convErr != nil {
+		customerrors.BadRequestResponse(w, r, convErr)
+		return
+	}
+
+	var item models.Item
+
+	err := h.TxManager.WithinTx(r.Context(), func(ctx context.Context, repos *repositories.Repositories) error {
+		var err error
+		item, err = repos.ItemRepository.Restore(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		// Price changes that fell due while the item was in the trash are
+		// dropped rather than applied late.
+		if _, err := repos.PriceRepository.CancelDue(ctx, id, time.Now()); err != nil {
+			return err
+		}
+
+		return recordAudit(ctx, repos, r, auditRestore, "item", id, nil, item)
+	})
+
+	if errors.Is(err, repositories.ErrNotFound) {
+		customerrors.NotFoundResponse(w, r)
+		return
+	}
+
+	if err != nil {
+		customerrors.ServerErrorResponse(w, r, err)
+		return
+	}
+
+	err = utils.WriteJSON(w, http.StatusOK, utils.Envelope{"item": item}, nil, logger.Logger)
+	if err != nil {
+		customerrors.ServerErrorResponse(w, r, err)
+	}
+}
+
+func (h *AdminHandler) PostRestoreCategory(w http.ResponseWriter, r *http.Request) {
+	id, convErr := strconv.ParseInt(chi.URLParam(r, "category_id"), 10, 64)
+
+	if convErr != nil {
+		customerrors.BadRequestResponse(w, r, convErr)
+		return
+	}
+
+	var category models.Category
+
+	err := h.TxManager.WithinTx(r.Context(), func(ctx context.Context, repos *repositories.Repositories) error {
+		var err error
+		category, err = repos.CategoryRepository.Restore(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		return recordAudit(ctx, repos, r, auditRestore, "category", id, nil, category)
+	})
+
+	if errors.Is(err, repositories.ErrNotFound) {
+		customerrors.NotFoundResponse(w, r)
+		return
+	}
+
+	if err != nil {
+		customerrors.ServerErrorResponse(w, r, err)
+		return
+	}
+
+	err = utils.WriteJSON(w, http.StatusOK, utils.Envelope{"category": category}, nil, logger.Logger)
+	if err != nil {
+		customerrors.ServerErrorResponse(w, r, err)
+	}
+}
+
+func (h *AdminHandler) GetExchangeRates(w http.ResponseWriter, r *http.Request) {
+	rates, err := h.ExchangeRateRepository.GetAll(r.Context())
+	if err != nil {
+		customerrors.ServerErrorResponse(w, r, err)
+		return
+	}
+
+	err = utils.WriteJSON(w, http.StatusOK, utils.Envelope{"exchange_rates": rates}, nil, logger.Logger)
+	if err != nil {
+		customerrors.ServerErrorResponse(w, r, err)
+	}
+}
+
+type exchangeRateRequest struct {
+	Rate string `json:"rate"`
+}
+
+// exchangeRatePair reads the base and quote currencies from the URL.
+func exchangeRatePair(r *http.Request) (string, string, error) {
+	base, err := money.Normalize(chi.URLParam(r, "base"))
+	if err != nil {
+		return "", "", err
+	}
+
+	quote, err := money.Normalize(chi.URLParam(r, "quote"))
+	if err != nil {
+		return "", "", err
+	}
+
+	if base == quote {
+		return "", "", errors.New("base and quote must be different currencies")
+	}
+
+	return base, quote, nil
+}
+
+func (h *AdminHandler) PutExchangeRate(w http.ResponseWriter, r *http.Request) {
+	base, quote, pairErr := exchangeRatePair(r)
+
+	if pairErr != nil {
+		customerrors.BadRequestResponse(w, r, pairErr)
+		return
+	}
+
+	var req exchangeRateRequest
+
+	if err := utils.ReadJSON(w, r, &req, logger.Logger); err != nil {
+		customerrors.BadRequestResponse(w, r, err)
+		return
+	}
+
+	rate, parseErr := money.ParseRate(req.Rate)
+
+	if parseErr != nil {
+		customerrors.BadRequestResponse(w, r, parseErr)
+		return
+	}
+
+	exchangeRate := models.ExchangeRate{Base: base, Quote: quote, Rate: rate.FloatString(12), Source: "manual"}
+
+	err := h.TxManager.WithinTx(r.Context(), func(ctx context.Context, repos *repositories.Repositories) error {
+		if err := repos.ExchangeRateRepository.Upsert(ctx, &exchangeRate); err != nil {
+			return err
+		}
+
+		return recordAudit(ctx, repos, r, auditUpdate, "exchange_rate", base+"/"+quote, nil, exchangeRate)
+	})
+
+	if err != nil {
+		customerrors.ServerErrorResponse(w, r, err)
+		return
+	}
+
+	err = utils.WriteJSON(w, http.StatusOK, utils.Envelope{"exchange_rate": exchangeRate}, nil, logger.Logger)
+	if err != nil {
+		customerrors.ServerErrorResponse(w, r, err)
+	}
+}
+
+func (h *AdminHandler) DeleteExchangeRate(w http.ResponseWriter, r *http.Request) {
+	base, quote, pairErr := exchangeRatePair(r)
+
+	if pairErr != nil {
+		customerrors.BadRequestResponse(w, r, pairErr)
+		return
+	}
+
+	err := h.TxManager.WithinTx(r.Context(), func(ctx context.Context, repos *repositories.Repositories) error {
+		rowsAffected, err := repos.ExchangeRateRepository.Delete(ctx, base, quote)
+		if err != nil {
+			return err
+		}
+
+		if rowsAffected == 0 {
+			return repositories.ErrNotFound
+		}
+
+		return recordAudit(ctx, repos, r, auditDelete, "exchange_rate", base+"/"+quote, nil, nil)
+	})
+
+	if errors.Is(err, repositories.ErrNotFound) {
+		customerrors.NotFoundResponse(w, r)
+		return
+	}
+
+	if err != nil {
+		customerrors.ServerErrorResponse(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
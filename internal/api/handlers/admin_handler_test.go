@@ -0,0 +1,75 @@
+package handlers_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+	"training/proj/internal/api/models"
+	"training/proj/internal/db/repositories"
+	"training/proj/internal/jobs"
+)
+
+func TestScheduledPricesAreAuditedAndDroppedOnRestore(t *testing.T) {
+	api := newTestAPI(t)
+	admin := api.token("1", true)
+	ctx := context.Background()
+
+	api.expect(api.do(http.MethodPost, "/api/v1/items/", map[string]any{"item": "apple", "price": 250}, admin), http.StatusCreated, nil)
+	api.expect(api.do(http.MethodPost, "/api/v1/items/", map[string]any{"item": "pear", "price": 300}, admin), http.StatusCreated, nil)
+
+	var scheduled struct {
+		Change models.PriceChange `json:"scheduled_price"`
+	}
+	body := map[string]any{"price": 400, "effective_at": time.Now().Add(time.Hour)}
+	api.expect(api.do(http.MethodPost, "/api/v1/items/1/prices/scheduled", body, admin), http.StatusCreated, &scheduled)
+
+	if scheduled.Change.PriceID == 0 || scheduled.Change.Price != 400 {
+		t.Errorf("scheduled_price = %+v, want a change to 400", scheduled.Change)
+	}
+
+	// Changes that are already due, which the API does not accept.
+	for _, id := range []int64{1, 2} {
+		err := api.repos.PriceRepository.Create(ctx, &models.PriceChange{ItemID: id, Price: 100, EffectiveAt: time.Now().Add(-time.Minute)})
+		if err != nil {
+			t.Fatalf("create price: %v", err)
+		}
+	}
+
+	api.expect(api.do(http.MethodDelete, "/api/v1/items/2", nil, admin), http.StatusNoContent, nil)
+
+	apply := jobs.ApplyScheduledPrices(api.repos.TxManager)
+
+	stats, err := apply(ctx)
+	if err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	if stats != (jobs.PriceStats{Applied: 1, Pending: 1}) {
+		t.Errorf("stats = %+v, want 1 applied and 1 pending", stats)
+	}
+
+	entries, err := api.repos.AuditRepository.GetAll(ctx, repositories.AuditFilter{Actor: "scheduler", Limit: 10})
+	if err != nil {
+		t.Fatalf("audit: %v", err)
+	}
+	if len(entries) != 1 || entries[0].EntityID != "1" || entries[0].Action != "update" {
+		t.Errorf("scheduler audit entries = %+v, want one update of item 1", entries)
+	}
+
+	api.expect(api.do(http.MethodPost, "/api/v1/admin/trash/items/2/restore", nil, admin), http.StatusOK, nil)
+
+	stats, err = apply(ctx)
+	if err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	if stats != (jobs.PriceStats{}) {
+		t.Errorf("stats after restore = %+v, want nothing left to apply", stats)
+	}
+
+	var pear models.Item
+	api.expect(api.do(http.MethodGet, "/api/v1/items/2", nil, ""), http.StatusOK, &pear)
+
+	if pear.Price != 300 {
+		t.Errorf("pear price = %d, want 300", pear.Price)
+	}
+}
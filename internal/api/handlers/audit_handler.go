@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"training/proj/internal/api/models"
+	"training/proj/internal/db/repositories"
+
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/jwtauth/v5"
+)
+
+const (
+	auditCreate   = "create"
+	auditUpdate   = "update"
+	auditDelete   = "delete"
+	auditLink     = "link"
+	auditRestore  = "restore"
+	auditRollback = "rollback"
+)
+
+// newAuditEntry describes a change made by the request. The actor comes from
+// the JWT claims, so it is empty for unauthenticated requests.
+func newAuditEntry(r *http.Request, action string, entityType string, entityID any, before any, after any) (*models.AuditEntry, error) {
+	entry := &models.AuditEntry{
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   fmt.Sprint(entityID),
+		RequestID:  chimiddleware.GetReqID(r.Context()),
+		IP:         clientIP(r),
+	}
+
+	entry.ActorID, entry.Actor = requestActor(r)
+
+	var err error
+
+	if before != nil {
+		if entry.Before, err = json.Marshal(before); err != nil {
+			return nil, err
+		}
+	}
+
+	if after != nil {
+		if entry.After, err = json.Marshal(after); err != nil {
+			return nil, err
+		}
+	}
+
+	return entry, nil
+}
+
+func recordAudit(ctx context.Context, repos *repositories.Repositories, r *http.Request, action string, entityType string, entityID any, before any, after any) error {
+	entry, err := newAuditEntry(r, action, entityType, entityID, before, after)
+	if err != nil {
+		return err
+	}
+
+	return repos.AuditRepository.Create(ctx, entry)
+}
+
+// requestActor returns the user id and email from the JWT claims, or empty
+// strings for unauthenticated requests.
+func requestActor(r *http.Request) (id string, email string) {
+	_, claims, err := jwtauth.FromContext(r.Context())
+	if err != nil {
+		return "", ""
+	}
+
+	if v, ok := claims["user_id"]; ok {
+		id = fmt.Sprint(v)
+	}
+	email, _ = claims["email"].(string)
+
+	return id, email
+}
+
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
@@ -1,27 +1,43 @@
 package handlers
 
 import (
-	"database/sql"
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
 	"training/proj/internal/api/models"
 	"training/proj/internal/customerrors"
 	"training/proj/internal/db/repositories"
+	"training/proj/internal/tax"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5/pgconn"
 )
 
 type CategoryHandler struct {
-	CategoryRepository     *repositories.CategoryRepository
-	CategoryItemRepository *repositories.CategoryItemRepository
+	CategoryRepository     repositories.CategoryRepositoryInterface
+	CategoryItemRepository repositories.CategoryItemRepositoryInterface
+	RevisionRepository     repositories.RevisionRepositoryInterface
+	TaxRepository          repositories.TaxRepositoryInterface
+	TxManager              repositories.TxManager
+
+	// TaxCalculator works out the tax shown with ?tax_region=.
+	TaxCalculator tax.Calculator
 }
 
-func NewCategoryHandler(cr *repositories.CategoryRepository, cir *repositories.CategoryItemRepository) *CategoryHandler {
+func NewCategoryHandler(cr repositories.CategoryRepositoryInterface, cir repositories.CategoryItemRepositoryInterface,
+	rr repositories.RevisionRepositoryInterface, tr repositories.TaxRepositoryInterface, tc tax.Calculator,
+	tm repositories.TxManager) *CategoryHandler {
 	return &CategoryHandler{
 		CategoryRepository:     cr,
 		CategoryItemRepository: cir,
+		RevisionRepository:     rr,
+		TaxRepository:          tr,
+		TxManager:              tm,
+		TaxCalculator:          tc,
 	}
 }
 
@@ -40,10 +56,25 @@ func (h *CategoryHandler) PutCategoryItem(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	crudErr := h.CategoryItemRepository.Create(categoryId, itemId)
+	crudErr := h.TxManager.WithinTx(r.Context(), func(ctx context.Context, repos *repositories.Repositories) error {
+		err := repos.CategoryItemRepository.Create(ctx, categoryId, itemId)
+		if err != nil {
+			return err
+		}
 
-	if crudErr != nil {
-		switch crudErr.Code {
+		link := models.CategoryItem{CategoryID: categoryId, ItemID: itemId}
+		return recordAudit(ctx, repos, r, auditLink, "category_item", fmt.Sprintf("%d:%d", categoryId, itemId), nil, link)
+	})
+
+	if errors.Is(crudErr, repositories.ErrNotFound) {
+		customerrors.NotFoundResponse(w, r)
+		return
+	}
+
+	var pgErr *pgconn.PgError
+
+	if errors.As(crudErr, &pgErr) {
+		switch pgErr.Code {
 		case pgerrcode.UniqueViolation:
 			customerrors.EditConflictResponse(w, r)
 		case pgerrcode.ForeignKeyViolation:
@@ -54,11 +85,16 @@ func (h *CategoryHandler) PutCategoryItem(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	if crudErr != nil {
+		customerrors.ServerErrorResponse(w, r, crudErr)
+		return
+	}
+
 	w.WriteHeader(http.StatusNoContent)
 }
 
 func (h *CategoryHandler) GetAllCategories(w http.ResponseWriter, r *http.Request) {
-	categories, crudErr := h.CategoryRepository.GetAll()
+	categories, crudErr := h.CategoryRepository.GetAll(r.Context())
 
 	if crudErr != nil {
 		customerrors.ServerErrorResponse(w, r, crudErr)
@@ -77,9 +113,9 @@ func (h *CategoryHandler) GetCategory(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	category, crudErr := h.CategoryRepository.GetById(id)
+	category, crudErr := h.CategoryRepository.GetById(r.Context(), id)
 
-	if crudErr == sql.ErrNoRows {
+	if errors.Is(crudErr, repositories.ErrNotFound) {
 		customerrors.NotFoundResponse(w, r)
 		return
 	}
@@ -103,7 +139,21 @@ func (h *CategoryHandler) PostCategory(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	categoryResp, crudErr := h.CategoryRepository.Create(&categoryReq)
+	var categoryResp models.Category
+
+	crudErr := h.TxManager.WithinTx(r.Context(), func(ctx context.Context, repos *repositories.Repositories) error {
+		var err error
+		categoryResp, err = repos.CategoryRepository.Create(ctx, &categoryReq)
+		if err != nil {
+			return err
+		}
+
+		if err := recordRevision(ctx, repos, r, "category", categoryResp.CategoryID, models.NewCategorySnapshot(categoryResp)); err != nil {
+			return err
+		}
+
+		return recordAudit(ctx, repos, r, auditCreate, "category", categoryResp.CategoryID, nil, categoryResp)
+	})
 
 	if crudErr != nil {
 		customerrors.ServerErrorResponse(w, r, crudErr)
@@ -122,15 +172,31 @@ func (h *CategoryHandler) DeleteCategory(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	rowsAffecetd, crudErr := h.CategoryRepository.Delete(int64(id))
+	crudErr := h.TxManager.WithinTx(r.Context(), func(ctx context.Context, repos *repositories.Repositories) error {
+		before, err := repos.CategoryRepository.GetById(ctx, id)
+		if err != nil {
+			return err
+		}
 
-	if crudErr != nil {
-		customerrors.ServerErrorResponse(w, r, crudErr)
+		rowsAffected, err := repos.CategoryRepository.Delete(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if rowsAffected == 0 {
+			return repositories.ErrNotFound
+		}
+
+		return recordAudit(ctx, repos, r, auditDelete, "category", id, before, nil)
+	})
+
+	if errors.Is(crudErr, repositories.ErrNotFound) {
+		customerrors.NotFoundResponse(w, r)
 		return
 	}
 
-	if rowsAffecetd == 0 {
-		customerrors.NotFoundResponse(w, r)
+	if crudErr != nil {
+		customerrors.ServerErrorResponse(w, r, crudErr)
 		return
 	}
 
@@ -154,9 +220,27 @@ func (h *CategoryHandler) PutCategory(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	categoryResp, crudErr := h.CategoryRepository.Update(id, &categoryReq)
+	var categoryResp models.Category
+
+	crudErr := h.TxManager.WithinTx(r.Context(), func(ctx context.Context, repos *repositories.Repositories) error {
+		before, err := repos.CategoryRepository.GetById(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		categoryResp, err = repos.CategoryRepository.Update(ctx, id, &categoryReq)
+		if err != nil {
+			return err
+		}
 
-	if crudErr == sql.ErrNoRows {
+		if err := recordRevision(ctx, repos, r, "category", id, models.NewCategorySnapshot(categoryResp)); err != nil {
+			return err
+		}
+
+		return recordAudit(ctx, repos, r, auditUpdate, "category", id, before, categoryResp)
+	})
+
+	if errors.Is(crudErr, repositories.ErrNotFound) {
 		customerrors.NotFoundResponse(w, r)
 		return
 	}
@@ -178,13 +262,49 @@ func (h *CategoryHandler) GetCategoryItems(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	items, crudErr := h.CategoryRepository.GetCategoryItems(id)
+	currency, currencyErr := requestedCurrency(r)
+
+	if currencyErr != nil {
+		customerrors.BadRequestResponse(w, r, currencyErr)
+		return
+	}
+
+	region, regionErr := requestedTaxRegion(r)
 
-	if crudErr == sql.ErrNoRows {
+	if regionErr != nil {
+		customerrors.BadRequestResponse(w, r, regionErr)
+		return
+	}
+
+	var items []models.Item
+
+	crudErr := h.TxManager.WithinTx(r.Context(), func(ctx context.Context, repos *repositories.Repositories) error {
+		var err error
+		items, err = repos.CategoryRepository.GetCategoryItems(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if err := convertPrices(ctx, repos.ExchangeRateRepository, items, currency); err != nil {
+			return err
+		}
+
+		if err := applySales(ctx, repos.SaleRepository, items); err != nil {
+			return err
+		}
+
+		return applyTax(ctx, repos.TaxRepository, h.TaxCalculator, items, region)
+	}, repositories.WithIsolation(repositories.RepeatableRead), repositories.WithReadOnly())
+
+	if errors.Is(crudErr, repositories.ErrNotFound) {
 		customerrors.NotFoundResponse(w, r)
 		return
 	}
 
+	if priceErrorResponse(w, r, crudErr) {
+		return
+	}
+
 	if crudErr != nil {
 		customerrors.ServerErrorResponse(w, r, crudErr)
 		return
@@ -193,3 +313,68 @@ func (h *CategoryHandler) GetCategoryItems(w http.ResponseWriter, r *http.Reques
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(items)
 }
+
+func (h *CategoryHandler) GetCategoryRevisions(w http.ResponseWriter, r *http.Request) {
+	writeRevisions(w, r, h.RevisionRepository, "category", "category_id")
+}
+
+func (h *CategoryHandler) GetCategoryRevisionDiff(w http.ResponseWriter, r *http.Request) {
+	writeRevisionDiff(w, r, h.RevisionRepository, "category", "category_id")
+}
+
+// PostCategoryRevisionRestore puts the category back to the state of an earlier
+// revision. The result is stored as a new revision.
+func (h *CategoryHandler) PostCategoryRevisionRestore(w http.ResponseWriter, r *http.Request) {
+	id, revision, convErr := revisionParams(r, "category_id")
+
+	if convErr != nil {
+		customerrors.BadRequestResponse(w, r, convErr)
+		return
+	}
+
+	var categoryResp models.Category
+
+	crudErr := h.TxManager.WithinTx(r.Context(), func(ctx context.Context, repos *repositories.Repositories) error {
+		before, err := repos.CategoryRepository.GetById(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		rev, err := repos.RevisionRepository.Get(ctx, "category", id, revision)
+		if err != nil {
+			return err
+		}
+
+		snapshot := models.NewCategorySnapshot(before)
+		if err := json.Unmarshal(rev.Data, &snapshot); err != nil {
+			return err
+		}
+
+		categoryReq := before
+		snapshot.Apply(&categoryReq)
+
+		categoryResp, err = repos.CategoryRepository.Update(ctx, id, &categoryReq)
+		if err != nil {
+			return err
+		}
+
+		if err := recordRevision(ctx, repos, r, "category", id, models.NewCategorySnapshot(categoryResp)); err != nil {
+			return err
+		}
+
+		return recordAudit(ctx, repos, r, auditRollback, "category", id, before, categoryResp)
+	})
+
+	if errors.Is(crudErr, repositories.ErrNotFound) {
+		customerrors.NotFoundResponse(w, r)
+		return
+	}
+
+	if crudErr != nil {
+		customerrors.ServerErrorResponse(w, r, crudErr)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(categoryResp)
+}
@@ -0,0 +1,305 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"training/proj/internal/api/models"
+	"training/proj/internal/coupons"
+	"training/proj/internal/customerrors"
+	"training/proj/internal/db/repositories"
+	"training/proj/internal/logger"
+	"training/proj/internal/money"
+	"training/proj/internal/utils"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+var couponCodePattern = regexp.MustCompile(`^[A-Z0-9_-]+$`)
+
+var errInvalidCoupon = errors.New("invalid coupon")
+
+// validateCoupon normalizes the coupon's code and currency, clears the
+// fields that do not apply to its kind and checks the rest.
+func validateCoupon(coupon *models.Coupon) error {
+	coupon.Code = coupons.NormalizeCode(coupon.Code)
+
+	if err := validator.New().Struct(coupon); err != nil {
+		return err
+	}
+
+	if !couponCodePattern.MatchString(coupon.Code) {
+		return errors.New("code may only contain letters, digits, '-' and '_'")
+	}
+
+	switch coupon.Kind {
+	case models.CouponPercent:
+		if coupon.PercentOff == 0 {
+			return errors.New("percent_off is required for percent coupons")
+		}
+		coupon.AmountOff, coupon.Currency, coupon.BuyQuantity, coupon.GetQuantity = 0, "", 0, 0
+
+	case models.CouponFixed:
+		if coupon.AmountOff == 0 {
+			return errors.New("amount_off is required for fixed coupons")
+		}
+		currency, err := money.Normalize(coupon.Currency)
+		if err != nil {
+			return fmt.Errorf("currency is required for fixed coupons: %w", err)
+		}
+		coupon.Currency = currency
+		coupon.PercentOff, coupon.BuyQuantity, coupon.GetQuantity = 0, 0, 0
+
+	case models.CouponBuyXGetY:
+		if coupon.BuyQuantity == 0 || coupon.GetQuantity == 0 {
+			return errors.New("buy_quantity and get_quantity are required for buy_x_get_y coupons")
+		}
+		coupon.PercentOff, coupon.AmountOff, coupon.Currency = 0, 0, ""
+	}
+
+	if coupon.ValidFrom != nil && coupon.ValidUntil != nil && !coupon.ValidUntil.After(*coupon.ValidFrom) {
+		return errors.New("valid_until must be after valid_from")
+	}
+
+	coupon.ItemIDs = compactIDs(coupon.ItemIDs)
+	coupon.CategoryIDs = compactIDs(coupon.CategoryIDs)
+
+	return nil
+}
+
+func compactIDs(ids []int64) []int64 {
+	ids = slices.Clone(ids)
+	slices.Sort(ids)
+
+	if ids == nil {
+		return []int64{}
+	}
+
+	return slices.Compact(ids)
+}
+
+// checkCouponTargets makes sure the items and categories a coupon is
+// restricted to exist.
+func checkCouponTargets(ctx context.Context, repos *repositories.Repositories, coupon *models.Coupon) error {
+	for _, id := range coupon.ItemIDs {
+		if _, err := repos.ItemRepository.GetById(ctx, id); err != nil {
+			if errors.Is(err, repositories.ErrNotFound) {
+				return fmt.Errorf("%w: item %d does not exist", errInvalidCoupon, id)
+			}
+			return err
+		}
+	}
+
+	for _, id := range coupon.CategoryIDs {
+		if _, err := repos.CategoryRepository.GetById(ctx, id); err != nil {
+			if errors.Is(err, repositories.ErrNotFound) {
+				return fmt.Errorf("%w: category %d does not exist", errInvalidCoupon, id)
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+// couponErrorResponse maps the errors of coupon writes. It reports false if
+// err is not one of them.
+func couponErrorResponse(w http.ResponseWriter, r *http.Request, err error) bool {
+	var pgErr *pgconn.PgError
+
+	switch {
+	case errors.Is(err, repositories.ErrNotFound):
+		customerrors.NotFoundResponse(w, r)
+	case errors.Is(err, errInvalidCoupon):
+		customerrors.BadRequestResponse(w, r, err)
+	case errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation:
+		customerrors.ErrorResponse(w, r, http.StatusConflict, "a coupon with this code already exists")
+	default:
+		return false
+	}
+
+	return true
+}
+
+func (h *AdminHandler) GetCoupons(w http.ResponseWriter, r *http.Request) {
+	list, err := h.CouponRepository.GetAll(r.Context())
+	if err != nil {
+		customerrors.ServerErrorResponse(w, r, err)
+		return
+	}
+
+	err = utils.WriteJSON(w, http.StatusOK, utils.Envelope{"coupons": list}, nil, logger.Logger)
+	if err != nil {
+		customerrors.ServerErrorResponse(w, r, err)
+	}
+}
+
+func (h *AdminHandler) GetCoupon(w http.ResponseWriter, r *http.Request) {
+	id, convErr := strconv.ParseInt(chi.URLParam(r, "coupon_id"), 10, 64)
+
+	if convErr != nil {
+		customerrors.BadRequestResponse(w, r, convErr)
+		return
+	}
+
+	coupon, err := h.CouponRepository.GetById(r.Context(), id)
+
+	if errors.Is(err, repositories.ErrNotFound) {
+		customerrors.NotFoundResponse(w, r)
+		return
+	}
+
+	if err != nil {
+		customerrors.ServerErrorResponse(w, r, err)
+		return
+	}
+
+	err = utils.WriteJSON(w, http.StatusOK, utils.Envelope{"coupon": coupon}, nil, logger.Logger)
+	if err != nil {
+		customerrors.ServerErrorResponse(w, r, err)
+	}
+}
+
+func (h *AdminHandler) PostCoupon(w http.ResponseWriter, r *http.Request) {
+	var req models.Coupon
+
+	if err := utils.ReadJSON(w, r, &req, logger.Logger); err != nil {
+		customerrors.BadRequestResponse(w, r, err)
+		return
+	}
+
+	if err := validateCoupon(&req); err != nil {
+		customerrors.BadRequestResponse(w, r, err)
+		return
+	}
+
+	var coupon models.Coupon
+
+	err := h.TxManager.WithinTx(r.Context(), func(ctx context.Context, repos *repositories.Repositories) error {
+		if err := checkCouponTargets(ctx, repos, &req); err != nil {
+			return err
+		}
+
+		var err error
+		coupon, err = repos.CouponRepository.Create(ctx, &req)
+		if err != nil {
+			return err
+		}
+
+		return recordAudit(ctx, repos, r, auditCreate, "coupon", coupon.CouponID, nil, coupon)
+	})
+
+	if couponErrorResponse(w, r, err) {
+		return
+	}
+
+	if err != nil {
+		customerrors.ServerErrorResponse(w, r, err)
+		return
+	}
+
+	err = utils.WriteJSON(w, http.StatusCreated, utils.Envelope{"coupon": coupon}, nil, logger.Logger)
+	if err != nil {
+		customerrors.ServerErrorResponse(w, r, err)
+	}
+}
+
+func (h *AdminHandler) PutCoupon(w http.ResponseWriter, r *http.Request) {
+	id, convErr := strconv.ParseInt(chi.URLParam(r, "coupon_id"), 10, 64)
+
+	if convErr != nil {
+		customerrors.BadRequestResponse(w, r, convErr)
+		return
+	}
+
+	var req models.Coupon
+
+	if err := utils.ReadJSON(w, r, &req, logger.Logger); err != nil {
+		customerrors.BadRequestResponse(w, r, err)
+		return
+	}
+
+	if err := validateCoupon(&req); err != nil {
+		customerrors.BadRequestResponse(w, r, err)
+		return
+	}
+
+	var coupon models.Coupon
+
+	err := h.TxManager.WithinTx(r.Context(), func(ctx context.Context, repos *repositories.Repositories) error {
+		before, err := repos.CouponRepository.GetById(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if req.MaxUses != nil && *req.MaxUses < before.Uses {
+			return fmt.Errorf("%w: max_uses cannot be lower than the %d uses so far", errInvalidCoupon, before.Uses)
+		}
+
+		if err := checkCouponTargets(ctx, repos, &req); err != nil {
+			return err
+		}
+
+		coupon, err = repos.CouponRepository.Update(ctx, id, &req)
+		if err != nil {
+			return err
+		}
+
+		return recordAudit(ctx, repos, r, auditUpdate, "coupon", id, before, coupon)
+	})
+
+	if couponErrorResponse(w, r, err) {
+		return
+	}
+
+	if err != nil {
+		customerrors.ServerErrorResponse(w, r, err)
+		return
+	}
+
+	err = utils.WriteJSON(w, http.StatusOK, utils.Envelope{"coupon": coupon}, nil, logger.Logger)
+	if err != nil {
+		customerrors.ServerErrorResponse(w, r, err)
+	}
+}
+
+func (h *AdminHandler) DeleteCoupon(w http.ResponseWriter, r *http.Request) {
+	id, convErr := strconv.ParseInt(chi.URLParam(r, "coupon_id"), 10, 64)
+
+	if convErr != nil {
+		customerrors.BadRequestResponse(w, r, convErr)
+		return
+	}
+
+	err := h.TxManager.WithinTx(r.Context(), func(ctx context.Context, repos *repositories.Repositories) error {
+		before, err := repos.CouponRepository.GetById(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if _, err := repos.CouponRepository.Delete(ctx, id); err != nil {
+			return err
+		}
+
+		return recordAudit(ctx, repos, r, auditDelete, "coupon", id, before, nil)
+	})
+
+	if errors.Is(err, repositories.ErrNotFound) {
+		customerrors.NotFoundResponse(w, r)
+		return
+	}
+
+	if err != nil {
+		customerrors.ServerErrorResponse(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"training/proj/internal/api/models"
+	"training/proj/internal/customerrors"
+	"training/proj/internal/db/repositories"
+	"training/proj/internal/money"
+	"training/proj/internal/tax"
+)
+
+var errCurrencyMismatch = errors.New("currency mismatch")
+
+// checkItemCurrency rejects a price given in a currency other than the one
+// the item is configured for. An empty currency means the item's own.
+func checkItemCurrency(item models.Item, currency string) error {
+	if currency == "" {
+		return nil
+	}
+
+	code, err := money.Normalize(currency)
+	if err != nil {
+		return err
+	}
+
+	if code != item.Currency {
+		return fmt.Errorf("%w: item %d is priced in %s", errCurrencyMismatch, item.ItemID, item.Currency)
+	}
+
+	return nil
+}
+
+// requestedCurrency returns the currency asked for with ?currency=, or an
+// empty string if prices should stay in each item's base currency.
+func requestedCurrency(r *http.Request) (string, error) {
+	currency := r.URL.Query().Get("currency")
+	if currency == "" {
+		return "", nil
+	}
+
+	return money.Normalize(currency)
+}
+
+func loadRates(ctx context.Context, repo repositories.ExchangeRateRepositoryInterface) (*money.Rates, error) {
+	stored, err := repo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rates := money.NewRates()
+
+	for _, rate := range stored {
+		value, err := money.ParseRate(rate.Rate)
+		if err != nil {
+			return nil, err
+		}
+		rates.Add(rate.Base, rate.Quote, value)
+	}
+
+	return rates, nil
+}
+
+// convertPrices converts the items' prices to currency in place, keeping
+// the original price in BasePrice.
+func convertPrices(ctx context.Context, repo repositories.ExchangeRateRepositoryInterface, items []models.Item, currency string) error {
+	if currency == "" || len(items) == 0 {
+		return nil
+	}
+
+	rates, err := loadRates(ctx, repo)
+	if err != nil {
+		return err
+	}
+
+	return applyRates(rates, items, currency)
+}
+
+func applyRates(rates *money.Rates, items []models.Item, currency string) error {
+	for i := range items {
+		base := money.Money{Amount: items[i].Price, Currency: items[i].Currency}
+
+		converted, err := rates.Convert(base, currency)
+		if err != nil {
+			return err
+		}
+
+		items[i].Price = converted.Amount
+		items[i].Currency = converted.Currency
+		if base.Currency != converted.Currency {
+			items[i].BasePrice = &base
+		}
+	}
+
+	return nil
+}
+
+// priceErrorResponse answers with 400 for bad currency or tax region input
+// and 422 when a price cannot be converted or taxed. It reports false for
+// any other error.
+func priceErrorResponse(w http.ResponseWriter, r *http.Request, err error) bool {
+	switch {
+	case errors.Is(err, money.ErrUnknownCurrency), errors.Is(err, errCurrencyMismatch), errors.Is(err, tax.ErrUnknownRegion):
+		customerrors.BadRequestResponse(w, r, err)
+	case errors.Is(err, money.ErrNoRate), errors.Is(err, money.ErrOverflow), errors.Is(err, tax.ErrNoRate):
+		customerrors.ErrorResponse(w, r, http.StatusUnprocessableEntity, err.Error())
+	default:
+		return false
+	}
+
+	return true
+}
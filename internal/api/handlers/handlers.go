@@ -1,18 +1,34 @@
 package handlers
 
-import "training/proj/internal/db/repositories"
+import (
+	"training/proj/internal/db/repositories"
+	"training/proj/internal/tax"
+)
 
 type Handlers struct {
 	CategoryHandler *CategoryHandler
 	ItemHandler     *ItemHandler
 	UserHandler     *UserHandler
+	HealthHandler   *HealthHandler
+	AdminHandler    *AdminHandler
+	QuoteHandler    *QuoteHandler
+	ShippingHandler *ShippingHandler
+	ReviewHandler   *ReviewHandler
 }
 
-func NewHandlers(cr *repositories.CategoryRepository, cir *repositories.CategoryItemRepository,
-	ir *repositories.ItemRepository, ur *repositories.UserRepository) *Handlers {
+func NewHandlers(r *repositories.Repositories, jwtSecret string, defaultCurrency string) *Handlers {
+	taxCalculator := tax.NewTableCalculator(r.TaxRepository)
+
 	return &Handlers{
-		CategoryHandler: NewCategoryHandler(cr, cir),
-		ItemHandler:     NewItemHandler(ir),
-		UserHandler:     NewUserHandler(ur),
+		CategoryHandler: NewCategoryHandler(r.CategoryRepository, r.CategoryItemRepository, r.RevisionRepository, r.TaxRepository, taxCalculator, r.TxManager),
+		ItemHandler: NewItemHandler(r.ItemRepository, r.RevisionRepository, r.PriceRepository, r.ExchangeRateRepository, r.SaleRepository,
+			r.TaxRepository, taxCalculator, r.TxManager, defaultCurrency),
+		UserHandler:   NewUserHandler(r.UserRepository, r.TxManager, jwtSecret),
+		HealthHandler: NewHealthHandler(),
+		AdminHandler: NewAdminHandler(r.ItemRepository, r.CategoryRepository, r.AuditRepository, r.ExchangeRateRepository, r.CouponRepository,
+			r.SaleRepository, r.TaxRepository, r.ShippingRepository, r.TxManager),
+		QuoteHandler:    NewQuoteHandler(r.ItemRepository, r.ExchangeRateRepository, r.SaleRepository, r.CouponRepository, r.TxManager),
+		ShippingHandler: NewShippingHandler(r.ItemRepository, r.SaleRepository, r.ShippingRepository, r.ExchangeRateRepository, r.TxManager),
+		ReviewHandler:   NewReviewHandler(r.ItemRepository, r.ReviewRepository, r.TxManager),
 	}
 }
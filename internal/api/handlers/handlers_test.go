@@ -0,0 +1,101 @@
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"training/proj/internal/api/routes"
+	"training/proj/internal/config"
+	"training/proj/internal/db/memory"
+	"training/proj/internal/db/repositories"
+	"training/proj/internal/logger"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/jwtauth/v5"
+	"go.uber.org/zap"
+)
+
+const testSecret = "test-secret"
+
+func TestMain(m *testing.M) {
+	logger.Logger = zap.NewNop().Sugar()
+	m.Run()
+}
+
+// testAPI serves the full router over the in-memory store.
+type testAPI struct {
+	t      *testing.T
+	router http.Handler
+	repos  *repositories.Repositories
+}
+
+func newTestAPI(t *testing.T) *testAPI {
+	t.Helper()
+
+	cfg := &config.Config{}
+	cfg.JWT.Secret = testSecret
+	cfg.Pricing.Currency = "EUR"
+
+	repos := memory.NewRepositories(memory.NewStore())
+
+	r := chi.NewRouter()
+	routes.SetupRoutes(r, cfg.InitializeHandlers(repos), cfg)
+
+	return &testAPI{t: t, router: r, repos: repos}
+}
+
+// token signs a JWT for the user the way Login does.
+func (a *testAPI) token(userId string, isAdmin bool) string {
+	a.t.Helper()
+
+	_, token, err := jwtauth.New("HS256", []byte(testSecret), nil).Encode(map[string]any{
+		"user_id":  userId,
+		"email":    userId + "@example.com",
+		"is_admin": isAdmin,
+	})
+	if err != nil {
+		a.t.Fatalf("sign token: %v", err)
+	}
+
+	return token
+}
+
+// do sends body, if it is not nil, as JSON and returns the response.
+func (a *testAPI) do(method string, path string, body any, token string) *httptest.ResponseRecorder {
+	a.t.Helper()
+
+	var buf bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&buf).Encode(body); err != nil {
+			a.t.Fatalf("encode body: %v", err)
+		}
+	}
+
+	req := httptest.NewRequest(method, path, &buf)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	rec := httptest.NewRecorder()
+	a.router.ServeHTTP(rec, req)
+
+	return rec
+}
+
+// expect fails the test unless the response has the status, and decodes
+// its body into dst if dst is not nil.
+func (a *testAPI) expect(rec *httptest.ResponseRecorder, status int, dst any) {
+	a.t.Helper()
+
+	if rec.Code != status {
+		a.t.Fatalf("status = %d, want %d: %s", rec.Code, status, rec.Body.String())
+	}
+
+	if dst != nil {
+		if err := json.Unmarshal(rec.Body.Bytes(), dst); err != nil {
+			a.t.Fatalf("decode %s: %v", rec.Body.String(), err)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+	"training/proj/internal/logger"
+	"training/proj/internal/utils"
+)
+
+type HealthCheck func(ctx context.Context) (interface{}, error)
+
+type namedCheck struct {
+	name     string
+	check    HealthCheck
+	advisory bool
+}
+
+type HealthHandler struct {
+	Timeout time.Duration
+	checks  []namedCheck
+	ready   atomic.Bool
+}
+
+type checkResult struct {
+	Status     string      `json:"status"`
+	DurationMs int64       `json:"duration_ms"`
+	Details    interface{} `json:"details,omitempty"`
+	Error      string      `json:"error,omitempty"`
+}
+
+func NewHealthHandler() *HealthHandler {
+	h := &HealthHandler{
+		Timeout: 2 * time.Second,
+	}
+	h.ready.Store(true)
+	return h
+}
+
+func (h *HealthHandler) AddCheck(name string, check HealthCheck) {
+	h.checks = append(h.checks, namedCheck{name: name, check: check})
+}
+
+// AddAdvisoryCheck registers a check that is reported in the readiness
+// response but does not make the instance unready when it fails.
+func (h *HealthHandler) AddAdvisoryCheck(name string, check HealthCheck) {
+	h.checks = append(h.checks, namedCheck{name: name, check: check, advisory: true})
+}
+
+func (h *HealthHandler) SetReady(ready bool) {
+	h.ready.Store(ready)
+}
+
+func (h *HealthHandler) Liveness(w http.ResponseWriter, r *http.Request) {
+	utils.WriteJSON(w, http.StatusOK, utils.Envelope{"status": "ok"}, nil, logger.Logger)
+}
+
+func (h *HealthHandler) Readiness(w http.ResponseWriter, r *http.Request) {
+	if !h.ready.Load() {
+		utils.WriteJSON(w, http.StatusServiceUnavailable, utils.Envelope{"status": "shutting_down"}, nil, logger.Logger)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.Timeout)
+	defer cancel()
+
+	results := make(map[string]checkResult, len(h.checks))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for _, c := range h.checks {
+		wg.Add(1)
+		go func(c namedCheck) {
+			defer wg.Done()
+
+			start := time.Now()
+			details, err := c.check(ctx)
+			res := checkResult{
+				Status:     "ok",
+				DurationMs: time.Since(start).Milliseconds(),
+				Details:    details,
+			}
+
+			if err != nil {
+				res.Status = "fail"
+				if c.advisory {
+					res.Status = "warn"
+				}
+				res.Error = err.Error()
+			}
+
+			mu.Lock()
+			results[c.name] = res
+			mu.Unlock()
+		}(c)
+	}
+
+	wg.Wait()
+
+	status := "ok"
+	code := http.StatusOK
+
+	for _, res := range results {
+		if res.Status == "fail" {
+			status = "unavailable"
+			code = http.StatusServiceUnavailable
+		}
+	}
+
+	utils.WriteJSON(w, code, utils.Envelope{"status": status, "checks": results}, nil, logger.Logger)
+}
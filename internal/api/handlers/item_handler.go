@@ -1,35 +1,103 @@
 package handlers
 
 import (
-	"database/sql"
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
 	"training/proj/internal/api/models"
 	"training/proj/internal/customerrors"
 	"training/proj/internal/db/repositories"
+	"training/proj/internal/metrics"
+	"training/proj/internal/money"
+	"training/proj/internal/tax"
 
 	"github.com/go-chi/chi/v5"
 )
 
 type ItemHandler struct {
-	ItemRepository *repositories.ItemRepository
+	ItemRepository         repositories.ItemRepositoryInterface
+	RevisionRepository     repositories.RevisionRepositoryInterface
+	PriceRepository        repositories.PriceRepositoryInterface
+	ExchangeRateRepository repositories.ExchangeRateRepositoryInterface
+	SaleRepository         repositories.SaleRepositoryInterface
+	TaxRepository          repositories.TaxRepositoryInterface
+	TxManager              repositories.TxManager
+
+	// TaxCalculator works out the tax shown with ?tax_region=.
+	TaxCalculator tax.Calculator
+
+	// DefaultCurrency is used for items created without a currency.
+	DefaultCurrency string
 }
 
-func NewItemHandler(ir *repositories.ItemRepository) *ItemHandler {
+func NewItemHandler(ir repositories.ItemRepositoryInterface, rr repositories.RevisionRepositoryInterface,
+	pr repositories.PriceRepositoryInterface, er repositories.ExchangeRateRepositoryInterface, sr repositories.SaleRepositoryInterface,
+	tr repositories.TaxRepositoryInterface, tc tax.Calculator, tm repositories.TxManager, defaultCurrency string) *ItemHandler {
 	return &ItemHandler{
-		ItemRepository: ir,
+		ItemRepository:         ir,
+		RevisionRepository:     rr,
+		PriceRepository:        pr,
+		ExchangeRateRepository: er,
+		SaleRepository:         sr,
+		TaxRepository:          tr,
+		TxManager:              tm,
+		TaxCalculator:          tc,
+		DefaultCurrency:        defaultCurrency,
 	}
 }
 
 func (h *ItemHandler) GetAllItems(w http.ResponseWriter, r *http.Request) {
-	items, crudErr := h.ItemRepository.GetAll()
+	currency, currencyErr := requestedCurrency(r)
+
+	if currencyErr != nil {
+		customerrors.BadRequestResponse(w, r, currencyErr)
+		return
+	}
+
+	region, regionErr := requestedTaxRegion(r)
+
+	if regionErr != nil {
+		customerrors.BadRequestResponse(w, r, regionErr)
+		return
+	}
+
+	sortBy := r.URL.Query().Get("sort")
+
+	if sortBy != "" && sortBy != itemsByRating {
+		customerrors.BadRequestResponse(w, r, fmt.Errorf("sort must be %q", itemsByRating))
+		return
+	}
+
+	items, crudErr := h.ItemRepository.GetAll(r.Context())
+
+	if crudErr == nil {
+		crudErr = convertPrices(r.Context(), h.ExchangeRateRepository, items, currency)
+	}
+
+	if crudErr == nil {
+		crudErr = applySales(r.Context(), h.SaleRepository, items)
+	}
+
+	if crudErr == nil {
+		crudErr = applyTax(r.Context(), h.TaxRepository, h.TaxCalculator, items, region)
+	}
+
+	if priceErrorResponse(w, r, crudErr) {
+		return
+	}
 
 	if crudErr != nil {
 		customerrors.ServerErrorResponse(w, r, crudErr)
 		return
 	}
 
+	if sortBy == itemsByRating {
+		sortByRating(items)
+	}
+
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(items)
 }
@@ -42,13 +110,43 @@ func (h *ItemHandler) GetItem(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	item, crudErr := h.ItemRepository.GetById(id)
+	currency, currencyErr := requestedCurrency(r)
+
+	if currencyErr != nil {
+		customerrors.BadRequestResponse(w, r, currencyErr)
+		return
+	}
+
+	region, regionErr := requestedTaxRegion(r)
 
-	if crudErr == sql.ErrNoRows {
+	if regionErr != nil {
+		customerrors.BadRequestResponse(w, r, regionErr)
+		return
+	}
+
+	item, crudErr := h.ItemRepository.GetById(r.Context(), id)
+
+	if crudErr == nil {
+		items := []models.Item{item}
+		crudErr = convertPrices(r.Context(), h.ExchangeRateRepository, items, currency)
+		if crudErr == nil {
+			crudErr = applySales(r.Context(), h.SaleRepository, items)
+		}
+		if crudErr == nil {
+			crudErr = applyTax(r.Context(), h.TaxRepository, h.TaxCalculator, items, region)
+		}
+		item = items[0]
+	}
+
+	if errors.Is(crudErr, repositories.ErrNotFound) {
 		customerrors.NotFoundResponse(w, r)
 		return
 	}
 
+	if priceErrorResponse(w, r, crudErr) {
+		return
+	}
+
 	if crudErr != nil {
 		customerrors.ServerErrorResponse(w, r, crudErr)
 		return
@@ -68,13 +166,51 @@ func (h *ItemHandler) PostItem(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	itemResp, err := h.ItemRepository.Create(&itemReq)
+	if itemReq.Currency == "" {
+		itemReq.Currency = h.DefaultCurrency
+	}
+
+	currency, currencyErr := money.Normalize(itemReq.Currency)
+
+	if currencyErr != nil {
+		customerrors.BadRequestResponse(w, r, currencyErr)
+		return
+	}
+
+	itemReq.Currency = currency
 
-	if err != nil {
-		customerrors.ServerErrorResponse(w, r, err)
+	if err := validateItemSize(itemReq); err != nil {
+		customerrors.BadRequestResponse(w, r, err)
 		return
 	}
 
+	var itemResp models.Item
+
+	crudErr := h.TxManager.WithinTx(r.Context(), func(ctx context.Context, repos *repositories.Repositories) error {
+		var err error
+		itemResp, err = repos.ItemRepository.Create(ctx, &itemReq)
+		if err != nil {
+			return err
+		}
+
+		if err := recordRevision(ctx, repos, r, "item", itemResp.ItemID, models.NewItemSnapshot(itemResp)); err != nil {
+			return err
+		}
+
+		if err := recordPrice(ctx, repos, r, nil, itemResp); err != nil {
+			return err
+		}
+
+		return recordAudit(ctx, repos, r, auditCreate, "item", itemResp.ItemID, nil, itemResp)
+	})
+
+	if crudErr != nil {
+		customerrors.ServerErrorResponse(w, r, crudErr)
+		return
+	}
+
+	metrics.ItemsCreatedTotal.Inc()
+
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(itemResp)
 }
@@ -87,15 +223,31 @@ func (h *ItemHandler) DeleteItem(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	rowsAffecetd, crudErr := h.ItemRepository.Delete(id)
+	crudErr := h.TxManager.WithinTx(r.Context(), func(ctx context.Context, repos *repositories.Repositories) error {
+		before, err := repos.ItemRepository.GetById(ctx, id)
+		if err != nil {
+			return err
+		}
 
-	if crudErr != nil {
-		customerrors.ServerErrorResponse(w, r, crudErr)
+		rowsAffected, err := repos.ItemRepository.Delete(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if rowsAffected == 0 {
+			return repositories.ErrNotFound
+		}
+
+		return recordAudit(ctx, repos, r, auditDelete, "item", id, before, nil)
+	})
+
+	if errors.Is(crudErr, repositories.ErrNotFound) {
+		customerrors.NotFoundResponse(w, r)
 		return
 	}
 
-	if rowsAffecetd == 0 {
-		customerrors.NotFoundResponse(w, r)
+	if crudErr != nil {
+		customerrors.ServerErrorResponse(w, r, crudErr)
 		return
 	}
 
@@ -119,13 +271,48 @@ func (h *ItemHandler) PutItem(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	itemResp, crudErr := h.ItemRepository.Update(id, &itemReq)
+	if err := validateItemSize(itemReq); err != nil {
+		customerrors.BadRequestResponse(w, r, err)
+		return
+	}
+
+	var itemResp models.Item
+
+	crudErr := h.TxManager.WithinTx(r.Context(), func(ctx context.Context, repos *repositories.Repositories) error {
+		before, err := repos.ItemRepository.GetById(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if err := checkItemCurrency(before, itemReq.Currency); err != nil {
+			return err
+		}
+
+		itemResp, err = repos.ItemRepository.Update(ctx, id, &itemReq)
+		if err != nil {
+			return err
+		}
 
-	if crudErr == sql.ErrNoRows {
+		if err := recordRevision(ctx, repos, r, "item", id, models.NewItemSnapshot(itemResp)); err != nil {
+			return err
+		}
+
+		if err := recordPrice(ctx, repos, r, &before, itemResp); err != nil {
+			return err
+		}
+
+		return recordAudit(ctx, repos, r, auditUpdate, "item", id, before, itemResp)
+	})
+
+	if errors.Is(crudErr, repositories.ErrNotFound) {
 		customerrors.NotFoundResponse(w, r)
 		return
 	}
 
+	if priceErrorResponse(w, r, crudErr) {
+		return
+	}
+
 	if crudErr != nil {
 		customerrors.ServerErrorResponse(w, r, crudErr)
 		return
@@ -143,9 +330,15 @@ func (h *ItemHandler) GetItemCategories(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	categories, crudErr := h.ItemRepository.GetItemCategories(id)
+	var categories []models.Category
+
+	crudErr := h.TxManager.WithinTx(r.Context(), func(ctx context.Context, repos *repositories.Repositories) error {
+		var err error
+		categories, err = repos.ItemRepository.GetItemCategories(ctx, id)
+		return err
+	}, repositories.WithIsolation(repositories.RepeatableRead), repositories.WithReadOnly())
 
-	if crudErr == sql.ErrNoRows {
+	if errors.Is(crudErr, repositories.ErrNotFound) {
 		customerrors.NotFoundResponse(w, r)
 		return
 	}
@@ -158,3 +351,74 @@ func (h *ItemHandler) GetItemCategories(w http.ResponseWriter, r *http.Request)
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(categories)
 }
+
+func (h *ItemHandler) GetItemRevisions(w http.ResponseWriter, r *http.Request) {
+	writeRevisions(w, r, h.RevisionRepository, "item", "item_id")
+}
+
+func (h *ItemHandler) GetItemRevisionDiff(w http.ResponseWriter, r *http.Request) {
+	writeRevisionDiff(w, r, h.RevisionRepository, "item", "item_id")
+}
+
+// PostItemRevisionRestore puts the item back to the state of an earlier
+// revision. The result is stored as a new revision.
+func (h *ItemHandler) PostItemRevisionRestore(w http.ResponseWriter, r *http.Request) {
+	id, revision, convErr := revisionParams(r, "item_id")
+
+	if convErr != nil {
+		customerrors.BadRequestResponse(w, r, convErr)
+		return
+	}
+
+	var itemResp models.Item
+
+	crudErr := h.TxManager.WithinTx(r.Context(), func(ctx context.Context, repos *repositories.Repositories) error {
+		before, err := repos.ItemRepository.GetById(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		rev, err := repos.RevisionRepository.Get(ctx, "item", id, revision)
+		if err != nil {
+			return err
+		}
+
+		// Revisions written before a column existed leave it out, so the
+		// revision is merged onto the current state rather than replacing it.
+		snapshot := models.NewItemSnapshot(before)
+		if err := json.Unmarshal(rev.Data, &snapshot); err != nil {
+			return err
+		}
+
+		itemReq := before
+		snapshot.Apply(&itemReq)
+
+		itemResp, err = repos.ItemRepository.Update(ctx, id, &itemReq)
+		if err != nil {
+			return err
+		}
+
+		if err := recordRevision(ctx, repos, r, "item", id, models.NewItemSnapshot(itemResp)); err != nil {
+			return err
+		}
+
+		if err := recordPrice(ctx, repos, r, &before, itemResp); err != nil {
+			return err
+		}
+
+		return recordAudit(ctx, repos, r, auditRollback, "item", id, before, itemResp)
+	})
+
+	if errors.Is(crudErr, repositories.ErrNotFound) {
+		customerrors.NotFoundResponse(w, r)
+		return
+	}
+
+	if crudErr != nil {
+		customerrors.ServerErrorResponse(w, r, crudErr)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(itemResp)
+}
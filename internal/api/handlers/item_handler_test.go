@@ -0,0 +1,99 @@
+package handlers_test
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"testing"
+	"training/proj/internal/api/models"
+	"training/proj/internal/db/repositories"
+)
+
+func TestPostItemIsReadBackAndAudited(t *testing.T) {
+	api := newTestAPI(t)
+	admin := api.token("1", true)
+
+	var created models.Item
+	api.expect(api.do(http.MethodPost, "/api/v1/items/", map[string]any{"item": "apple", "price": 250}, admin), http.StatusCreated, &created)
+
+	if created.Currency != "EUR" {
+		t.Errorf("currency = %q, want the default EUR", created.Currency)
+	}
+
+	var got models.Item
+	api.expect(api.do(http.MethodGet, "/api/v1/items/1", nil, ""), http.StatusOK, &got)
+
+	if got.Item != "apple" || got.Price != 250 {
+		t.Errorf("got %+v, want apple at 250", got)
+	}
+
+	entries, err := api.repos.AuditRepository.GetAll(context.Background(), repositories.AuditFilter{EntityType: "item", Limit: 10})
+	if err != nil {
+		t.Fatalf("audit: %v", err)
+	}
+
+	if len(entries) != 1 || entries[0].Action != "create" || entries[0].ActorID != "1" {
+		t.Errorf("audit entries = %+v, want one create by user 1", entries)
+	}
+}
+
+func TestPostItemRejectsInvalidItemWithoutWriting(t *testing.T) {
+	api := newTestAPI(t)
+	admin := api.token("1", true)
+
+	api.expect(api.do(http.MethodPost, "/api/v1/items/", map[string]any{"item": "apple", "price": 250, "weight_grams": -1}, admin), http.StatusBadRequest, nil)
+
+	items, err := api.repos.ItemRepository.GetAll(context.Background())
+	if err != nil {
+		t.Fatalf("items: %v", err)
+	}
+
+	if len(items) != 0 {
+		t.Errorf("got %d items, want none", len(items))
+	}
+}
+
+func TestRestoreMergesRevisionOntoCurrentItem(t *testing.T) {
+	api := newTestAPI(t)
+	admin := api.token("1", true)
+	ctx := context.Background()
+
+	api.expect(api.do(http.MethodPost, "/api/v1/items/", map[string]any{"item": "apple", "price": 250}, admin), http.StatusCreated, nil)
+
+	// A revision written before items had a weight, holding the whole item.
+	err := api.repos.RevisionRepository.Create(ctx, &models.Revision{
+		EntityType: "item",
+		EntityID:   1,
+		Data:       []byte(`{"item_id":1,"item":"apple","price":100,"currency":"EUR","rating_count":3}`),
+		Actor:      "legacy",
+	})
+	if err != nil {
+		t.Fatalf("create revision: %v", err)
+	}
+
+	api.expect(api.do(http.MethodPut, "/api/v1/items/1", map[string]any{"item": "apple", "price": 300, "weight_grams": 500}, admin), http.StatusOK, nil)
+
+	var restored models.Item
+	api.expect(api.do(http.MethodPost, "/api/v1/items/1/revisions/2/restore", nil, admin), http.StatusOK, &restored)
+
+	if restored.Price != 100 {
+		t.Errorf("price = %d, want 100 from revision 2", restored.Price)
+	}
+	if restored.WeightGrams == nil || *restored.WeightGrams != 500 {
+		t.Errorf("weight_grams = %v, want the current 500", restored.WeightGrams)
+	}
+
+	var resp struct {
+		Diff models.RevisionDiff `json:"diff"`
+	}
+	api.expect(api.do(http.MethodGet, "/api/v1/items/1/revisions/diff?from=2&to=3", nil, admin), http.StatusOK, &resp)
+
+	fields := []string{}
+	for _, c := range resp.Diff.Changes {
+		fields = append(fields, c.Field)
+	}
+
+	if !slices.Equal(fields, []string{"price", "weight_grams"}) {
+		t.Errorf("changed fields = %v, want [price weight_grams]", fields)
+	}
+}
@@ -0,0 +1,247 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+	"training/proj/internal/api/models"
+	"training/proj/internal/customerrors"
+	"training/proj/internal/db/repositories"
+	"training/proj/internal/logger"
+	"training/proj/internal/utils"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// lowestPriceWindow is the period the lowest price is reported for, as
+// required for discount claims.
+const lowestPriceWindow = 30 * 24 * time.Hour
+
+// recordPrice adds the item's price to its history if the change made by
+// the request set a new price. before is nil for new items.
+func recordPrice(ctx context.Context, repos *repositories.Repositories, r *http.Request, before *models.Item, after models.Item) error {
+	if before != nil && before.Price == after.Price {
+		return nil
+	}
+
+	now := time.Now()
+	change := &models.PriceChange{ItemID: after.ItemID, Price: after.Price, EffectiveAt: now, AppliedAt: &now}
+	change.ActorID, change.Actor = requestActor(r)
+
+	return repos.PriceRepository.Create(ctx, change)
+}
+
+func (h *ItemHandler) GetItemPrices(w http.ResponseWriter, r *http.Request) {
+	id, convErr := strconv.ParseInt(chi.URLParam(r, "item_id"), 10, 64)
+
+	if convErr != nil {
+		customerrors.BadRequestResponse(w, r, convErr)
+		return
+	}
+
+	var from, to *time.Time
+
+	for _, bound := range []struct {
+		param string
+		dst   **time.Time
+	}{{"from", &from}, {"to", &to}} {
+		raw := r.URL.Query().Get(bound.param)
+		if raw == "" {
+			continue
+		}
+
+		t, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			customerrors.BadRequestResponse(w, r, fmt.Errorf("%s must be an RFC 3339 timestamp", bound.param))
+			return
+		}
+		*bound.dst = &t
+	}
+
+	var prices []models.PriceChange
+	var lowest *int64
+
+	crudErr := h.TxManager.WithinTx(r.Context(), func(ctx context.Context, repos *repositories.Repositories) error {
+		if _, err := repos.ItemRepository.GetById(ctx, id); err != nil {
+			return err
+		}
+
+		var err error
+		prices, err = repos.PriceRepository.GetByItem(ctx, id, from, to)
+		if err != nil {
+			return err
+		}
+
+		price, err := repos.PriceRepository.LowestSince(ctx, id, time.Now().Add(-lowestPriceWindow))
+		if errors.Is(err, repositories.ErrNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		lowest = &price
+		return nil
+	}, repositories.WithIsolation(repositories.RepeatableRead), repositories.WithReadOnly())
+
+	if errors.Is(crudErr, repositories.ErrNotFound) {
+		customerrors.NotFoundResponse(w, r)
+		return
+	}
+
+	if crudErr != nil {
+		customerrors.ServerErrorResponse(w, r, crudErr)
+		return
+	}
+
+	err := utils.WriteJSON(w, http.StatusOK, utils.Envelope{"prices": prices, "lowest_price_30d": lowest}, nil, logger.Logger)
+	if err != nil {
+		customerrors.ServerErrorResponse(w, r, err)
+	}
+}
+
+func (h *ItemHandler) GetScheduledPrices(w http.ResponseWriter, r *http.Request) {
+	id, convErr := strconv.ParseInt(chi.URLParam(r, "item_id"), 10, 64)
+
+	if convErr != nil {
+		customerrors.BadRequestResponse(w, r, convErr)
+		return
+	}
+
+	if _, err := h.ItemRepository.GetById(r.Context(), id); err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			customerrors.NotFoundResponse(w, r)
+			return
+		}
+		customerrors.ServerErrorResponse(w, r, err)
+		return
+	}
+
+	scheduled, err := h.PriceRepository.GetScheduled(r.Context(), id)
+	if err != nil {
+		customerrors.ServerErrorResponse(w, r, err)
+		return
+	}
+
+	err = utils.WriteJSON(w, http.StatusOK, utils.Envelope{"scheduled": scheduled}, nil, logger.Logger)
+	if err != nil {
+		customerrors.ServerErrorResponse(w, r, err)
+	}
+}
+
+type scheduledPriceRequest struct {
+	Price       int64     `json:"price"`
+	Currency    string    `json:"currency"`
+	EffectiveAt time.Time `json:"effective_at"`
+}
+
+// PostScheduledPrice schedules a price that the scheduler applies once
+// effective_at has passed.
+func (h *ItemHandler) PostScheduledPrice(w http.ResponseWriter, r *http.Request) {
+	id, convErr := strconv.ParseInt(chi.URLParam(r, "item_id"), 10, 64)
+
+	if convErr != nil {
+		customerrors.BadRequestResponse(w, r, convErr)
+		return
+	}
+
+	var req scheduledPriceRequest
+
+	if err := utils.ReadJSON(w, r, &req, logger.Logger); err != nil {
+		customerrors.BadRequestResponse(w, r, err)
+		return
+	}
+
+	if req.Price <= 0 {
+		customerrors.BadRequestResponse(w, r, errors.New("price must be greater than zero"))
+		return
+	}
+
+	if !req.EffectiveAt.After(time.Now()) {
+		customerrors.BadRequestResponse(w, r, errors.New("effective_at must be in the future"))
+		return
+	}
+
+	change := models.PriceChange{ItemID: id, Price: req.Price, EffectiveAt: req.EffectiveAt}
+	change.ActorID, change.Actor = requestActor(r)
+
+	crudErr := h.TxManager.WithinTx(r.Context(), func(ctx context.Context, repos *repositories.Repositories) error {
+		item, err := repos.ItemRepository.GetById(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if err := checkItemCurrency(item, req.Currency); err != nil {
+			return err
+		}
+
+		if err := repos.PriceRepository.Create(ctx, &change); err != nil {
+			return err
+		}
+
+		return recordAudit(ctx, repos, r, auditCreate, "scheduled_price", change.PriceID, nil, change)
+	})
+
+	if errors.Is(crudErr, repositories.ErrNotFound) {
+		customerrors.NotFoundResponse(w, r)
+		return
+	}
+
+	if priceErrorResponse(w, r, crudErr) {
+		return
+	}
+
+	if crudErr != nil {
+		customerrors.ServerErrorResponse(w, r, crudErr)
+		return
+	}
+
+	err := utils.WriteJSON(w, http.StatusCreated, utils.Envelope{"scheduled_price": change}, nil, logger.Logger)
+	if err != nil {
+		customerrors.ServerErrorResponse(w, r, err)
+	}
+}
+
+func (h *ItemHandler) DeleteScheduledPrice(w http.ResponseWriter, r *http.Request) {
+	id, convErr := strconv.ParseInt(chi.URLParam(r, "item_id"), 10, 64)
+
+	if convErr != nil {
+		customerrors.BadRequestResponse(w, r, convErr)
+		return
+	}
+
+	priceId, convErr := strconv.ParseInt(chi.URLParam(r, "price_id"), 10, 64)
+
+	if convErr != nil {
+		customerrors.BadRequestResponse(w, r, convErr)
+		return
+	}
+
+	crudErr := h.TxManager.WithinTx(r.Context(), func(ctx context.Context, repos *repositories.Repositories) error {
+		rowsAffected, err := repos.PriceRepository.CancelScheduled(ctx, id, priceId)
+		if err != nil {
+			return err
+		}
+
+		if rowsAffected == 0 {
+			return repositories.ErrNotFound
+		}
+
+		return recordAudit(ctx, repos, r, auditDelete, "scheduled_price", priceId, nil, nil)
+	})
+
+	if errors.Is(crudErr, repositories.ErrNotFound) {
+		customerrors.NotFoundResponse(w, r)
+		return
+	}
+
+	if crudErr != nil {
+		customerrors.ServerErrorResponse(w, r, crudErr)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
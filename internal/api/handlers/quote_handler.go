@@ -0,0 +1,242 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+	"training/proj/internal/api/models"
+	"training/proj/internal/coupons"
+	"training/proj/internal/customerrors"
+	"training/proj/internal/db/repositories"
+	"training/proj/internal/logger"
+	"training/proj/internal/money"
+	"training/proj/internal/utils"
+)
+
+const (
+	maxQuoteLines    = 100
+	maxQuoteQuantity = 10000
+	maxQuoteCodes    = 10
+)
+
+var (
+	errInvalidBasket = errors.New("invalid basket")
+	errCouponUsedUp  = errors.New("coupon has reached its usage limit")
+)
+
+type QuoteHandler struct {
+	ItemRepository         repositories.ItemRepositoryInterface
+	ExchangeRateRepository repositories.ExchangeRateRepositoryInterface
+	SaleRepository         repositories.SaleRepositoryInterface
+	CouponRepository       repositories.CouponRepositoryInterface
+	TxManager              repositories.TxManager
+}
+
+func NewQuoteHandler(ir repositories.ItemRepositoryInterface, er repositories.ExchangeRateRepositoryInterface,
+	sr repositories.SaleRepositoryInterface, cor repositories.CouponRepositoryInterface, tm repositories.TxManager) *QuoteHandler {
+	return &QuoteHandler{
+		ItemRepository:         ir,
+		ExchangeRateRepository: er,
+		SaleRepository:         sr,
+		CouponRepository:       cor,
+		TxManager:              tm,
+	}
+}
+
+// PostQuote prices a basket against the current item prices and the
+// supplied codes without redeeming them.
+func (h *QuoteHandler) PostQuote(w http.ResponseWriter, r *http.Request) {
+	h.writeQuote(w, r, false)
+}
+
+// PostRedeemQuote prices a basket like PostQuote and counts a use of every
+// code that was applied.
+func (h *QuoteHandler) PostRedeemQuote(w http.ResponseWriter, r *http.Request) {
+	h.writeQuote(w, r, true)
+}
+
+func (h *QuoteHandler) writeQuote(w http.ResponseWriter, r *http.Request, redeem bool) {
+	var req models.QuoteRequest
+
+	if err := utils.ReadJSON(w, r, &req, logger.Logger); err != nil {
+		customerrors.BadRequestResponse(w, r, err)
+		return
+	}
+
+	basket, validErr := validateBasket(req.Items)
+
+	if validErr != nil {
+		customerrors.BadRequestResponse(w, r, validErr)
+		return
+	}
+
+	if len(req.Codes) > maxQuoteCodes {
+		customerrors.BadRequestResponse(w, r, fmt.Errorf("at most %d codes can be applied", maxQuoteCodes))
+		return
+	}
+
+	var currency string
+
+	if req.Currency != "" {
+		var err error
+		if currency, err = money.Normalize(req.Currency); err != nil {
+			customerrors.BadRequestResponse(w, r, err)
+			return
+		}
+	}
+
+	var quote models.Quote
+
+	txOpts := []repositories.TxOption{repositories.WithIsolation(repositories.RepeatableRead)}
+	if !redeem {
+		txOpts = append(txOpts, repositories.WithReadOnly())
+	}
+
+	crudErr := h.TxManager.WithinTx(r.Context(), func(ctx context.Context, repos *repositories.Repositories) error {
+		var err error
+		quote, err = buildQuote(ctx, repos, basket, req.Codes, currency)
+		if err != nil || !redeem {
+			return err
+		}
+
+		for _, applied := range quote.Applied {
+			rowsAffected, err := repos.CouponRepository.Redeem(ctx, applied.CouponID)
+			if err != nil {
+				return err
+			}
+
+			if rowsAffected == 0 {
+				return fmt.Errorf("%w: %s", errCouponUsedUp, applied.Code)
+			}
+		}
+
+		return nil
+	}, txOpts...)
+
+	if errors.Is(crudErr, errInvalidBasket) {
+		customerrors.ErrorResponse(w, r, http.StatusUnprocessableEntity, crudErr.Error())
+		return
+	}
+
+	if errors.Is(crudErr, errCouponUsedUp) {
+		customerrors.ErrorResponse(w, r, http.StatusConflict, crudErr.Error())
+		return
+	}
+
+	if priceErrorResponse(w, r, crudErr) {
+		return
+	}
+
+	if crudErr != nil {
+		customerrors.ServerErrorResponse(w, r, crudErr)
+		return
+	}
+
+	err := utils.WriteJSON(w, http.StatusOK, utils.Envelope{"quote": quote}, nil, logger.Logger)
+	if err != nil {
+		customerrors.ServerErrorResponse(w, r, err)
+	}
+}
+
+// validateBasket checks the requested quantities and merges lines for the
+// same item, keeping the order in which items first appear.
+func validateBasket(items []models.QuoteItem) ([]models.QuoteItem, error) {
+	if len(items) == 0 {
+		return nil, errors.New("basket is empty")
+	}
+
+	if len(items) > maxQuoteLines {
+		return nil, fmt.Errorf("basket can have at most %d lines", maxQuoteLines)
+	}
+
+	basket := make([]models.QuoteItem, 0, len(items))
+	index := make(map[int64]int, len(items))
+
+	for _, item := range items {
+		if item.Quantity <= 0 {
+			return nil, fmt.Errorf("quantity of item %d must be greater than zero", item.ItemID)
+		}
+
+		if i, ok := index[item.ItemID]; ok {
+			basket[i].Quantity += item.Quantity
+		} else {
+			index[item.ItemID] = len(basket)
+			basket = append(basket, item)
+		}
+
+		if basket[index[item.ItemID]].Quantity > maxQuoteQuantity {
+			return nil, fmt.Errorf("quantity of item %d must not exceed %d", item.ItemID, maxQuoteQuantity)
+		}
+	}
+
+	return basket, nil
+}
+
+// buildQuote loads the basket's items and the requested coupons and prices
+// them in currency, or in the first item's currency if it is empty.
+func buildQuote(ctx context.Context, repos *repositories.Repositories, basket []models.QuoteItem, codes []string, currency string) (models.Quote, error) {
+	lines := make([]coupons.Line, 0, len(basket))
+	items := make([]models.Item, 0, len(basket))
+
+	for _, entry := range basket {
+		item, err := repos.ItemRepository.GetById(ctx, entry.ItemID)
+		if errors.Is(err, repositories.ErrNotFound) {
+			return models.Quote{}, fmt.Errorf("%w: item %d does not exist", errInvalidBasket, entry.ItemID)
+		}
+		if err != nil {
+			return models.Quote{}, err
+		}
+
+		categories, err := repos.ItemRepository.GetItemCategories(ctx, entry.ItemID)
+		if err != nil {
+			return models.Quote{}, err
+		}
+
+		line := coupons.Line{Quantity: entry.Quantity}
+		for _, category := range categories {
+			line.CategoryIDs = append(line.CategoryIDs, category.CategoryID)
+		}
+
+		lines = append(lines, line)
+		items = append(items, item)
+	}
+
+	if currency == "" {
+		currency = items[0].Currency
+	}
+
+	rates, err := loadRates(ctx, repos.ExchangeRateRepository)
+	if err != nil {
+		return models.Quote{}, err
+	}
+
+	if err := applyRates(rates, items, currency); err != nil {
+		return models.Quote{}, err
+	}
+
+	if err := applySales(ctx, repos.SaleRepository, items); err != nil {
+		return models.Quote{}, err
+	}
+
+	for i := range lines {
+		lines[i].Item = items[i]
+	}
+
+	available := make([]models.Coupon, 0)
+
+	if len(codes) > 0 {
+		normalized := make([]string, 0, len(codes))
+		for _, code := range codes {
+			normalized = append(normalized, coupons.NormalizeCode(code))
+		}
+
+		available, err = repos.CouponRepository.GetByCodes(ctx, normalized)
+		if err != nil {
+			return models.Quote{}, err
+		}
+	}
+
+	return coupons.Quote(currency, lines, codes, available, rates, time.Now()), nil
+}
@@ -0,0 +1,46 @@
+package handlers_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"training/proj/internal/api/models"
+)
+
+func TestQuoteRedeemsCouponUpToItsLimit(t *testing.T) {
+	api := newTestAPI(t)
+	admin := api.token("1", true)
+
+	api.expect(api.do(http.MethodPost, "/api/v1/items/", map[string]any{"item": "apple", "price": 1000}, admin), http.StatusCreated, nil)
+
+	coupon := map[string]any{"code": "TENOFF", "kind": "percent", "percent_off": 10, "max_uses": 1}
+	api.expect(api.do(http.MethodPost, "/api/v1/admin/coupons", coupon, admin), http.StatusCreated, nil)
+
+	basket := map[string]any{"items": []map[string]any{{"item_id": 1, "quantity": 2}}, "codes": []string{"tenoff"}}
+
+	var resp struct {
+		Quote models.Quote `json:"quote"`
+	}
+	api.expect(api.do(http.MethodPost, "/api/v1/quotes/", basket, ""), http.StatusOK, &resp)
+
+	if resp.Quote.Subtotal != 2000 || resp.Quote.Total != 1800 {
+		t.Errorf("quote = %d of %d, want 1800 of 2000", resp.Quote.Total, resp.Quote.Subtotal)
+	}
+
+	api.expect(api.do(http.MethodPost, "/api/v1/quotes/redeem", basket, api.token("2", false)), http.StatusOK, nil)
+
+	// The used-up code is rejected rather than applied again.
+	api.expect(api.do(http.MethodPost, "/api/v1/quotes/redeem", basket, api.token("2", false)), http.StatusOK, &resp)
+
+	if len(resp.Quote.Applied) != 0 || resp.Quote.Total != 2000 {
+		t.Errorf("second redeem applied %+v, want no coupon", resp.Quote.Applied)
+	}
+
+	coupons, err := api.repos.CouponRepository.GetByCodes(context.Background(), []string{"TENOFF"})
+	if err != nil {
+		t.Fatalf("coupons: %v", err)
+	}
+	if len(coupons) != 1 || coupons[0].Uses != 1 {
+		t.Errorf("coupons = %+v, want TENOFF used once", coupons)
+	}
+}
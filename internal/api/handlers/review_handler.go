@@ -0,0 +1,491 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"training/proj/internal/api/models"
+	"training/proj/internal/customerrors"
+	"training/proj/internal/db/repositories"
+	"training/proj/internal/logger"
+	"training/proj/internal/utils"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/jwtauth/v5"
+	"github.com/go-playground/validator/v10"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const (
+	defaultReviewPageSize = 20
+	maxReviewPageSize     = 100
+
+	// itemsByRating is the ?sort= value of GetAllItems that puts the best
+	// rated items first.
+	itemsByRating = "rating"
+)
+
+var (
+	errNotReviewAuthor = errors.New("only the author can change a review")
+	errOwnReviewVote   = errors.New("you cannot vote on your own review")
+)
+
+// PurchaseVerifier tells whether a user has bought an item, which marks
+// their review of it as a verified purchase.
+type PurchaseVerifier interface {
+	HasPurchased(ctx context.Context, userId int64, itemId int64) (bool, error)
+}
+
+// noPurchases is the PurchaseVerifier used until orders are recorded: no
+// review is a verified purchase. Purchase verification is deferred until
+// there is an orders table to check against; replace it then.
+type noPurchases struct{}
+
+func (noPurchases) HasPurchased(ctx context.Context, userId int64, itemId int64) (bool, error) {
+	return false, nil
+}
+
+type ReviewHandler struct {
+	ItemRepository   repositories.ItemRepositoryInterface
+	ReviewRepository repositories.ReviewRepositoryInterface
+	TxManager        repositories.TxManager
+
+	// Purchases sets verified_purchase on new reviews.
+	Purchases PurchaseVerifier
+}
+
+func NewReviewHandler(ir repositories.ItemRepositoryInterface, rvr repositories.ReviewRepositoryInterface, tm repositories.TxManager) *ReviewHandler {
+	return &ReviewHandler{
+		ItemRepository:   ir,
+		ReviewRepository: rvr,
+		TxManager:        tm,
+		Purchases:        noPurchases{},
+	}
+}
+
+// requestUser returns the ID of the authenticated user and whether they are
+// an admin. Login signs user_id as a string; numeric claims are accepted too.
+func requestUser(r *http.Request) (int64, bool, bool) {
+	_, claims, err := jwtauth.FromContext(r.Context())
+	if err != nil {
+		return 0, false, false
+	}
+
+	isAdmin, _ := claims["is_admin"].(bool)
+
+	switch id := claims["user_id"].(type) {
+	case string:
+		n, err := strconv.ParseInt(id, 10, 64)
+		return n, isAdmin, err == nil
+	case float64:
+		return int64(id), isAdmin, true
+	case int64:
+		return id, isAdmin, true
+	case json.Number:
+		n, err := id.Int64()
+		return n, isAdmin, err == nil
+	}
+
+	return 0, false, false
+}
+
+// sortByRating orders items by average rating, best first, with unrated
+// items last. Ties go to the item with more ratings.
+func sortByRating(items []models.Item) {
+	sort.SliceStable(items, func(i, j int) bool {
+		a, b := items[i], items[j]
+
+		switch {
+		case a.RatingAvg == nil || b.RatingAvg == nil:
+			if (a.RatingAvg == nil) != (b.RatingAvg == nil) {
+				return b.RatingAvg == nil
+			}
+		case *a.RatingAvg != *b.RatingAvg:
+			return *a.RatingAvg > *b.RatingAvg
+		}
+
+		if a.RatingCount != b.RatingCount {
+			return a.RatingCount > b.RatingCount
+		}
+		return a.ItemID < b.ItemID
+	})
+}
+
+func reviewURLParams(r *http.Request) (int64, int64, error) {
+	itemId, err := strconv.ParseInt(chi.URLParam(r, "item_id"), 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	reviewId, err := strconv.ParseInt(chi.URLParam(r, "review_id"), 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return itemId, reviewId, nil
+}
+
+// itemReview loads a review of the item. A review of another item is
+// reported as not found.
+func itemReview(ctx context.Context, repos *repositories.Repositories, itemId int64, reviewId int64) (models.Review, error) {
+	review, err := repos.ReviewRepository.GetById(ctx, reviewId)
+	if err != nil {
+		return models.Review{}, err
+	}
+
+	if review.ItemID != itemId {
+		return models.Review{}, repositories.ErrNotFound
+	}
+
+	return review, nil
+}
+
+// reviewErrorResponse maps the errors of review writes. It reports false if
+// err is not one of them.
+func reviewErrorResponse(w http.ResponseWriter, r *http.Request, err error) bool {
+	var pgErr *pgconn.PgError
+
+	switch {
+	case errors.Is(err, repositories.ErrNotFound):
+		customerrors.NotFoundResponse(w, r)
+	case errors.Is(err, errNotReviewAuthor):
+		customerrors.NotPermittedResponse(w, r)
+	case errors.Is(err, errOwnReviewVote):
+		customerrors.BadRequestResponse(w, r, err)
+	case errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation:
+		customerrors.ErrorResponse(w, r, http.StatusConflict, "you have already reviewed this item")
+	case errors.As(err, &pgErr) && pgErr.Code == pgerrcode.ForeignKeyViolation:
+		customerrors.NotFoundResponse(w, r)
+	default:
+		return false
+	}
+
+	return true
+}
+
+// GetItemReviews lists a page of the item's reviews, the most helpful first
+// unless ?sort=newest is given.
+func (h *ReviewHandler) GetItemReviews(w http.ResponseWriter, r *http.Request) {
+	itemId, convErr := strconv.ParseInt(chi.URLParam(r, "item_id"), 10, 64)
+
+	if convErr != nil {
+		customerrors.BadRequestResponse(w, r, convErr)
+		return
+	}
+
+	query := r.URL.Query()
+
+	filter := repositories.ReviewFilter{Sort: repositories.ReviewsByHelpful}
+
+	if raw := query.Get("sort"); raw != "" {
+		if raw != repositories.ReviewsByHelpful && raw != repositories.ReviewsByNewest {
+			customerrors.BadRequestResponse(w, r, fmt.Errorf("sort must be %q or %q", repositories.ReviewsByHelpful, repositories.ReviewsByNewest))
+			return
+		}
+		filter.Sort = raw
+	}
+
+	page, pageSize := 1, defaultReviewPageSize
+
+	if raw := query.Get("page"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 {
+			customerrors.BadRequestResponse(w, r, errors.New("page must be a positive integer"))
+			return
+		}
+		page = n
+	}
+
+	if raw := query.Get("page_size"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > maxReviewPageSize {
+			customerrors.BadRequestResponse(w, r, fmt.Errorf("page_size must be an integer between 1 and %d", maxReviewPageSize))
+			return
+		}
+		pageSize = n
+	}
+
+	filter.Limit = pageSize
+	filter.Offset = (page - 1) * pageSize
+
+	var reviews []models.Review
+	var total int64
+
+	err := h.TxManager.WithinTx(r.Context(), func(ctx context.Context, repos *repositories.Repositories) error {
+		if _, err := repos.ItemRepository.GetById(ctx, itemId); err != nil {
+			return err
+		}
+
+		var err error
+		reviews, total, err = repos.ReviewRepository.GetForItem(ctx, itemId, filter)
+
+		return err
+	}, repositories.WithIsolation(repositories.RepeatableRead), repositories.WithReadOnly())
+
+	if errors.Is(err, repositories.ErrNotFound) {
+		customerrors.NotFoundResponse(w, r)
+		return
+	}
+
+	if err != nil {
+		customerrors.ServerErrorResponse(w, r, err)
+		return
+	}
+
+	metadata := map[string]any{
+		"page":      page,
+		"page_size": pageSize,
+		"total":     total,
+	}
+
+	err = utils.WriteJSON(w, http.StatusOK, utils.Envelope{"reviews": reviews, "metadata": metadata}, nil, logger.Logger)
+	if err != nil {
+		customerrors.ServerErrorResponse(w, r, err)
+	}
+}
+
+// PostItemReview adds the authenticated user's review of the item. Each
+// user can review an item once.
+func (h *ReviewHandler) PostItemReview(w http.ResponseWriter, r *http.Request) {
+	itemId, convErr := strconv.ParseInt(chi.URLParam(r, "item_id"), 10, 64)
+
+	if convErr != nil {
+		customerrors.BadRequestResponse(w, r, convErr)
+		return
+	}
+
+	userId, _, ok := requestUser(r)
+
+	if !ok {
+		customerrors.AuthenticationRequiredResponse(w, r)
+		return
+	}
+
+	var req models.Review
+
+	if err := utils.ReadJSON(w, r, &req, logger.Logger); err != nil {
+		customerrors.BadRequestResponse(w, r, err)
+		return
+	}
+
+	if err := validator.New().Struct(req); err != nil {
+		customerrors.BadRequestResponse(w, r, err)
+		return
+	}
+
+	req.ItemID = itemId
+	req.UserID = userId
+
+	var review models.Review
+
+	err := h.TxManager.WithinTx(r.Context(), func(ctx context.Context, repos *repositories.Repositories) error {
+		if _, err := repos.ItemRepository.GetById(ctx, itemId); err != nil {
+			return err
+		}
+
+		var err error
+		if req.VerifiedPurchase, err = h.Purchases.HasPurchased(ctx, userId, itemId); err != nil {
+			return err
+		}
+
+		if review, err = repos.ReviewRepository.Create(ctx, &req); err != nil {
+			return err
+		}
+
+		return recordAudit(ctx, repos, r, auditCreate, "review", review.ReviewID, nil, review)
+	})
+
+	if reviewErrorResponse(w, r, err) {
+		return
+	}
+
+	if err != nil {
+		customerrors.ServerErrorResponse(w, r, err)
+		return
+	}
+
+	err = utils.WriteJSON(w, http.StatusCreated, utils.Envelope{"review": review}, nil, logger.Logger)
+	if err != nil {
+		customerrors.ServerErrorResponse(w, r, err)
+	}
+}
+
+// PutItemReview changes the rating, title and body of a review. Only its
+// author can do so.
+func (h *ReviewHandler) PutItemReview(w http.ResponseWriter, r *http.Request) {
+	itemId, reviewId, convErr := reviewURLParams(r)
+
+	if convErr != nil {
+		customerrors.BadRequestResponse(w, r, convErr)
+		return
+	}
+
+	userId, _, ok := requestUser(r)
+
+	if !ok {
+		customerrors.AuthenticationRequiredResponse(w, r)
+		return
+	}
+
+	var req models.Review
+
+	if err := utils.ReadJSON(w, r, &req, logger.Logger); err != nil {
+		customerrors.BadRequestResponse(w, r, err)
+		return
+	}
+
+	if err := validator.New().Struct(req); err != nil {
+		customerrors.BadRequestResponse(w, r, err)
+		return
+	}
+
+	var review models.Review
+
+	err := h.TxManager.WithinTx(r.Context(), func(ctx context.Context, repos *repositories.Repositories) error {
+		before, err := itemReview(ctx, repos, itemId, reviewId)
+		if err != nil {
+			return err
+		}
+
+		if before.UserID != userId {
+			return errNotReviewAuthor
+		}
+
+		if review, err = repos.ReviewRepository.Update(ctx, reviewId, &req); err != nil {
+			return err
+		}
+
+		return recordAudit(ctx, repos, r, auditUpdate, "review", reviewId, before, review)
+	})
+
+	if reviewErrorResponse(w, r, err) {
+		return
+	}
+
+	if err != nil {
+		customerrors.ServerErrorResponse(w, r, err)
+		return
+	}
+
+	err = utils.WriteJSON(w, http.StatusOK, utils.Envelope{"review": review}, nil, logger.Logger)
+	if err != nil {
+		customerrors.ServerErrorResponse(w, r, err)
+	}
+}
+
+// DeleteItemReview removes a review. Its author and admins can do so.
+func (h *ReviewHandler) DeleteItemReview(w http.ResponseWriter, r *http.Request) {
+	itemId, reviewId, convErr := reviewURLParams(r)
+
+	if convErr != nil {
+		customerrors.BadRequestResponse(w, r, convErr)
+		return
+	}
+
+	userId, isAdmin, ok := requestUser(r)
+
+	if !ok {
+		customerrors.AuthenticationRequiredResponse(w, r)
+		return
+	}
+
+	err := h.TxManager.WithinTx(r.Context(), func(ctx context.Context, repos *repositories.Repositories) error {
+		before, err := itemReview(ctx, repos, itemId, reviewId)
+		if err != nil {
+			return err
+		}
+
+		if before.UserID != userId && !isAdmin {
+			return errNotReviewAuthor
+		}
+
+		if _, err := repos.ReviewRepository.Delete(ctx, reviewId); err != nil {
+			return err
+		}
+
+		return recordAudit(ctx, repos, r, auditDelete, "review", reviewId, before, nil)
+	})
+
+	if reviewErrorResponse(w, r, err) {
+		return
+	}
+
+	if err != nil {
+		customerrors.ServerErrorResponse(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// PostReviewVote marks a review as helpful to the authenticated user.
+// Voting again has no effect.
+func (h *ReviewHandler) PostReviewVote(w http.ResponseWriter, r *http.Request) {
+	h.vote(w, r, true)
+}
+
+// DeleteReviewVote withdraws the authenticated user's helpful vote.
+func (h *ReviewHandler) DeleteReviewVote(w http.ResponseWriter, r *http.Request) {
+	h.vote(w, r, false)
+}
+
+func (h *ReviewHandler) vote(w http.ResponseWriter, r *http.Request, helpful bool) {
+	itemId, reviewId, convErr := reviewURLParams(r)
+
+	if convErr != nil {
+		customerrors.BadRequestResponse(w, r, convErr)
+		return
+	}
+
+	userId, _, ok := requestUser(r)
+
+	if !ok {
+		customerrors.AuthenticationRequiredResponse(w, r)
+		return
+	}
+
+	var review models.Review
+
+	err := h.TxManager.WithinTx(r.Context(), func(ctx context.Context, repos *repositories.Repositories) error {
+		var err error
+		if review, err = itemReview(ctx, repos, itemId, reviewId); err != nil {
+			return err
+		}
+
+		if review.UserID == userId {
+			return errOwnReviewVote
+		}
+
+		if helpful {
+			_, err = repos.ReviewRepository.AddVote(ctx, reviewId, userId)
+		} else {
+			_, err = repos.ReviewRepository.RemoveVote(ctx, reviewId, userId)
+		}
+		if err != nil {
+			return err
+		}
+
+		review, err = repos.ReviewRepository.GetById(ctx, reviewId)
+
+		return err
+	})
+
+	if reviewErrorResponse(w, r, err) {
+		return
+	}
+
+	if err != nil {
+		customerrors.ServerErrorResponse(w, r, err)
+		return
+	}
+
+	err = utils.WriteJSON(w, http.StatusOK, utils.Envelope{"review": review}, nil, logger.Logger)
+	if err != nil {
+		customerrors.ServerErrorResponse(w, r, err)
+	}
+}
@@ -0,0 +1,43 @@
+package handlers_test
+
+import (
+	"net/http"
+	"testing"
+	"training/proj/internal/api/models"
+)
+
+func TestLoggedInUserCanPostReview(t *testing.T) {
+	api := newTestAPI(t)
+
+	api.expect(api.do(http.MethodPost, "/api/v1/items/", map[string]any{"item": "apple", "price": 250}, api.token("1", true)), http.StatusCreated, nil)
+
+	signup := map[string]any{
+		"email":      "ann@example.com",
+		"username":   "ann",
+		"first_name": "Ann",
+		"last_name":  "Smith",
+		"password":   "correct horse",
+	}
+	api.expect(api.do(http.MethodPost, "/api/v1/users/signup", signup, ""), http.StatusCreated, nil)
+
+	var login struct {
+		Token string `json:"token"`
+	}
+	api.expect(api.do(http.MethodGet, "/api/v1/users/auth", map[string]any{"login": "ann", "password": "correct horse"}, ""), http.StatusOK, &login)
+
+	var resp struct {
+		Review models.Review `json:"review"`
+	}
+	api.expect(api.do(http.MethodPost, "/api/v1/items/1/reviews", map[string]any{"rating": 4, "title": "Crisp", "body": "Sweet and crunchy."}, login.Token), http.StatusCreated, &resp)
+
+	if resp.Review.UserID == 0 || resp.Review.Rating != 4 {
+		t.Errorf("review = %+v, want a rating of 4 by the signed-up user", resp.Review)
+	}
+
+	var item models.Item
+	api.expect(api.do(http.MethodGet, "/api/v1/items/1", nil, ""), http.StatusOK, &item)
+
+	if item.RatingCount != 1 {
+		t.Errorf("rating_count = %d, want 1", item.RatingCount)
+	}
+}
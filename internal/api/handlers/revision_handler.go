@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"slices"
+	"strconv"
+	"training/proj/internal/api/models"
+	"training/proj/internal/customerrors"
+	"training/proj/internal/db/repositories"
+	"training/proj/internal/logger"
+	"training/proj/internal/utils"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// recordRevision stores the state of an entity after a change made by the
+// request as its next revision.
+func recordRevision(ctx context.Context, repos *repositories.Repositories, r *http.Request, entityType string, entityID int64, data any) error {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	rev := &models.Revision{EntityType: entityType, EntityID: entityID, Data: raw}
+	rev.ActorID, rev.Actor = requestActor(r)
+
+	return repos.RevisionRepository.Create(ctx, rev)
+}
+
+// diffRevisions compares the top-level fields of two revisions. Older
+// revisions stored whole entities, so fields outside the entity's snapshot
+// are ignored.
+func diffRevisions(from models.Revision, to models.Revision) (models.RevisionDiff, error) {
+	diff := models.RevisionDiff{From: from.Revision, To: to.Revision, Changes: []models.FieldChange{}}
+
+	var before, after map[string]any
+
+	if err := decodeRevision(from.Data, &before); err != nil {
+		return diff, err
+	}
+
+	if err := decodeRevision(to.Data, &after); err != nil {
+		return diff, err
+	}
+
+	if fields, ok := snapshotFields[from.EntityType]; ok {
+		for field := range before {
+			if !fields[field] {
+				delete(before, field)
+			}
+		}
+		for field := range after {
+			if !fields[field] {
+				delete(after, field)
+			}
+		}
+	}
+
+	fields := make([]string, 0, len(before)+len(after))
+	for field := range before {
+		fields = append(fields, field)
+	}
+	for field := range after {
+		if _, ok := before[field]; !ok {
+			fields = append(fields, field)
+		}
+	}
+	slices.Sort(fields)
+
+	for _, field := range fields {
+		if !reflect.DeepEqual(before[field], after[field]) {
+			diff.Changes = append(diff.Changes, models.FieldChange{Field: field, From: before[field], To: after[field]})
+		}
+	}
+
+	return diff, nil
+}
+
+// snapshotFields holds the fields each entity type's revisions store.
+var snapshotFields = map[string]map[string]bool{
+	"item":     jsonFields(models.ItemSnapshot{}),
+	"category": jsonFields(models.CategorySnapshot{}),
+}
+
+func jsonFields(v any) map[string]bool {
+	raw, _ := json.Marshal(v)
+
+	var m map[string]any
+	json.Unmarshal(raw, &m)
+
+	fields := make(map[string]bool, len(m))
+	for field := range m {
+		fields[field] = true
+	}
+	return fields
+}
+
+func decodeRevision(data json.RawMessage, v any) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	return dec.Decode(v)
+}
+
+func writeRevisions(w http.ResponseWriter, r *http.Request, repo repositories.RevisionRepositoryInterface, entityType string, param string) {
+	id, convErr := strconv.ParseInt(chi.URLParam(r, param), 10, 64)
+
+	if convErr != nil {
+		customerrors.BadRequestResponse(w, r, convErr)
+		return
+	}
+
+	revisions, err := repo.GetByEntity(r.Context(), entityType, id)
+	if err != nil {
+		customerrors.ServerErrorResponse(w, r, err)
+		return
+	}
+
+	if len(revisions) == 0 {
+		customerrors.NotFoundResponse(w, r)
+		return
+	}
+
+	err = utils.WriteJSON(w, http.StatusOK, utils.Envelope{"revisions": revisions}, nil, logger.Logger)
+	if err != nil {
+		customerrors.ServerErrorResponse(w, r, err)
+	}
+}
+
+func writeRevisionDiff(w http.ResponseWriter, r *http.Request, repo repositories.RevisionRepositoryInterface, entityType string, param string) {
+	id, convErr := strconv.ParseInt(chi.URLParam(r, param), 10, 64)
+
+	if convErr != nil {
+		customerrors.BadRequestResponse(w, r, convErr)
+		return
+	}
+
+	from, fromErr := strconv.ParseInt(r.URL.Query().Get("from"), 10, 64)
+	to, toErr := strconv.ParseInt(r.URL.Query().Get("to"), 10, 64)
+
+	if fromErr != nil || toErr != nil {
+		customerrors.BadRequestResponse(w, r, errors.New("from and to must be revision numbers"))
+		return
+	}
+
+	diff, err := loadRevisionDiff(r.Context(), repo, entityType, id, from, to)
+
+	if errors.Is(err, repositories.ErrNotFound) {
+		customerrors.NotFoundResponse(w, r)
+		return
+	}
+
+	if err != nil {
+		customerrors.ServerErrorResponse(w, r, err)
+		return
+	}
+
+	err = utils.WriteJSON(w, http.StatusOK, utils.Envelope{"diff": diff}, nil, logger.Logger)
+	if err != nil {
+		customerrors.ServerErrorResponse(w, r, err)
+	}
+}
+
+func loadRevisionDiff(ctx context.Context, repo repositories.RevisionRepositoryInterface, entityType string, id int64, from int64, to int64) (models.RevisionDiff, error) {
+	fromRev, err := repo.Get(ctx, entityType, id, from)
+	if err != nil {
+		return models.RevisionDiff{}, err
+	}
+
+	toRev, err := repo.Get(ctx, entityType, id, to)
+	if err != nil {
+		return models.RevisionDiff{}, err
+	}
+
+	return diffRevisions(fromRev, toRev)
+}
+
+// revisionParams reads the entity id and revision number of a restore
+// request.
+func revisionParams(r *http.Request, param string) (id int64, revision int64, err error) {
+	id, err = strconv.ParseInt(chi.URLParam(r, param), 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	revision, err = strconv.ParseInt(chi.URLParam(r, "revision"), 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return id, revision, nil
+}
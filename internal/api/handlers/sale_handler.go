@@ -0,0 +1,250 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strconv"
+	"time"
+	"training/proj/internal/api/models"
+	"training/proj/internal/customerrors"
+	"training/proj/internal/db/repositories"
+	"training/proj/internal/logger"
+	"training/proj/internal/money"
+	"training/proj/internal/utils"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+)
+
+var errInvalidSale = errors.New("invalid sale")
+
+// applySales sets the sale price and promotion of the items covered by a
+// sale that is running now. It must run after any currency conversion so
+// that the sale price is rounded in the currency shown.
+func applySales(ctx context.Context, repo repositories.SaleRepositoryInterface, items []models.Item) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, 0, len(items))
+	for _, item := range items {
+		ids = append(ids, item.ItemID)
+	}
+
+	sales, err := repo.ActiveForItems(ctx, ids, time.Now())
+	if err != nil {
+		return err
+	}
+
+	for i, item := range items {
+		sale, ok := sales[item.ItemID]
+		if !ok {
+			continue
+		}
+
+		price := money.Money{Amount: item.Price, Currency: item.Currency}
+
+		salePrice, err := money.Convert(price, item.Currency, big.NewRat(100-sale.PercentOff, 100))
+		if err != nil {
+			return err
+		}
+
+		items[i].SalePrice = &salePrice.Amount
+		items[i].Promotion = sale.Name
+	}
+
+	return nil
+}
+
+func (h *AdminHandler) GetSales(w http.ResponseWriter, r *http.Request) {
+	sales, err := h.SaleRepository.GetAll(r.Context())
+	if err != nil {
+		customerrors.ServerErrorResponse(w, r, err)
+		return
+	}
+
+	err = utils.WriteJSON(w, http.StatusOK, utils.Envelope{"sales": sales}, nil, logger.Logger)
+	if err != nil {
+		customerrors.ServerErrorResponse(w, r, err)
+	}
+}
+
+func (h *AdminHandler) GetSale(w http.ResponseWriter, r *http.Request) {
+	id, convErr := strconv.ParseInt(chi.URLParam(r, "sale_id"), 10, 64)
+
+	if convErr != nil {
+		customerrors.BadRequestResponse(w, r, convErr)
+		return
+	}
+
+	sale, err := h.SaleRepository.GetById(r.Context(), id)
+
+	if errors.Is(err, repositories.ErrNotFound) {
+		customerrors.NotFoundResponse(w, r)
+		return
+	}
+
+	if err != nil {
+		customerrors.ServerErrorResponse(w, r, err)
+		return
+	}
+
+	err = utils.WriteJSON(w, http.StatusOK, utils.Envelope{"sale": sale}, nil, logger.Logger)
+	if err != nil {
+		customerrors.ServerErrorResponse(w, r, err)
+	}
+}
+
+// checkSaleCategory makes sure the sale's category exists and is not in
+// the trash.
+func checkSaleCategory(ctx context.Context, repos *repositories.Repositories, sale *models.Sale) error {
+	_, err := repos.CategoryRepository.GetById(ctx, sale.CategoryID)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return fmt.Errorf("%w: category %d does not exist", errInvalidSale, sale.CategoryID)
+	}
+
+	return err
+}
+
+func (h *AdminHandler) PostSale(w http.ResponseWriter, r *http.Request) {
+	var req models.Sale
+
+	if err := utils.ReadJSON(w, r, &req, logger.Logger); err != nil {
+		customerrors.BadRequestResponse(w, r, err)
+		return
+	}
+
+	if err := validator.New().Struct(req); err != nil {
+		customerrors.BadRequestResponse(w, r, err)
+		return
+	}
+
+	var sale models.Sale
+
+	err := h.TxManager.WithinTx(r.Context(), func(ctx context.Context, repos *repositories.Repositories) error {
+		if err := checkSaleCategory(ctx, repos, &req); err != nil {
+			return err
+		}
+
+		var err error
+		sale, err = repos.SaleRepository.Create(ctx, &req)
+		if err != nil {
+			return err
+		}
+
+		return recordAudit(ctx, repos, r, auditCreate, "sale", sale.SaleID, nil, sale)
+	})
+
+	if errors.Is(err, errInvalidSale) {
+		customerrors.BadRequestResponse(w, r, err)
+		return
+	}
+
+	if err != nil {
+		customerrors.ServerErrorResponse(w, r, err)
+		return
+	}
+
+	err = utils.WriteJSON(w, http.StatusCreated, utils.Envelope{"sale": sale}, nil, logger.Logger)
+	if err != nil {
+		customerrors.ServerErrorResponse(w, r, err)
+	}
+}
+
+func (h *AdminHandler) PutSale(w http.ResponseWriter, r *http.Request) {
+	id, convErr := strconv.ParseInt(chi.URLParam(r, "sale_id"), 10, 64)
+
+	if convErr != nil {
+		customerrors.BadRequestResponse(w, r, convErr)
+		return
+	}
+
+	var req models.Sale
+
+	if err := utils.ReadJSON(w, r, &req, logger.Logger); err != nil {
+		customerrors.BadRequestResponse(w, r, err)
+		return
+	}
+
+	if err := validator.New().Struct(req); err != nil {
+		customerrors.BadRequestResponse(w, r, err)
+		return
+	}
+
+	var sale models.Sale
+
+	err := h.TxManager.WithinTx(r.Context(), func(ctx context.Context, repos *repositories.Repositories) error {
+		before, err := repos.SaleRepository.GetById(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if err := checkSaleCategory(ctx, repos, &req); err != nil {
+			return err
+		}
+
+		sale, err = repos.SaleRepository.Update(ctx, id, &req)
+		if err != nil {
+			return err
+		}
+
+		return recordAudit(ctx, repos, r, auditUpdate, "sale", id, before, sale)
+	})
+
+	if errors.Is(err, repositories.ErrNotFound) {
+		customerrors.NotFoundResponse(w, r)
+		return
+	}
+
+	if errors.Is(err, errInvalidSale) {
+		customerrors.BadRequestResponse(w, r, err)
+		return
+	}
+
+	if err != nil {
+		customerrors.ServerErrorResponse(w, r, err)
+		return
+	}
+
+	err = utils.WriteJSON(w, http.StatusOK, utils.Envelope{"sale": sale}, nil, logger.Logger)
+	if err != nil {
+		customerrors.ServerErrorResponse(w, r, err)
+	}
+}
+
+func (h *AdminHandler) DeleteSale(w http.ResponseWriter, r *http.Request) {
+	id, convErr := strconv.ParseInt(chi.URLParam(r, "sale_id"), 10, 64)
+
+	if convErr != nil {
+		customerrors.BadRequestResponse(w, r, convErr)
+		return
+	}
+
+	err := h.TxManager.WithinTx(r.Context(), func(ctx context.Context, repos *repositories.Repositories) error {
+		before, err := repos.SaleRepository.GetById(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if _, err := repos.SaleRepository.Delete(ctx, id); err != nil {
+			return err
+		}
+
+		return recordAudit(ctx, repos, r, auditDelete, "sale", id, before, nil)
+	})
+
+	if errors.Is(err, repositories.ErrNotFound) {
+		customerrors.NotFoundResponse(w, r)
+		return
+	}
+
+	if err != nil {
+		customerrors.ServerErrorResponse(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
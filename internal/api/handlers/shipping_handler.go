@@ -0,0 +1,419 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"training/proj/internal/api/models"
+	"training/proj/internal/customerrors"
+	"training/proj/internal/db/repositories"
+	"training/proj/internal/logger"
+	"training/proj/internal/money"
+	"training/proj/internal/shipping"
+	"training/proj/internal/utils"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const (
+	maxPostalCodeLength = 16
+	maxItemWeightGrams  = 10_000_000
+	maxItemDimensionMM  = 100_000
+)
+
+var countryPattern = regexp.MustCompile(`^[A-Z]{2}$`)
+
+type ShippingHandler struct {
+	ItemRepository         repositories.ItemRepositoryInterface
+	SaleRepository         repositories.SaleRepositoryInterface
+	ShippingRepository     repositories.ShippingRepositoryInterface
+	ExchangeRateRepository repositories.ExchangeRateRepositoryInterface
+	TxManager              repositories.TxManager
+}
+
+func NewShippingHandler(ir repositories.ItemRepositoryInterface, sr repositories.SaleRepositoryInterface,
+	shr repositories.ShippingRepositoryInterface, er repositories.ExchangeRateRepositoryInterface, tm repositories.TxManager) *ShippingHandler {
+	return &ShippingHandler{
+		ItemRepository:         ir,
+		SaleRepository:         sr,
+		ShippingRepository:     shr,
+		ExchangeRateRepository: er,
+		TxManager:              tm,
+	}
+}
+
+func normalizeCountry(country string) (string, error) {
+	country = strings.ToUpper(strings.TrimSpace(country))
+
+	if !countryPattern.MatchString(country) {
+		return "", fmt.Errorf("country %q must be an ISO 3166 alpha-2 code such as DE", country)
+	}
+
+	return country, nil
+}
+
+// validateItemSize checks the weight and dimensions of an item, which are
+// all optional.
+func validateItemSize(item models.Item) error {
+	if item.WeightGrams != nil && (*item.WeightGrams <= 0 || *item.WeightGrams > maxItemWeightGrams) {
+		return fmt.Errorf("weight_grams must be between 1 and %d", maxItemWeightGrams)
+	}
+
+	dimensions := []struct {
+		name string
+		size *int64
+	}{{"length_mm", item.LengthMM}, {"width_mm", item.WidthMM}, {"height_mm", item.HeightMM}}
+
+	for _, dimension := range dimensions {
+		if dimension.size != nil && (*dimension.size <= 0 || *dimension.size > maxItemDimensionMM) {
+			return fmt.Errorf("%s must be between 1 and %d", dimension.name, maxItemDimensionMM)
+		}
+	}
+
+	return nil
+}
+
+// PostShippingQuote lists the shipping methods that can deliver a basket to
+// a destination, with their prices and delivery windows.
+func (h *ShippingHandler) PostShippingQuote(w http.ResponseWriter, r *http.Request) {
+	var req models.ShippingQuoteRequest
+
+	if err := utils.ReadJSON(w, r, &req, logger.Logger); err != nil {
+		customerrors.BadRequestResponse(w, r, err)
+		return
+	}
+
+	basket, validErr := validateBasket(req.Items)
+
+	if validErr != nil {
+		customerrors.BadRequestResponse(w, r, validErr)
+		return
+	}
+
+	country, countryErr := normalizeCountry(req.Country)
+
+	if countryErr != nil {
+		customerrors.BadRequestResponse(w, r, countryErr)
+		return
+	}
+
+	postalCode := shipping.NormalizePostalCode(req.PostalCode)
+
+	if postalCode == "" || len(postalCode) > maxPostalCodeLength {
+		customerrors.BadRequestResponse(w, r, fmt.Errorf("postal_code is required and must have at most %d characters", maxPostalCodeLength))
+		return
+	}
+
+	var currency string
+
+	if req.Currency != "" {
+		var err error
+		if currency, err = money.Normalize(req.Currency); err != nil {
+			customerrors.BadRequestResponse(w, r, err)
+			return
+		}
+	}
+
+	var quote models.ShippingQuote
+
+	crudErr := h.TxManager.WithinTx(r.Context(), func(ctx context.Context, repos *repositories.Repositories) error {
+		items := make([]models.Item, 0, len(basket))
+
+		for _, entry := range basket {
+			item, err := repos.ItemRepository.GetById(ctx, entry.ItemID)
+			if errors.Is(err, repositories.ErrNotFound) {
+				return fmt.Errorf("%w: item %d does not exist", errInvalidBasket, entry.ItemID)
+			}
+			if err != nil {
+				return err
+			}
+
+			items = append(items, item)
+		}
+
+		if err := applySales(ctx, repos.SaleRepository, items); err != nil {
+			return err
+		}
+
+		lines := make([]shipping.Line, 0, len(items))
+		for i, item := range items {
+			lines = append(lines, shipping.Line{Item: item, Quantity: basket[i].Quantity})
+		}
+
+		methods, err := repos.ShippingRepository.ForCountry(ctx, country)
+		if err != nil {
+			return err
+		}
+
+		rates, err := loadRates(ctx, repos.ExchangeRateRepository)
+		if err != nil {
+			return err
+		}
+
+		quote = shipping.Quote(country, postalCode, lines, methods, currency, rates, time.Now())
+
+		return nil
+	}, repositories.WithIsolation(repositories.RepeatableRead), repositories.WithReadOnly())
+
+	if errors.Is(crudErr, errInvalidBasket) {
+		customerrors.ErrorResponse(w, r, http.StatusUnprocessableEntity, crudErr.Error())
+		return
+	}
+
+	if priceErrorResponse(w, r, crudErr) {
+		return
+	}
+
+	if crudErr != nil {
+		customerrors.ServerErrorResponse(w, r, crudErr)
+		return
+	}
+
+	err := utils.WriteJSON(w, http.StatusOK, utils.Envelope{"shipping": quote}, nil, logger.Logger)
+	if err != nil {
+		customerrors.ServerErrorResponse(w, r, err)
+	}
+}
+
+// validateShippingMethod normalizes the method's currency and its rules'
+// destinations, clears the rule fields that do not apply to its kind and
+// checks the rest.
+func validateShippingMethod(method *models.ShippingMethod) error {
+	if err := validator.New().Struct(method); err != nil {
+		return err
+	}
+
+	currency, err := money.Normalize(method.Currency)
+	if err != nil {
+		return err
+	}
+	method.Currency = currency
+
+	if method.Rules == nil {
+		method.Rules = make([]models.ShippingRule, 0)
+	}
+
+	for i := range method.Rules {
+		rule := &method.Rules[i]
+
+		if rule.Country, err = normalizeCountry(rule.Country); err != nil {
+			return err
+		}
+		rule.PostalPrefix = shipping.NormalizePostalCode(rule.PostalPrefix)
+
+		if len(rule.PostalPrefix) > maxPostalCodeLength {
+			return fmt.Errorf("postal_prefix must have at most %d characters", maxPostalCodeLength)
+		}
+
+		switch method.Kind {
+		case models.ShippingFlat:
+			rule.PerKg, rule.FreeOver = 0, nil
+
+		case models.ShippingWeight:
+			if rule.PerKg == 0 {
+				return errors.New("per_kg is required for weight methods")
+			}
+			rule.FreeOver = nil
+
+		case models.ShippingFreeOver:
+			if rule.FreeOver == nil {
+				return errors.New("free_over is required for free_over methods")
+			}
+			rule.PerKg = 0
+		}
+	}
+
+	return nil
+}
+
+// shippingErrorResponse maps the errors of shipping method writes. It
+// reports false if err is not one of them.
+func shippingErrorResponse(w http.ResponseWriter, r *http.Request, err error) bool {
+	var pgErr *pgconn.PgError
+
+	switch {
+	case errors.Is(err, repositories.ErrNotFound):
+		customerrors.NotFoundResponse(w, r)
+	case errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation:
+		customerrors.ErrorResponse(w, r, http.StatusConflict, "a method can have only one rule per country and postal prefix")
+	default:
+		return false
+	}
+
+	return true
+}
+
+func (h *AdminHandler) GetShippingMethods(w http.ResponseWriter, r *http.Request) {
+	methods, err := h.ShippingRepository.GetAll(r.Context())
+	if err != nil {
+		customerrors.ServerErrorResponse(w, r, err)
+		return
+	}
+
+	err = utils.WriteJSON(w, http.StatusOK, utils.Envelope{"shipping_methods": methods}, nil, logger.Logger)
+	if err != nil {
+		customerrors.ServerErrorResponse(w, r, err)
+	}
+}
+
+func (h *AdminHandler) GetShippingMethod(w http.ResponseWriter, r *http.Request) {
+	id, convErr := strconv.ParseInt(chi.URLParam(r, "method_id"), 10, 64)
+
+	if convErr != nil {
+		customerrors.BadRequestResponse(w, r, convErr)
+		return
+	}
+
+	method, err := h.ShippingRepository.GetById(r.Context(), id)
+
+	if errors.Is(err, repositories.ErrNotFound) {
+		customerrors.NotFoundResponse(w, r)
+		return
+	}
+
+	if err != nil {
+		customerrors.ServerErrorResponse(w, r, err)
+		return
+	}
+
+	err = utils.WriteJSON(w, http.StatusOK, utils.Envelope{"shipping_method": method}, nil, logger.Logger)
+	if err != nil {
+		customerrors.ServerErrorResponse(w, r, err)
+	}
+}
+
+func (h *AdminHandler) PostShippingMethod(w http.ResponseWriter, r *http.Request) {
+	// Methods are active unless the request says otherwise.
+	req := models.ShippingMethod{Active: true}
+
+	if err := utils.ReadJSON(w, r, &req, logger.Logger); err != nil {
+		customerrors.BadRequestResponse(w, r, err)
+		return
+	}
+
+	if err := validateShippingMethod(&req); err != nil {
+		customerrors.BadRequestResponse(w, r, err)
+		return
+	}
+
+	var method models.ShippingMethod
+
+	err := h.TxManager.WithinTx(r.Context(), func(ctx context.Context, repos *repositories.Repositories) error {
+		var err error
+		method, err = repos.ShippingRepository.Create(ctx, &req)
+		if err != nil {
+			return err
+		}
+
+		return recordAudit(ctx, repos, r, auditCreate, "shipping_method", method.MethodID, nil, method)
+	})
+
+	if shippingErrorResponse(w, r, err) {
+		return
+	}
+
+	if err != nil {
+		customerrors.ServerErrorResponse(w, r, err)
+		return
+	}
+
+	err = utils.WriteJSON(w, http.StatusCreated, utils.Envelope{"shipping_method": method}, nil, logger.Logger)
+	if err != nil {
+		customerrors.ServerErrorResponse(w, r, err)
+	}
+}
+
+// PutShippingMethod replaces a method together with all of its rules.
+func (h *AdminHandler) PutShippingMethod(w http.ResponseWriter, r *http.Request) {
+	id, convErr := strconv.ParseInt(chi.URLParam(r, "method_id"), 10, 64)
+
+	if convErr != nil {
+		customerrors.BadRequestResponse(w, r, convErr)
+		return
+	}
+
+	// Methods are active unless the request says otherwise.
+	req := models.ShippingMethod{Active: true}
+
+	if err := utils.ReadJSON(w, r, &req, logger.Logger); err != nil {
+		customerrors.BadRequestResponse(w, r, err)
+		return
+	}
+
+	if err := validateShippingMethod(&req); err != nil {
+		customerrors.BadRequestResponse(w, r, err)
+		return
+	}
+
+	var method models.ShippingMethod
+
+	err := h.TxManager.WithinTx(r.Context(), func(ctx context.Context, repos *repositories.Repositories) error {
+		before, err := repos.ShippingRepository.GetById(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		method, err = repos.ShippingRepository.Update(ctx, id, &req)
+		if err != nil {
+			return err
+		}
+
+		return recordAudit(ctx, repos, r, auditUpdate, "shipping_method", id, before, method)
+	})
+
+	if shippingErrorResponse(w, r, err) {
+		return
+	}
+
+	if err != nil {
+		customerrors.ServerErrorResponse(w, r, err)
+		return
+	}
+
+	err = utils.WriteJSON(w, http.StatusOK, utils.Envelope{"shipping_method": method}, nil, logger.Logger)
+	if err != nil {
+		customerrors.ServerErrorResponse(w, r, err)
+	}
+}
+
+func (h *AdminHandler) DeleteShippingMethod(w http.ResponseWriter, r *http.Request) {
+	id, convErr := strconv.ParseInt(chi.URLParam(r, "method_id"), 10, 64)
+
+	if convErr != nil {
+		customerrors.BadRequestResponse(w, r, convErr)
+		return
+	}
+
+	err := h.TxManager.WithinTx(r.Context(), func(ctx context.Context, repos *repositories.Repositories) error {
+		before, err := repos.ShippingRepository.GetById(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if _, err := repos.ShippingRepository.Delete(ctx, id); err != nil {
+			return err
+		}
+
+		return recordAudit(ctx, repos, r, auditDelete, "shipping_method", id, before, nil)
+	})
+
+	if errors.Is(err, repositories.ErrNotFound) {
+		customerrors.NotFoundResponse(w, r)
+		return
+	}
+
+	if err != nil {
+		customerrors.ServerErrorResponse(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
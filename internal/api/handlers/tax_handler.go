@@ -0,0 +1,517 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"training/proj/internal/api/models"
+	"training/proj/internal/customerrors"
+	"training/proj/internal/db/repositories"
+	"training/proj/internal/logger"
+	"training/proj/internal/money"
+	"training/proj/internal/tax"
+	"training/proj/internal/utils"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+var (
+	taxZonePattern  = regexp.MustCompile(`^[A-Z]{2}(-[A-Z0-9]{1,3})?$`)
+	taxClassPattern = regexp.MustCompile(`^[a-z0-9_-]{1,32}$`)
+)
+
+var errInvalidTax = errors.New("invalid tax setting")
+
+// requestedTaxRegion returns the zone asked for with ?tax_region=, or an
+// empty string if prices should be shown without tax.
+func requestedTaxRegion(r *http.Request) (string, error) {
+	region := r.URL.Query().Get("tax_region")
+	if region == "" {
+		return "", nil
+	}
+
+	return normalizeTaxZone(region)
+}
+
+func normalizeTaxZone(code string) (string, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+
+	if !taxZonePattern.MatchString(code) {
+		return "", fmt.Errorf("%w: tax zone %q must be an ISO 3166 code such as DE or US-CA", errInvalidTax, code)
+	}
+
+	return code, nil
+}
+
+func normalizeTaxClass(code string) (string, error) {
+	code = strings.ToLower(strings.TrimSpace(code))
+
+	if !taxClassPattern.MatchString(code) {
+		return "", fmt.Errorf("%w: tax class %q may only contain up to 32 letters, digits, '-' and '_'", errInvalidTax, code)
+	}
+
+	return code, nil
+}
+
+// applyTax sets the tax breakdown of the items' prices, and of their sale
+// prices, for region. It must run after currency conversion and sales so
+// that tax is worked out on the amounts shown.
+func applyTax(ctx context.Context, repo repositories.TaxRepositoryInterface, calc tax.Calculator, items []models.Item, region string) error {
+	if region == "" || len(items) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, 0, len(items))
+	for _, item := range items {
+		ids = append(ids, item.ItemID)
+	}
+
+	classes, err := repo.ItemClasses(ctx, ids)
+	if err != nil {
+		return err
+	}
+
+	lines := make([]tax.Line, 0, len(items))
+
+	for _, item := range items {
+		class := classes[item.ItemID]
+		lines = append(lines, tax.Line{Amount: money.Money{Amount: item.Price, Currency: item.Currency}, TaxClass: class})
+
+		if item.SalePrice != nil {
+			lines = append(lines, tax.Line{Amount: money.Money{Amount: *item.SalePrice, Currency: item.Currency}, TaxClass: class})
+		}
+	}
+
+	amounts, err := calc.Calculate(ctx, region, lines)
+	if err != nil {
+		return err
+	}
+
+	next := 0
+
+	for i := range items {
+		items[i].Tax = &amounts[next]
+		next++
+
+		if items[i].SalePrice != nil {
+			items[i].SaleTax = &amounts[next]
+			next++
+		}
+	}
+
+	return nil
+}
+
+// taxErrorResponse maps the errors of tax setting writes. It reports false
+// if err is not one of them.
+func taxErrorResponse(w http.ResponseWriter, r *http.Request, err error) bool {
+	var pgErr *pgconn.PgError
+
+	switch {
+	case errors.Is(err, repositories.ErrNotFound):
+		customerrors.NotFoundResponse(w, r)
+	case errors.Is(err, errInvalidTax):
+		customerrors.BadRequestResponse(w, r, err)
+	case errors.As(err, &pgErr) && pgErr.Code == pgerrcode.ForeignKeyViolation:
+		customerrors.BadRequestResponse(w, r, errors.New("the tax zone or tax class does not exist"))
+	case errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation:
+		customerrors.ErrorResponse(w, r, http.StatusConflict, "a rate for this zone and class already takes effect at this time")
+	default:
+		return false
+	}
+
+	return true
+}
+
+func (h *AdminHandler) GetTaxZones(w http.ResponseWriter, r *http.Request) {
+	zones, err := h.TaxRepository.GetZones(r.Context())
+	if err != nil {
+		customerrors.ServerErrorResponse(w, r, err)
+		return
+	}
+
+	err = utils.WriteJSON(w, http.StatusOK, utils.Envelope{"zones": zones}, nil, logger.Logger)
+	if err != nil {
+		customerrors.ServerErrorResponse(w, r, err)
+	}
+}
+
+func (h *AdminHandler) PutTaxZone(w http.ResponseWriter, r *http.Request) {
+	code, codeErr := normalizeTaxZone(chi.URLParam(r, "zone"))
+
+	if codeErr != nil {
+		customerrors.BadRequestResponse(w, r, codeErr)
+		return
+	}
+
+	var zone models.TaxZone
+
+	if err := utils.ReadJSON(w, r, &zone, logger.Logger); err != nil {
+		customerrors.BadRequestResponse(w, r, err)
+		return
+	}
+
+	zone.Code = code
+
+	if err := validator.New().Struct(zone); err != nil {
+		customerrors.BadRequestResponse(w, r, err)
+		return
+	}
+
+	err := h.TxManager.WithinTx(r.Context(), func(ctx context.Context, repos *repositories.Repositories) error {
+		if err := repos.TaxRepository.UpsertZone(ctx, &zone); err != nil {
+			return err
+		}
+
+		return recordAudit(ctx, repos, r, auditUpdate, "tax_zone", code, nil, zone)
+	})
+
+	if err != nil {
+		customerrors.ServerErrorResponse(w, r, err)
+		return
+	}
+
+	err = utils.WriteJSON(w, http.StatusOK, utils.Envelope{"zone": zone}, nil, logger.Logger)
+	if err != nil {
+		customerrors.ServerErrorResponse(w, r, err)
+	}
+}
+
+// DeleteTaxZone removes a zone and all of its rates.
+func (h *AdminHandler) DeleteTaxZone(w http.ResponseWriter, r *http.Request) {
+	code, codeErr := normalizeTaxZone(chi.URLParam(r, "zone"))
+
+	if codeErr != nil {
+		customerrors.BadRequestResponse(w, r, codeErr)
+		return
+	}
+
+	err := h.TxManager.WithinTx(r.Context(), func(ctx context.Context, repos *repositories.Repositories) error {
+		rowsAffected, err := repos.TaxRepository.DeleteZone(ctx, code)
+		if err != nil {
+			return err
+		}
+
+		if rowsAffected == 0 {
+			return repositories.ErrNotFound
+		}
+
+		return recordAudit(ctx, repos, r, auditDelete, "tax_zone", code, nil, nil)
+	})
+
+	if errors.Is(err, repositories.ErrNotFound) {
+		customerrors.NotFoundResponse(w, r)
+		return
+	}
+
+	if err != nil {
+		customerrors.ServerErrorResponse(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *AdminHandler) GetTaxClasses(w http.ResponseWriter, r *http.Request) {
+	classes, err := h.TaxRepository.GetClasses(r.Context())
+	if err != nil {
+		customerrors.ServerErrorResponse(w, r, err)
+		return
+	}
+
+	err = utils.WriteJSON(w, http.StatusOK, utils.Envelope{"classes": classes}, nil, logger.Logger)
+	if err != nil {
+		customerrors.ServerErrorResponse(w, r, err)
+	}
+}
+
+func (h *AdminHandler) PutTaxClass(w http.ResponseWriter, r *http.Request) {
+	code, codeErr := normalizeTaxClass(chi.URLParam(r, "class"))
+
+	if codeErr != nil {
+		customerrors.BadRequestResponse(w, r, codeErr)
+		return
+	}
+
+	var class models.TaxClass
+
+	if err := utils.ReadJSON(w, r, &class, logger.Logger); err != nil {
+		customerrors.BadRequestResponse(w, r, err)
+		return
+	}
+
+	class.Code = code
+
+	if err := validator.New().Struct(class); err != nil {
+		customerrors.BadRequestResponse(w, r, err)
+		return
+	}
+
+	err := h.TxManager.WithinTx(r.Context(), func(ctx context.Context, repos *repositories.Repositories) error {
+		if err := repos.TaxRepository.UpsertClass(ctx, &class); err != nil {
+			return err
+		}
+
+		return recordAudit(ctx, repos, r, auditUpdate, "tax_class", code, nil, class)
+	})
+
+	if err != nil {
+		customerrors.ServerErrorResponse(w, r, err)
+		return
+	}
+
+	err = utils.WriteJSON(w, http.StatusOK, utils.Envelope{"class": class}, nil, logger.Logger)
+	if err != nil {
+		customerrors.ServerErrorResponse(w, r, err)
+	}
+}
+
+// DeleteTaxClass removes a class and its rates. Items and categories that
+// had the class fall back to the next class that applies to them. The
+// default class cannot be removed.
+func (h *AdminHandler) DeleteTaxClass(w http.ResponseWriter, r *http.Request) {
+	code, codeErr := normalizeTaxClass(chi.URLParam(r, "class"))
+
+	if codeErr != nil {
+		customerrors.BadRequestResponse(w, r, codeErr)
+		return
+	}
+
+	if code == models.DefaultTaxClass {
+		customerrors.ErrorResponse(w, r, http.StatusConflict, "the default tax class cannot be deleted")
+		return
+	}
+
+	err := h.TxManager.WithinTx(r.Context(), func(ctx context.Context, repos *repositories.Repositories) error {
+		rowsAffected, err := repos.TaxRepository.DeleteClass(ctx, code)
+		if err != nil {
+			return err
+		}
+
+		if rowsAffected == 0 {
+			return repositories.ErrNotFound
+		}
+
+		return recordAudit(ctx, repos, r, auditDelete, "tax_class", code, nil, nil)
+	})
+
+	if errors.Is(err, repositories.ErrNotFound) {
+		customerrors.NotFoundResponse(w, r)
+		return
+	}
+
+	if err != nil {
+		customerrors.ServerErrorResponse(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetTaxRates lists the rates of the zone given with ?zone=, or of every
+// zone.
+func (h *AdminHandler) GetTaxRates(w http.ResponseWriter, r *http.Request) {
+	zone := r.URL.Query().Get("zone")
+
+	if zone != "" {
+		var err error
+		if zone, err = normalizeTaxZone(zone); err != nil {
+			customerrors.BadRequestResponse(w, r, err)
+			return
+		}
+	}
+
+	rates, err := h.TaxRepository.GetRates(r.Context(), zone)
+	if err != nil {
+		customerrors.ServerErrorResponse(w, r, err)
+		return
+	}
+
+	err = utils.WriteJSON(w, http.StatusOK, utils.Envelope{"rates": rates}, nil, logger.Logger)
+	if err != nil {
+		customerrors.ServerErrorResponse(w, r, err)
+	}
+}
+
+// validateTaxRate normalizes the rate's zone and class and checks that the
+// rate is a percentage between 0 and 100 with at most four decimals.
+func validateTaxRate(rate *models.TaxRate) error {
+	if err := validator.New().Struct(rate); err != nil {
+		return err
+	}
+
+	var err error
+
+	if rate.Zone, err = normalizeTaxZone(rate.Zone); err != nil {
+		return err
+	}
+
+	if rate.TaxClass, err = normalizeTaxClass(rate.TaxClass); err != nil {
+		return err
+	}
+
+	percent, ok := new(big.Rat).SetString(strings.TrimSpace(rate.Rate))
+	if !ok || percent.Sign() < 0 || percent.Cmp(big.NewRat(100, 1)) > 0 {
+		return fmt.Errorf("rate %q must be a percentage between 0 and 100", rate.Rate)
+	}
+
+	if !new(big.Rat).Mul(percent, big.NewRat(10000, 1)).IsInt() {
+		return fmt.Errorf("rate %q must have at most four decimals", rate.Rate)
+	}
+
+	rate.Rate = percent.FloatString(4)
+
+	return nil
+}
+
+func (h *AdminHandler) PostTaxRate(w http.ResponseWriter, r *http.Request) {
+	var rate models.TaxRate
+
+	if err := utils.ReadJSON(w, r, &rate, logger.Logger); err != nil {
+		customerrors.BadRequestResponse(w, r, err)
+		return
+	}
+
+	if err := validateTaxRate(&rate); err != nil {
+		customerrors.BadRequestResponse(w, r, err)
+		return
+	}
+
+	err := h.TxManager.WithinTx(r.Context(), func(ctx context.Context, repos *repositories.Repositories) error {
+		if err := repos.TaxRepository.CreateRate(ctx, &rate); err != nil {
+			return err
+		}
+
+		return recordAudit(ctx, repos, r, auditCreate, "tax_rate", rate.RateID, nil, rate)
+	})
+
+	if taxErrorResponse(w, r, err) {
+		return
+	}
+
+	if err != nil {
+		customerrors.ServerErrorResponse(w, r, err)
+		return
+	}
+
+	err = utils.WriteJSON(w, http.StatusCreated, utils.Envelope{"rate": rate}, nil, logger.Logger)
+	if err != nil {
+		customerrors.ServerErrorResponse(w, r, err)
+	}
+}
+
+func (h *AdminHandler) DeleteTaxRate(w http.ResponseWriter, r *http.Request) {
+	id, convErr := strconv.ParseInt(chi.URLParam(r, "rate_id"), 10, 64)
+
+	if convErr != nil {
+		customerrors.BadRequestResponse(w, r, convErr)
+		return
+	}
+
+	err := h.TxManager.WithinTx(r.Context(), func(ctx context.Context, repos *repositories.Repositories) error {
+		rowsAffected, err := repos.TaxRepository.DeleteRate(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if rowsAffected == 0 {
+			return repositories.ErrNotFound
+		}
+
+		return recordAudit(ctx, repos, r, auditDelete, "tax_rate", id, nil, nil)
+	})
+
+	if errors.Is(err, repositories.ErrNotFound) {
+		customerrors.NotFoundResponse(w, r)
+		return
+	}
+
+	if err != nil {
+		customerrors.ServerErrorResponse(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type taxClassAssignment struct {
+	TaxClass *string `json:"tax_class"`
+}
+
+// PutItemTaxClass assigns a tax class to an item. A null class makes the
+// item fall back to the class of its categories.
+func (h *AdminHandler) PutItemTaxClass(w http.ResponseWriter, r *http.Request) {
+	h.putTaxClass(w, r, "item_id", "item", func(ctx context.Context, repos *repositories.Repositories, id int64, class *string) (int64, error) {
+		return repos.TaxRepository.SetItemClass(ctx, id, class)
+	})
+}
+
+// PutCategoryTaxClass assigns a tax class to a category. It applies to the
+// category's items that have no class of their own.
+func (h *AdminHandler) PutCategoryTaxClass(w http.ResponseWriter, r *http.Request) {
+	h.putTaxClass(w, r, "category_id", "category", func(ctx context.Context, repos *repositories.Repositories, id int64, class *string) (int64, error) {
+		return repos.TaxRepository.SetCategoryClass(ctx, id, class)
+	})
+}
+
+type setTaxClassFunc func(ctx context.Context, repos *repositories.Repositories, id int64, class *string) (int64, error)
+
+func (h *AdminHandler) putTaxClass(w http.ResponseWriter, r *http.Request, param string, entityType string, set setTaxClassFunc) {
+	id, convErr := strconv.ParseInt(chi.URLParam(r, param), 10, 64)
+
+	if convErr != nil {
+		customerrors.BadRequestResponse(w, r, convErr)
+		return
+	}
+
+	var req taxClassAssignment
+
+	if err := utils.ReadJSON(w, r, &req, logger.Logger); err != nil {
+		customerrors.BadRequestResponse(w, r, err)
+		return
+	}
+
+	if req.TaxClass != nil {
+		class, err := normalizeTaxClass(*req.TaxClass)
+		if err != nil {
+			customerrors.BadRequestResponse(w, r, err)
+			return
+		}
+		req.TaxClass = &class
+	}
+
+	err := h.TxManager.WithinTx(r.Context(), func(ctx context.Context, repos *repositories.Repositories) error {
+		rowsAffected, err := set(ctx, repos, id, req.TaxClass)
+		if err != nil {
+			return err
+		}
+
+		if rowsAffected == 0 {
+			return repositories.ErrNotFound
+		}
+
+		return recordAudit(ctx, repos, r, auditUpdate, entityType, id, nil, req)
+	})
+
+	if taxErrorResponse(w, r, err) {
+		return
+	}
+
+	if err != nil {
+		customerrors.ServerErrorResponse(w, r, err)
+		return
+	}
+
+	err = utils.WriteJSON(w, http.StatusOK, utils.Envelope{"tax_class": req.TaxClass}, nil, logger.Logger)
+	if err != nil {
+		customerrors.ServerErrorResponse(w, r, err)
+	}
+}
@@ -1,30 +1,35 @@
 package handlers
 
 import (
-	"database/sql"
+	"context"
 	"encoding/json"
 	"errors"
 	"log"
 	"net/http"
 	"net/mail"
-	"os"
 	"training/proj/internal/api/models"
 	"training/proj/internal/customerrors"
 	"training/proj/internal/db/repositories"
+	"training/proj/internal/metrics"
 
 	"github.com/go-chi/jwtauth/v5"
 	"github.com/go-playground/validator/v10"
 	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5/pgconn"
 	"golang.org/x/crypto/bcrypt"
 )
 
 type UserHandler struct {
-	UserRepository *repositories.UserRepository
+	UserRepository repositories.UserRepositoryInterface
+	TxManager      repositories.TxManager
+	TokenAuth      *jwtauth.JWTAuth
 }
 
-func NewUserHandler(ur *repositories.UserRepository) *UserHandler {
+func NewUserHandler(ur repositories.UserRepositoryInterface, tm repositories.TxManager, jwtSecret string) *UserHandler {
 	return &UserHandler{
 		UserRepository: ur,
+		TxManager:      tm,
+		TokenAuth:      jwtauth.New("HS256", []byte(jwtSecret), nil),
 	}
 }
 
@@ -59,10 +64,29 @@ func (h *UserHandler) PostUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	userResp, crudErr := h.UserRepository.Create(&userReq, hashedPassword)
+	var userResp models.User
 
-	if crudErr != nil {
-		switch crudErr.Code {
+	crudErr := h.TxManager.WithinTx(r.Context(), func(ctx context.Context, repos *repositories.Repositories) error {
+		var err error
+		userResp, err = repos.UserRepository.Create(ctx, &userReq, hashedPassword)
+		if err != nil {
+			return err
+		}
+
+		entry, err := newAuditEntry(r, auditCreate, "user", userResp.UserID, nil, userResp)
+		if err != nil {
+			return err
+		}
+
+		// Signups are unauthenticated, so the new account is its own actor.
+		entry.ActorID, entry.Actor = userResp.UserID, userResp.Email
+		return repos.AuditRepository.Create(ctx, entry)
+	})
+
+	var pgErr *pgconn.PgError
+
+	if errors.As(crudErr, &pgErr) {
+		switch pgErr.Code {
 		case pgerrcode.UniqueViolation:
 			customerrors.EditConflictResponse(w, r)
 		default:
@@ -71,6 +95,13 @@ func (h *UserHandler) PostUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if crudErr != nil {
+		customerrors.ServerErrorResponse(w, r, crudErr)
+		return
+	}
+
+	metrics.SignupsTotal.Inc()
+
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(userResp)
 }
@@ -99,12 +130,13 @@ func (h *UserHandler) Login(w http.ResponseWriter, r *http.Request) {
 	_, emailErr := mail.ParseAddress(credentials.Login)
 
 	if emailErr != nil {
-		user, getUserErr = h.UserRepository.GetByUsername(credentials.Login)
+		user, getUserErr = h.UserRepository.GetByUsername(r.Context(), credentials.Login)
 	} else {
-		user, getUserErr = h.UserRepository.GetByEmail(credentials.Login)
+		user, getUserErr = h.UserRepository.GetByEmail(r.Context(), credentials.Login)
 	}
 
-	if getUserErr == sql.ErrNoRows {
+	if errors.Is(getUserErr, repositories.ErrNotFound) {
+		metrics.FailedLoginsTotal.Inc()
 		customerrors.InvalidCredentialsResponse(w, r)
 		return
 	}
@@ -122,13 +154,13 @@ func (h *UserHandler) Login(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if !match {
+		metrics.FailedLoginsTotal.Inc()
 		customerrors.InvalidCredentialsResponse(w, r)
 		return
 	}
 
-	token := jwtauth.New("HS256", []byte(os.Getenv("JWT_SECRET_KEY")), nil)
-	claims := map[string]interface{}{"user_id": user.UserID, "email": user.Email}
-	_, tokenString, err := token.Encode(claims)
+	claims := map[string]interface{}{"user_id": user.UserID, "email": user.Email, "is_admin": user.IsAdmin}
+	_, tokenString, err := h.TokenAuth.Encode(claims)
 
 	if err != nil {
 		customerrors.ServerErrorResponse(w, r, err)
@@ -139,6 +171,8 @@ func (h *UserHandler) Login(w http.ResponseWriter, r *http.Request) {
 		Token string `json:"token"`
 	}{Token: tokenString}
 
+	metrics.LoginsTotal.Inc()
+
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(resp)
 }
@@ -3,10 +3,18 @@ package middleware
 import (
 	"fmt"
 	"net/http"
+	"strconv"
+	"time"
 	"training/proj/internal/customerrors"
+	"training/proj/internal/metrics"
 
+	"github.com/go-chi/chi/v5"
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/jwtauth/v5"
 	"github.com/lestrrat-go/jwx/v2/jwt"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
 func RecoverPanic(next http.Handler) http.Handler {
@@ -40,3 +48,80 @@ func Authenticator(ja *jwtauth.JWTAuth) func(http.Handler) http.Handler {
 		return http.HandlerFunc(hfn)
 	}
 }
+
+func RequireAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, claims, err := jwtauth.FromContext(r.Context())
+		if err != nil {
+			customerrors.AuthenticationRequiredResponse(w, r)
+			return
+		}
+
+		if isAdmin, _ := claims["is_admin"].(bool); !isAdmin {
+			customerrors.NotPermittedResponse(w, r)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Metrics counts and times requests by route and status. It must run inside
+// RecoverPanic: a panicking request is counted as the 500 that RecoverPanic
+// sends, and the panic is passed on.
+func Metrics(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ww := chimiddleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+		defer func() {
+			p := recover()
+
+			route := routePattern(r)
+			if route == "" {
+				route = "unmatched"
+			}
+
+			status := ww.Status()
+			if p != nil {
+				status = http.StatusInternalServerError
+			} else if status == 0 {
+				status = http.StatusOK
+			}
+
+			labels := []string{r.Method, route, strconv.Itoa(status)}
+			metrics.HttpRequestsTotal.WithLabelValues(labels...).Inc()
+			metrics.HttpRequestDuration.WithLabelValues(labels...).Observe(time.Since(start).Seconds())
+
+			if p != nil {
+				panic(p)
+			}
+		}()
+
+		next.ServeHTTP(ww, r)
+	})
+}
+
+func Tracing(next http.Handler) http.Handler {
+	named := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r)
+
+		if route := routePattern(r); route != "" {
+			span := trace.SpanFromContext(r.Context())
+			span.SetName(r.Method + " " + route)
+			span.SetAttributes(semconv.HTTPRoute(route))
+		}
+	})
+
+	return otelhttp.NewHandler(named, "http.server")
+}
+
+func routePattern(r *http.Request) string {
+	rctx := chi.RouteContext(r.Context())
+
+	if rctx == nil {
+		return ""
+	}
+
+	return rctx.RoutePattern()
+}
@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"training/proj/internal/logger"
+	"training/proj/internal/metrics"
+
+	"github.com/go-chi/chi/v5"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.uber.org/zap"
+)
+
+var spans = tracetest.NewInMemoryExporter()
+
+func TestMain(m *testing.M) {
+	logger.Logger = zap.NewNop().Sugar()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(spans)))
+	m.Run()
+}
+
+func TestTracingNamesSpanAfterRoute(t *testing.T) {
+	spans.Reset()
+
+	r := chi.NewRouter()
+	r.Use(Tracing)
+	r.Get("/tracing-test/{id}", func(w http.ResponseWriter, r *http.Request) {})
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/tracing-test/7", nil))
+
+	got := spans.GetSpans()
+	if len(got) != 1 {
+		t.Fatalf("got %d spans, want 1", len(got))
+	}
+
+	if got[0].Name != "GET /tracing-test/{id}" {
+		t.Errorf("span name = %q, want %q", got[0].Name, "GET /tracing-test/{id}")
+	}
+
+	route := semconv.HTTPRoute("/tracing-test/{id}")
+	for _, attr := range got[0].Attributes {
+		if attr == route {
+			return
+		}
+	}
+	t.Errorf("span has no %s attribute", route.Key)
+}
+
+func TestMetricsCountsPanicAsServerError(t *testing.T) {
+	r := chi.NewRouter()
+	r.Use(RecoverPanic)
+	r.Use(Metrics)
+	r.Get("/metrics-test/panic", func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics-test/panic", nil))
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want 500", rec.Code)
+	}
+
+	scrape := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(scrape, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body, _ := io.ReadAll(scrape.Body)
+
+	want := `market_http_requests_total{method="GET",route="/metrics-test/panic",status="500"} 1`
+	if !strings.Contains(string(body), want) {
+		t.Fatalf("metrics do not contain %s", want)
+	}
+}
@@ -1,6 +1,9 @@
 package models
 
+import "time"
+
 type Category struct {
-	CategoryID int64  `json:"category_id"`
-	Category   string `json:"category" validate:"required"`
+	CategoryID int64      `json:"category_id"`
+	Category   string     `json:"category" validate:"required"`
+	DeletedAt  *time.Time `json:"deleted_at,omitempty"`
 }
@@ -0,0 +1,6 @@
+package models
+
+type CategoryItem struct {
+	CategoryID int64 `json:"category_id"`
+	ItemID     int64 `json:"item_id"`
+}
@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+const (
+	CouponPercent  = "percent"
+	CouponFixed    = "fixed"
+	CouponBuyXGetY = "buy_x_get_y"
+)
+
+// Coupon is a discount code. PercentOff applies to percent coupons,
+// AmountOff and Currency to fixed ones, and BuyQuantity and GetQuantity to
+// buy_x_get_y ones: for every BuyQuantity units bought, GetQuantity more
+// are free. A coupon without ItemIDs or CategoryIDs applies to every item.
+type Coupon struct {
+	CouponID    int64      `json:"coupon_id"`
+	Code        string     `json:"code" validate:"required,min=3,max=32"`
+	Kind        string     `json:"kind" validate:"oneof=percent fixed buy_x_get_y"`
+	PercentOff  int64      `json:"percent_off,omitempty" validate:"gte=0,lte=100"`
+	AmountOff   int64      `json:"amount_off,omitempty" validate:"gte=0"`
+	Currency    string     `json:"currency,omitempty"`
+	BuyQuantity int64      `json:"buy_quantity,omitempty" validate:"gte=0"`
+	GetQuantity int64      `json:"get_quantity,omitempty" validate:"gte=0"`
+	ItemIDs     []int64    `json:"item_ids"`
+	CategoryIDs []int64    `json:"category_ids"`
+	ValidFrom   *time.Time `json:"valid_from,omitempty"`
+	ValidUntil  *time.Time `json:"valid_until,omitempty"`
+	MaxUses     *int64     `json:"max_uses,omitempty" validate:"omitempty,gt=0"`
+	Uses        int64      `json:"uses"`
+	Stackable   bool       `json:"stackable"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// ExchangeRate is the number of units of Quote per unit of Base, kept as a
+// decimal string so that no precision is lost.
+type ExchangeRate struct {
+	Base      string    `json:"base"`
+	Quote     string    `json:"quote"`
+	Rate      string    `json:"rate"`
+	Source    string    `json:"source"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
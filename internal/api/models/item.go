@@ -1,7 +1,38 @@
 package models
 
+import (
+	"time"
+	"training/proj/internal/money"
+)
+
+// Item prices are in the minor unit of the item's base currency.
 type Item struct {
-	ItemID int64  `json:"item_id"`
-	Item   string `json:"item" validate:"required"`
-	Price  int64  `json:"price" validate:"required"`
+	ItemID    int64      `json:"item_id"`
+	Item      string     `json:"item" validate:"required"`
+	Price     int64      `json:"price" validate:"required"`
+	Currency  string     `json:"currency"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+
+	// WeightGrams and the dimensions of the packed item in millimetres are
+	// used to price shipping. They are nil when unknown.
+	WeightGrams *int64 `json:"weight_grams,omitempty"`
+	LengthMM    *int64 `json:"length_mm,omitempty"`
+	WidthMM     *int64 `json:"width_mm,omitempty"`
+	HeightMM    *int64 `json:"height_mm,omitempty"`
+
+	// RatingAvg is the mean review rating rounded to two decimals, or nil
+	// while the item has no reviews.
+	RatingAvg   *float64 `json:"rating_avg"`
+	RatingCount int64    `json:"rating_count"`
+
+	// BasePrice is set when Price has been converted to another currency.
+	BasePrice *money.Money `json:"base_price,omitempty"`
+
+	// SalePrice and Promotion are set while a sale covers the item.
+	SalePrice *int64 `json:"sale_price,omitempty"`
+	Promotion string `json:"promotion,omitempty"`
+
+	// Tax and SaleTax are set when prices are shown for a tax region.
+	Tax     *TaxAmounts `json:"tax,omitempty"`
+	SaleTax *TaxAmounts `json:"sale_tax,omitempty"`
 }
@@ -0,0 +1,17 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type JobRun struct {
+	JobRunID    int64           `json:"job_run_id"`
+	Job         string          `json:"job"`
+	TriggeredBy string          `json:"triggered_by"`
+	Status      string          `json:"status"`
+	StartedAt   time.Time       `json:"started_at"`
+	FinishedAt  *time.Time      `json:"finished_at,omitempty"`
+	Stats       json.RawMessage `json:"stats,omitempty"`
+	Error       string          `json:"error,omitempty"`
+}
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// PriceChange is an item price that took effect at EffectiveAt. Changes
+// scheduled for the future have no AppliedAt until the scheduler applies them.
+type PriceChange struct {
+	PriceID     int64      `json:"price_id"`
+	ItemID      int64      `json:"item_id"`
+	Price       int64      `json:"price"`
+	EffectiveAt time.Time  `json:"effective_at"`
+	AppliedAt   *time.Time `json:"applied_at,omitempty"`
+	ActorID     string     `json:"actor_id,omitempty"`
+	Actor       string     `json:"actor,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
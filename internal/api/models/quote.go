@@ -0,0 +1,59 @@
+package models
+
+type QuoteItem struct {
+	ItemID   int64 `json:"item_id"`
+	Quantity int64 `json:"quantity"`
+}
+
+type QuoteRequest struct {
+	Items []QuoteItem `json:"items"`
+	Codes []string    `json:"codes"`
+
+	// Currency to price the basket in. Defaults to the currency of the
+	// first item.
+	Currency string `json:"currency"`
+}
+
+// Quote prices a basket. All amounts are in the minor unit of Currency.
+type Quote struct {
+	Currency string          `json:"currency"`
+	Lines    []QuoteLine     `json:"lines"`
+	Subtotal int64           `json:"subtotal"`
+	Discount int64           `json:"discount"`
+	Total    int64           `json:"total"`
+	Applied  []AppliedCoupon `json:"applied"`
+	Rejected []RejectedCode  `json:"rejected"`
+}
+
+// QuoteLine is priced at the item's sale price while a sale covers it, in
+// which case ListPrice and Promotion are set.
+type QuoteLine struct {
+	ItemID    int64          `json:"item_id"`
+	Item      string         `json:"item"`
+	Quantity  int64          `json:"quantity"`
+	ListPrice int64          `json:"list_price,omitempty"`
+	Promotion string         `json:"promotion,omitempty"`
+	UnitPrice int64          `json:"unit_price"`
+	Subtotal  int64          `json:"subtotal"`
+	Discounts []LineDiscount `json:"discounts"`
+	Total     int64          `json:"total"`
+}
+
+// LineDiscount is the part of a coupon's discount taken off one line, with
+// a description of how it was worked out.
+type LineDiscount struct {
+	Code   string `json:"code"`
+	Amount int64  `json:"amount"`
+	Reason string `json:"reason"`
+}
+
+type AppliedCoupon struct {
+	CouponID int64  `json:"-"`
+	Code     string `json:"code"`
+	Amount   int64  `json:"amount"`
+}
+
+type RejectedCode struct {
+	Code   string `json:"code"`
+	Reason string `json:"reason"`
+}
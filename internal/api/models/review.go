@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// Review is a user's rating of an item. VerifiedPurchase is set when the
+// user is known to have bought the item at the time of writing.
+type Review struct {
+	ReviewID         int64     `json:"review_id"`
+	ItemID           int64     `json:"item_id"`
+	UserID           int64     `json:"user_id"`
+	Rating           int64     `json:"rating" validate:"gte=1,lte=5"`
+	Title            string    `json:"title" validate:"required,max=200"`
+	Body             string    `json:"body" validate:"required,max=10000"`
+	VerifiedPurchase bool      `json:"verified_purchase"`
+	HelpfulCount     int64     `json:"helpful_count"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
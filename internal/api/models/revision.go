@@ -0,0 +1,80 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type Revision struct {
+	RevisionID int64           `json:"-"`
+	EntityType string          `json:"entity_type"`
+	EntityID   int64           `json:"entity_id"`
+	Revision   int64           `json:"revision"`
+	Data       json.RawMessage `json:"data"`
+	ActorID    string          `json:"actor_id,omitempty"`
+	Actor      string          `json:"actor,omitempty"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+type FieldChange struct {
+	Field string `json:"field"`
+	From  any    `json:"from"`
+	To    any    `json:"to"`
+}
+
+type RevisionDiff struct {
+	From    int64         `json:"from"`
+	To      int64         `json:"to"`
+	Changes []FieldChange `json:"changes"`
+}
+
+// ItemSnapshot is the part of an item a revision stores: the columns an
+// admin edits. Ratings, sale prices and other values derived from other
+// tables are left out. The optional fields have no omitempty so that a
+// snapshot records them being unset.
+type ItemSnapshot struct {
+	Item        string `json:"item"`
+	Price       int64  `json:"price"`
+	Currency    string `json:"currency"`
+	WeightGrams *int64 `json:"weight_grams"`
+	LengthMM    *int64 `json:"length_mm"`
+	WidthMM     *int64 `json:"width_mm"`
+	HeightMM    *int64 `json:"height_mm"`
+}
+
+func NewItemSnapshot(i Item) ItemSnapshot {
+	return ItemSnapshot{
+		Item:        i.Item,
+		Price:       i.Price,
+		Currency:    i.Currency,
+		WeightGrams: i.WeightGrams,
+		LengthMM:    i.LengthMM,
+		WidthMM:     i.WidthMM,
+		HeightMM:    i.HeightMM,
+	}
+}
+
+// Apply copies the snapshot onto the item.
+func (s ItemSnapshot) Apply(i *Item) {
+	i.Item = s.Item
+	i.Price = s.Price
+	i.Currency = s.Currency
+	i.WeightGrams = s.WeightGrams
+	i.LengthMM = s.LengthMM
+	i.WidthMM = s.WidthMM
+	i.HeightMM = s.HeightMM
+}
+
+// CategorySnapshot is the part of a category a revision stores.
+type CategorySnapshot struct {
+	Category string `json:"category"`
+}
+
+func NewCategorySnapshot(c Category) CategorySnapshot {
+	return CategorySnapshot{Category: c.Category}
+}
+
+// Apply copies the snapshot onto the category.
+func (s CategorySnapshot) Apply(c *Category) {
+	c.Category = s.Category
+}
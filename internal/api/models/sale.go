@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// Sale takes PercentOff off every item in a category between StartsAt and
+// EndsAt. When several sales cover an item, the one with the highest
+// Priority wins, then the one with the largest discount, then the oldest.
+type Sale struct {
+	SaleID     int64     `json:"sale_id"`
+	Name       string    `json:"name" validate:"required,max=100"`
+	CategoryID int64     `json:"category_id" validate:"required"`
+	PercentOff int64     `json:"percent_off" validate:"gte=1,lte=100"`
+	Priority   int64     `json:"priority"`
+	StartsAt   time.Time `json:"starts_at" validate:"required"`
+	EndsAt     time.Time `json:"ends_at" validate:"required,gtfield=StartsAt"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
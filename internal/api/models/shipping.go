@@ -0,0 +1,77 @@
+package models
+
+import "time"
+
+const (
+	ShippingFlat     = "flat"
+	ShippingWeight   = "weight"
+	ShippingFreeOver = "free_over"
+)
+
+// ShippingMethod is a way of delivering orders. Its rules set the price and
+// delivery time per destination; a method without a rule for a destination
+// is not offered there.
+type ShippingMethod struct {
+	MethodID  int64          `json:"method_id"`
+	Name      string         `json:"name" validate:"required,max=100"`
+	Kind      string         `json:"kind" validate:"oneof=flat weight free_over"`
+	Currency  string         `json:"currency" validate:"required"`
+	Active    bool           `json:"active"`
+	Rules     []ShippingRule `json:"rules" validate:"dive"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+}
+
+// ShippingRule prices a method for a country, or for the postal codes of a
+// country that start with PostalPrefix. Amounts are in the minor unit of
+// the method's currency. Rate is the price of flat methods, the base price
+// of weight methods, to which PerKg is added for every started kilogram,
+// and the price of free_over methods below FreeOver.
+type ShippingRule struct {
+	RuleID         int64  `json:"rule_id"`
+	Country        string `json:"country" validate:"required"`
+	PostalPrefix   string `json:"postal_prefix"`
+	Rate           int64  `json:"rate" validate:"gte=0"`
+	PerKg          int64  `json:"per_kg,omitempty" validate:"gte=0"`
+	FreeOver       *int64 `json:"free_over,omitempty" validate:"omitempty,gte=0"`
+	MaxWeightGrams *int64 `json:"max_weight_grams,omitempty" validate:"omitempty,gt=0"`
+	MinDays        int64  `json:"min_days" validate:"gte=0"`
+	MaxDays        int64  `json:"max_days" validate:"gtefield=MinDays"`
+}
+
+type ShippingQuoteRequest struct {
+	Items      []QuoteItem `json:"items"`
+	Country    string      `json:"country"`
+	PostalCode string      `json:"postal_code"`
+
+	// Currency to show prices in. Defaults to each method's currency.
+	Currency string `json:"currency"`
+}
+
+type ShippingQuote struct {
+	Country     string                `json:"country"`
+	PostalCode  string                `json:"postal_code"`
+	WeightGrams int64                 `json:"weight_grams"`
+	Options     []ShippingOption      `json:"options"`
+	Unavailable []UnavailableShipping `json:"unavailable"`
+}
+
+// ShippingOption is a method that can deliver the basket. The delivery
+// window counts business days from today.
+type ShippingOption struct {
+	MethodID        int64  `json:"method_id"`
+	Name            string `json:"name"`
+	Kind            string `json:"kind"`
+	Price           int64  `json:"price"`
+	Currency        string `json:"currency"`
+	MinDays         int64  `json:"min_days"`
+	MaxDays         int64  `json:"max_days"`
+	EarliestArrival string `json:"earliest_arrival"`
+	LatestArrival   string `json:"latest_arrival"`
+}
+
+type UnavailableShipping struct {
+	MethodID int64  `json:"method_id"`
+	Name     string `json:"name"`
+	Reason   string `json:"reason"`
+}
@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+// DefaultTaxClass applies to items that have no tax class of their own and
+// are not in a category that has one.
+const DefaultTaxClass = "standard"
+
+// TaxZone is a country or region with its own tax rates, identified by an
+// ISO 3166 code such as "DE" or "US-CA".
+type TaxZone struct {
+	Code string `json:"code"`
+	Name string `json:"name" validate:"required,max=100"`
+}
+
+type TaxClass struct {
+	Code string `json:"code"`
+	Name string `json:"name" validate:"required,max=100"`
+}
+
+// TaxRate is the percentage charged on a tax class in a zone from
+// EffectiveFrom until the next rate for the same zone and class.
+type TaxRate struct {
+	RateID        int64     `json:"rate_id"`
+	Zone          string    `json:"zone" validate:"required"`
+	TaxClass      string    `json:"tax_class" validate:"required"`
+	Rate          string    `json:"rate" validate:"required"`
+	EffectiveFrom time.Time `json:"effective_from" validate:"required"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// TaxAmounts breaks a price down into its net amount, the tax on it and the
+// gross amount, all in the minor unit of the price's currency.
+type TaxAmounts struct {
+	Region   string `json:"region"`
+	TaxClass string `json:"tax_class"`
+	Rate     string `json:"rate"`
+	Net      int64  `json:"net"`
+	Tax      int64  `json:"tax"`
+	Gross    int64  `json:"gross"`
+}
@@ -7,4 +7,5 @@ type User struct {
 	FirstName string `json:"first_name" validate:"required"`
 	LastName  string `json:"last_name" validate:"required"`
 	Password  string `json:"password,omitempty" validate:"required"`
+	IsAdmin   bool   `json:"is_admin"`
 }
@@ -5,25 +5,37 @@ import (
 	"training/proj/internal/api/middleware"
 	"training/proj/internal/config"
 	"training/proj/internal/customerrors"
+	"training/proj/internal/metrics"
 
 	"github.com/go-chi/chi/v5"
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/jwtauth/v5"
 )
 
 var tokenAuth *jwtauth.JWTAuth
 
 func SetupRoutes(r *chi.Mux, h *handlers.Handlers, cfg *config.Config) {
-	tokenAuth = jwtauth.New("HS256", []byte(cfg.JwtSecret), nil)
+	tokenAuth = jwtauth.New("HS256", []byte(cfg.JWT.Secret), nil)
 
 	r.NotFound(customerrors.NotFoundResponse)
 	r.MethodNotAllowed(customerrors.MethodNotAllowedResponse)
 
+	r.Use(chimiddleware.RequestID)
 	r.Use(middleware.RecoverPanic)
+	r.Use(middleware.Tracing)
+	r.Use(middleware.Metrics)
+
+	r.Handle("/metrics", metrics.Handler())
+	r.Get("/healthz", h.HealthHandler.Liveness)
+	r.Get("/readyz", h.HealthHandler.Readiness)
 
 	r.Route("/api/v1/", func(r chi.Router) {
 		r.Mount("/categories", categoryRoutes(h.CategoryHandler))
-		r.Mount("/items", itemsRoutes(h.ItemHandler))
+		r.Mount("/items", itemsRoutes(h.ItemHandler, h.ReviewHandler))
 		r.Mount("/users", usersRoutes(h.UserHandler))
+		r.Mount("/quotes", quoteRoutes(h.QuoteHandler))
+		r.Mount("/shipping", shippingRoutes(h.ShippingHandler))
+		r.Mount("/admin", adminRoutes(h.AdminHandler))
 	})
 }
 
@@ -42,17 +54,22 @@ func categoryRoutes(h *handlers.CategoryHandler) *chi.Mux {
 		r.Delete("/{category_id}", h.DeleteCategory)
 		r.Post("/", h.PostCategory)
 		r.Put("/{category_id}/items/{item_id}", h.PutCategoryItem)
+		r.Get("/{category_id}/revisions", h.GetCategoryRevisions)
+		r.Get("/{category_id}/revisions/diff", h.GetCategoryRevisionDiff)
+		r.Post("/{category_id}/revisions/{revision}/restore", h.PostCategoryRevisionRestore)
 	})
 
 	return r
 }
 
-func itemsRoutes(h *handlers.ItemHandler) *chi.Mux {
+func itemsRoutes(h *handlers.ItemHandler, rh *handlers.ReviewHandler) *chi.Mux {
 	r := chi.NewRouter()
 
 	r.Get("/", h.GetAllItems)
 	r.Get("/{item_id}", h.GetItem)
 	r.Get("/{item_id}/categories", h.GetItemCategories)
+	r.Get("/{item_id}/prices", h.GetItemPrices)
+	r.Get("/{item_id}/reviews", rh.GetItemReviews)
 
 	r.Group(func(r chi.Router) {
 		r.Use(jwtauth.Verifier(tokenAuth))
@@ -60,6 +77,17 @@ func itemsRoutes(h *handlers.ItemHandler) *chi.Mux {
 		r.Post("/", h.PostItem)
 		r.Put("/{item_id}", h.PutItem)
 		r.Delete("/{item_id}", h.DeleteItem)
+		r.Get("/{item_id}/revisions", h.GetItemRevisions)
+		r.Get("/{item_id}/revisions/diff", h.GetItemRevisionDiff)
+		r.Post("/{item_id}/revisions/{revision}/restore", h.PostItemRevisionRestore)
+		r.Get("/{item_id}/prices/scheduled", h.GetScheduledPrices)
+		r.Post("/{item_id}/prices/scheduled", h.PostScheduledPrice)
+		r.Delete("/{item_id}/prices/scheduled/{price_id}", h.DeleteScheduledPrice)
+		r.Post("/{item_id}/reviews", rh.PostItemReview)
+		r.Put("/{item_id}/reviews/{review_id}", rh.PutItemReview)
+		r.Delete("/{item_id}/reviews/{review_id}", rh.DeleteItemReview)
+		r.Post("/{item_id}/reviews/{review_id}/helpful", rh.PostReviewVote)
+		r.Delete("/{item_id}/reviews/{review_id}/helpful", rh.DeleteReviewVote)
 	})
 	return r
 }
@@ -72,3 +100,82 @@ func usersRoutes(h *handlers.UserHandler) *chi.Mux {
 
 	return r
 }
+
+func quoteRoutes(h *handlers.QuoteHandler) *chi.Mux {
+	r := chi.NewRouter()
+
+	r.Post("/", h.PostQuote)
+
+	r.Group(func(r chi.Router) {
+		r.Use(jwtauth.Verifier(tokenAuth))
+		r.Use(middleware.Authenticator(tokenAuth))
+		r.Post("/redeem", h.PostRedeemQuote)
+	})
+
+	return r
+}
+
+func shippingRoutes(h *handlers.ShippingHandler) *chi.Mux {
+	r := chi.NewRouter()
+
+	r.Post("/quotes", h.PostShippingQuote)
+
+	return r
+}
+
+func adminRoutes(h *handlers.AdminHandler) *chi.Mux {
+	r := chi.NewRouter()
+
+	r.Use(jwtauth.Verifier(tokenAuth))
+	r.Use(middleware.Authenticator(tokenAuth))
+	r.Use(middleware.RequireAdmin)
+
+	r.Get("/log-level", h.GetLogLevel)
+	r.Put("/log-level", h.PutLogLevel)
+
+	r.Get("/jobs", h.GetJobs)
+	r.Get("/jobs/{name}/runs", h.GetJobRuns)
+	r.Post("/jobs/{name}/run", h.PostJobRun)
+
+	r.Get("/audit", h.GetAudit)
+
+	r.Get("/trash", h.GetTrash)
+	r.Post("/trash/items/{item_id}/restore", h.PostRestoreItem)
+	r.Post("/trash/categories/{category_id}/restore", h.PostRestoreCategory)
+
+	r.Get("/exchange-rates", h.GetExchangeRates)
+	r.Put("/exchange-rates/{base}/{quote}", h.PutExchangeRate)
+	r.Delete("/exchange-rates/{base}/{quote}", h.DeleteExchangeRate)
+
+	r.Get("/coupons", h.GetCoupons)
+	r.Post("/coupons", h.PostCoupon)
+	r.Get("/coupons/{coupon_id}", h.GetCoupon)
+	r.Put("/coupons/{coupon_id}", h.PutCoupon)
+	r.Delete("/coupons/{coupon_id}", h.DeleteCoupon)
+
+	r.Get("/sales", h.GetSales)
+	r.Post("/sales", h.PostSale)
+	r.Get("/sales/{sale_id}", h.GetSale)
+	r.Put("/sales/{sale_id}", h.PutSale)
+	r.Delete("/sales/{sale_id}", h.DeleteSale)
+
+	r.Get("/tax/zones", h.GetTaxZones)
+	r.Put("/tax/zones/{zone}", h.PutTaxZone)
+	r.Delete("/tax/zones/{zone}", h.DeleteTaxZone)
+	r.Get("/tax/classes", h.GetTaxClasses)
+	r.Put("/tax/classes/{class}", h.PutTaxClass)
+	r.Delete("/tax/classes/{class}", h.DeleteTaxClass)
+	r.Get("/tax/rates", h.GetTaxRates)
+	r.Post("/tax/rates", h.PostTaxRate)
+	r.Delete("/tax/rates/{rate_id}", h.DeleteTaxRate)
+	r.Put("/tax/items/{item_id}", h.PutItemTaxClass)
+	r.Put("/tax/categories/{category_id}", h.PutCategoryTaxClass)
+
+	r.Get("/shipping-methods", h.GetShippingMethods)
+	r.Post("/shipping-methods", h.PostShippingMethod)
+	r.Get("/shipping-methods/{method_id}", h.GetShippingMethod)
+	r.Put("/shipping-methods/{method_id}", h.PutShippingMethod)
+	r.Delete("/shipping-methods/{method_id}", h.DeleteShippingMethod)
+
+	return r
+}
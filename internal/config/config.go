@@ -1,46 +1,340 @@
 package config
 
 import (
-	"database/sql"
+	"bytes"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"net"
+	"net/url"
 	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
 	"training/proj/internal/api/handlers"
 	"training/proj/internal/db/repositories"
+	"training/proj/internal/importer"
+	"training/proj/internal/logger"
+	"unicode"
+
+	"github.com/BurntSushi/toml"
+	"github.com/go-playground/validator/v10"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"gopkg.in/yaml.v3"
 )
 
 type Config struct {
-	Address   string
-	JwtSecret string
-	DSN       string
-	DbName    string
+	Server    ServerConfig            `yaml:"server" toml:"server"`
+	DB        DBConfig                `yaml:"db" toml:"db"`
+	JWT       JWTConfig               `yaml:"jwt" toml:"jwt"`
+	Scheduler SchedulerConfig         `yaml:"scheduler" toml:"scheduler"`
+	Trash     TrashConfig             `yaml:"trash" toml:"trash"`
+	Pricing   PricingConfig           `yaml:"pricing" toml:"pricing"`
+	Log       LogConfig               `yaml:"log" toml:"log"`
+	Tracing   TracingConfig           `yaml:"tracing" toml:"tracing"`
+	Importers []importer.SourceConfig `yaml:"importers" toml:"importers" validate:"dive"`
+
+	File        string `yaml:"-" toml:"-"`
+	PrintConfig bool   `yaml:"-" toml:"-"`
+}
+
+type ServerConfig struct {
+	Address         string        `yaml:"address" toml:"address" validate:"required"`
+	ReadTimeout     time.Duration `yaml:"read_timeout" toml:"read_timeout" validate:"gt=0"`
+	WriteTimeout    time.Duration `yaml:"write_timeout" toml:"write_timeout" validate:"gt=0"`
+	IdleTimeout     time.Duration `yaml:"idle_timeout" toml:"idle_timeout" validate:"gt=0"`
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" toml:"shutdown_timeout" validate:"gt=0"`
+	DrainDelay      time.Duration `yaml:"drain_delay" toml:"drain_delay" validate:"gte=0"`
+}
+
+type DBConfig struct {
+	DSN                  string        `yaml:"dsn" toml:"dsn"`
+	Host                 string        `yaml:"host" toml:"host" validate:"required_without=DSN"`
+	Port                 int           `yaml:"port" toml:"port" validate:"gt=0,lte=65535"`
+	User                 string        `yaml:"user" toml:"user" validate:"required_without=DSN"`
+	Password             string        `yaml:"password" toml:"password"`
+	Name                 string        `yaml:"name" toml:"name" validate:"required"`
+	SSLMode              string        `yaml:"ssl_mode" toml:"ssl_mode" validate:"oneof=disable allow prefer require verify-ca verify-full"`
+	QueryTimeout         time.Duration `yaml:"query_timeout" toml:"query_timeout" validate:"gte=0"`
+	MinConns             int           `yaml:"min_conns" toml:"min_conns" validate:"gte=0,ltefield=MaxConns"`
+	MaxConns             int           `yaml:"max_conns" toml:"max_conns" validate:"gte=2"`
+	MaxConnLifetime      time.Duration `yaml:"max_conn_lifetime" toml:"max_conn_lifetime" validate:"gt=0"`
+	MaxConnIdleTime      time.Duration `yaml:"max_conn_idle_time" toml:"max_conn_idle_time" validate:"gt=0"`
+	HealthCheckPeriod    time.Duration `yaml:"health_check_period" toml:"health_check_period" validate:"gt=0"`
+	StatementCacheSize   int           `yaml:"statement_cache_size" toml:"statement_cache_size" validate:"gte=0"`
+	ConnectRetryDeadline time.Duration `yaml:"connect_retry_deadline" toml:"connect_retry_deadline" validate:"gt=0"`
+}
+
+type JWTConfig struct {
+	Secret string `yaml:"secret" toml:"secret" validate:"required"`
+}
+
+type SchedulerConfig struct {
+	Enabled  bool          `yaml:"enabled" toml:"enabled"`
+	Schedule string        `yaml:"schedule" toml:"schedule" validate:"required"`
+	Jitter   time.Duration `yaml:"jitter" toml:"jitter" validate:"gte=0"`
+	Timeout  time.Duration `yaml:"timeout" toml:"timeout" validate:"gte=0"`
+	DryRun   bool          `yaml:"dry_run" toml:"dry_run"`
+}
+
+type TrashConfig struct {
+	Retention time.Duration `yaml:"retention" toml:"retention" validate:"gte=0"`
+	Schedule  string        `yaml:"schedule" toml:"schedule" validate:"required"`
+}
+
+type PricingConfig struct {
+	Schedule string `yaml:"schedule" toml:"schedule" validate:"required"`
+
+	// Currency is given to items created without one. Migration 0009 also
+	// labels the items that existed before currencies with it.
+	Currency      string `yaml:"currency" toml:"currency" validate:"required,iso4217"`
+	RatesURL      string `yaml:"rates_url" toml:"rates_url" validate:"omitempty,url"`
+	RatesSchedule string `yaml:"rates_schedule" toml:"rates_schedule" validate:"required"`
+}
+
+type LogConfig struct {
+	Level      string `yaml:"level" toml:"level" validate:"oneof=debug info warn error"`
+	Format     string `yaml:"format" toml:"format" validate:"oneof=json console"`
+	Sampling   bool   `yaml:"sampling" toml:"sampling"`
+	Caller     bool   `yaml:"caller" toml:"caller"`
+	File       string `yaml:"file" toml:"file"`
+	MaxSizeMB  int    `yaml:"max_size_mb" toml:"max_size_mb" validate:"gte=0"`
+	MaxBackups int    `yaml:"max_backups" toml:"max_backups" validate:"gte=0"`
+	MaxAgeDays int    `yaml:"max_age_days" toml:"max_age_days" validate:"gte=0"`
+	Compress   bool   `yaml:"compress" toml:"compress"`
+}
+
+type TracingConfig struct {
+	Exporter     string `yaml:"exporter" toml:"exporter" validate:"omitempty,oneof=none otlp stdout"`
+	OtlpEndpoint string `yaml:"otlp_endpoint" toml:"otlp_endpoint"`
 }
 
 func NewConfig() *Config {
-	return &Config{}
-}
-
-func (cfg *Config) ParseFlags() error {
-	address := fmt.Sprintf("%v:%v", os.Getenv("APP_HOST"), os.Getenv("APP_INTERNAL_PORT"))
-	flag.StringVar(&cfg.Address, "address", address, "API server address")
-	flag.StringVar(&cfg.JwtSecret, "jwtSecret", os.Getenv("JWT_SECRET_KEY"), "JWT secret key")
-
-	connectionString := fmt.Sprintf("user=%v password=%v host=%v port=%v dbname=%v sslmode=%v",
-		os.Getenv("POSTGRES_USER"),
-		os.Getenv("POSTGRES_PASSWORD"),
-		os.Getenv("POSTGRES_HOST"),
-		os.Getenv("DB_INTERNAL_PORT"),
-		os.Getenv("POSTGRES_DB"),
-		os.Getenv("POSTGRES_SSL_MODE"))
-	flag.StringVar(&cfg.DSN, "DSN", connectionString, "DSN")
-	flag.StringVar(&cfg.DbName, "dbName", os.Getenv("POSTGRES_DB"), "DB name")
+	return &Config{
+		Server: ServerConfig{
+			Address:         ":8080",
+			ReadTimeout:     10 * time.Second,
+			WriteTimeout:    30 * time.Second,
+			IdleTimeout:     time.Minute,
+			ShutdownTimeout: 5 * time.Second,
+			DrainDelay:      3 * time.Second,
+		},
+		DB: DBConfig{
+			Port:                 5432,
+			SSLMode:              "prefer",
+			QueryTimeout:         5 * time.Second,
+			MinConns:             2,
+			MaxConns:             10,
+			MaxConnLifetime:      time.Hour,
+			MaxConnIdleTime:      30 * time.Minute,
+			HealthCheckPeriod:    time.Minute,
+			StatementCacheSize:   512,
+			ConnectRetryDeadline: time.Minute,
+		},
+		Scheduler: SchedulerConfig{
+			Enabled:  true,
+			Schedule: "@hourly",
+			Jitter:   5 * time.Minute,
+			Timeout:  30 * time.Minute,
+		},
+		Trash: TrashConfig{
+			Retention: 30 * 24 * time.Hour,
+			Schedule:  "@daily",
+		},
+		Pricing: PricingConfig{
+			Schedule:      "@every 1m",
+			Currency:      "USD",
+			RatesSchedule: "@daily",
+		},
+		Log: LogConfig{
+			Level:      "info",
+			Format:     "json",
+			Sampling:   true,
+			Caller:     true,
+			MaxSizeMB:  100,
+			MaxBackups: 5,
+			MaxAgeDays: 28,
+		},
+		Tracing: TracingConfig{
+			Exporter: "none",
+		},
+		Importers: []importer.SourceConfig{
+			{Name: "emoji", Type: "emoji", Enabled: true, Timeout: 30 * time.Second},
+		},
+	}
+}
+
+// Load builds the configuration from defaults, then the config file, then
+// the environment, then command-line flags, and validates the result.
+func Load(fs *flag.FlagSet, args []string) (*Config, error) {
+	cfg := NewConfig()
+
+	cfg.File = os.Getenv("CONFIG_FILE")
+	if path, ok := lookupArg(args, "config"); ok {
+		cfg.File = path
+	}
+
+	if cfg.File != "" {
+		if err := cfg.loadFile(cfg.File); err != nil {
+			return nil, err
+		}
+	}
+
+	opts := cfg.options()
+
+	if err := applyEnv(cfg, opts); err != nil {
+		return nil, err
+	}
+
+	fs.StringVar(&cfg.File, "config", cfg.File, "Path to a YAML or TOML config file (env CONFIG_FILE)")
+	fs.BoolVar(&cfg.PrintConfig, "print-config", false, "Print the effective configuration with secrets redacted and exit")
+
+	for _, o := range opts {
+		fs.Var(o.value, o.flag, fmt.Sprintf("%s (env %s)", o.usage, o.env))
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if cfg.PrintConfig {
+		return cfg, nil
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+func (cfg *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read config file: %w", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("parse config file %s: %w", path, err)
+		}
+	case ".toml":
+		md, err := toml.Decode(string(data), cfg)
+		if err != nil {
+			return fmt.Errorf("parse config file %s: %w", path, err)
+		}
+		if undecoded := md.Undecoded(); len(undecoded) > 0 {
+			return fmt.Errorf("parse config file %s: unknown keys %v", path, undecoded)
+		}
+	default:
+		return fmt.Errorf("unsupported config file extension %q (use .yaml, .yml or .toml)", filepath.Ext(path))
+	}
+
 	return nil
 }
 
+func (cfg *Config) Validate() error {
+	validate := validator.New()
+	validate.RegisterTagNameFunc(func(f reflect.StructField) string {
+		name := strings.SplitN(f.Tag.Get("yaml"), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+
+	err := validate.Struct(cfg)
+
+	var validationErrs validator.ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		return err
+	}
+
+	msgs := make([]string, 0, len(validationErrs))
+	for _, fe := range validationErrs {
+		key := strings.TrimPrefix(fe.Namespace(), "Config.")
+		msgs = append(msgs, describeValidationError(key, fe))
+	}
+
+	return fmt.Errorf("invalid configuration:\n  %s", strings.Join(msgs, "\n  "))
+}
+
+func describeValidationError(key string, fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return fmt.Sprintf("%s is required", key)
+	case "required_without":
+		return fmt.Sprintf("%s is required when %s is not set", key, siblingKey(key, fe.Param()))
+	case "oneof":
+		return fmt.Sprintf("%s must be one of [%s], got %q", key, fe.Param(), fmt.Sprint(fe.Value()))
+	case "ltefield":
+		return fmt.Sprintf("%s must not be greater than %s", key, siblingKey(key, fe.Param()))
+	default:
+		return fmt.Sprintf("%s must satisfy %s=%s, got %v", key, fe.Tag(), fe.Param(), fe.Value())
+	}
+}
+
+func siblingKey(key string, field string) string {
+	var b strings.Builder
+
+	for i, r := range field {
+		if i > 0 && unicode.IsUpper(r) && unicode.IsLower(rune(field[i-1])) {
+			b.WriteByte('_')
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+
+	if i := strings.LastIndex(key, "."); i >= 0 {
+		return key[:i+1] + b.String()
+	}
+
+	return b.String()
+}
+
+func (c DBConfig) ConnString() string {
+	if c.DSN != "" {
+		return c.DSN
+	}
+
+	u := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(c.User, c.Password),
+		Host:     net.JoinHostPort(c.Host, strconv.Itoa(c.Port)),
+		Path:     "/" + c.Name,
+		RawQuery: url.Values{"sslmode": {c.SSLMode}}.Encode(),
+	}
+
+	return u.String()
+}
+
+func (c LogConfig) Options() logger.Options {
+	return logger.Options{
+		Level:      c.Level,
+		Format:     c.Format,
+		Sampling:   c.Sampling,
+		Caller:     c.Caller,
+		File:       c.File,
+		MaxSizeMB:  c.MaxSizeMB,
+		MaxBackups: c.MaxBackups,
+		MaxAgeDays: c.MaxAgeDays,
+		Compress:   c.Compress,
+	}
+}
+
 func (c *Config) InitializeHandlers(r *repositories.Repositories) *handlers.Handlers {
-	return handlers.NewHandlers(r.CategoryRepository, r.CategoryItemRepository, r.ItemRepository, r.UserRepository)
+	return handlers.NewHandlers(r, c.JWT.Secret, c.Pricing.Currency)
+}
+
+func (c *Config) InitializeImporters() ([]importer.Importer, error) {
+	return importer.Build(c.Importers)
 }
 
-func (c *Config) InitializeRepositories(db *sql.DB) *repositories.Repositories {
-	return repositories.NewRepositories(db)
+func (c *Config) InitializeRepositories(pool *pgxpool.Pool) *repositories.Repositories {
+	return repositories.NewRepositories(pool, c.DB.QueryTimeout)
 }
@@ -0,0 +1,233 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"training/proj/internal/importer"
+
+	"gopkg.in/yaml.v3"
+)
+
+const redacted = "[redacted]"
+
+type option struct {
+	key    string
+	flag   string
+	env    string
+	usage  string
+	value  value
+	secret bool
+}
+
+type value interface {
+	String() string
+	Set(string) error
+	Get() any
+}
+
+func (cfg *Config) options() []option {
+	return []option{
+		{key: "server.address", flag: "address", env: "APP_ADDRESS", usage: "API server address", value: stringValue{&cfg.Server.Address}},
+		{key: "server.read_timeout", flag: "readTimeout", env: "SERVER_READ_TIMEOUT", usage: "HTTP read timeout", value: durationValue{&cfg.Server.ReadTimeout}},
+		{key: "server.write_timeout", flag: "writeTimeout", env: "SERVER_WRITE_TIMEOUT", usage: "HTTP write timeout", value: durationValue{&cfg.Server.WriteTimeout}},
+		{key: "server.idle_timeout", flag: "idleTimeout", env: "SERVER_IDLE_TIMEOUT", usage: "HTTP keep-alive idle timeout", value: durationValue{&cfg.Server.IdleTimeout}},
+		{key: "server.shutdown_timeout", flag: "shutdownTimeout", env: "SERVER_SHUTDOWN_TIMEOUT", usage: "Graceful shutdown timeout", value: durationValue{&cfg.Server.ShutdownTimeout}},
+		{key: "server.drain_delay", flag: "drainDelay", env: "SERVER_DRAIN_DELAY", usage: "How long to report not-ready before shutting down", value: durationValue{&cfg.Server.DrainDelay}},
+
+		{key: "db.dsn", flag: "DSN", env: "DB_DSN", usage: "Database connection string (overrides the individual db settings)", value: stringValue{&cfg.DB.DSN}, secret: true},
+		{key: "db.host", flag: "dbHost", env: "POSTGRES_HOST", usage: "Database host", value: stringValue{&cfg.DB.Host}},
+		{key: "db.port", flag: "dbPort", env: "DB_INTERNAL_PORT", usage: "Database port", value: intValue{&cfg.DB.Port}},
+		{key: "db.user", flag: "dbUser", env: "POSTGRES_USER", usage: "Database user", value: stringValue{&cfg.DB.User}},
+		{key: "db.password", flag: "dbPassword", env: "POSTGRES_PASSWORD", usage: "Database password", value: stringValue{&cfg.DB.Password}, secret: true},
+		{key: "db.name", flag: "dbName", env: "POSTGRES_DB", usage: "Database name", value: stringValue{&cfg.DB.Name}},
+		{key: "db.ssl_mode", flag: "dbSslMode", env: "POSTGRES_SSL_MODE", usage: "Database SSL mode", value: stringValue{&cfg.DB.SSLMode}},
+		{key: "db.query_timeout", flag: "queryTimeout", env: "DB_QUERY_TIMEOUT", usage: "Per-query database timeout", value: durationValue{&cfg.DB.QueryTimeout}},
+		{key: "db.min_conns", flag: "dbMinConns", env: "DB_MIN_CONNS", usage: "Minimum number of pooled DB connections", value: intValue{&cfg.DB.MinConns}},
+		{key: "db.max_conns", flag: "dbMaxConns", env: "DB_MAX_CONNS", usage: "Maximum number of pooled DB connections", value: intValue{&cfg.DB.MaxConns}},
+		{key: "db.max_conn_lifetime", flag: "dbMaxConnLifetime", env: "DB_MAX_CONN_LIFETIME", usage: "Maximum lifetime of a pooled DB connection", value: durationValue{&cfg.DB.MaxConnLifetime}},
+		{key: "db.max_conn_idle_time", flag: "dbMaxConnIdleTime", env: "DB_MAX_CONN_IDLE_TIME", usage: "Maximum idle time of a pooled DB connection", value: durationValue{&cfg.DB.MaxConnIdleTime}},
+		{key: "db.health_check_period", flag: "dbHealthCheckPeriod", env: "DB_HEALTH_CHECK_PERIOD", usage: "Interval between pool health checks", value: durationValue{&cfg.DB.HealthCheckPeriod}},
+		{key: "db.statement_cache_size", flag: "dbStatementCacheSize", env: "DB_STATEMENT_CACHE_SIZE", usage: "Prepared statement cache capacity per connection (0 disables caching)", value: intValue{&cfg.DB.StatementCacheSize}},
+		{key: "db.connect_retry_deadline", flag: "dbConnectRetryDeadline", env: "DB_CONNECT_RETRY_DEADLINE", usage: "How long to retry connecting to the database at startup", value: durationValue{&cfg.DB.ConnectRetryDeadline}},
+
+		{key: "jwt.secret", flag: "jwtSecret", env: "JWT_SECRET_KEY", usage: "JWT signing secret", value: stringValue{&cfg.JWT.Secret}, secret: true},
+
+		{key: "scheduler.enabled", flag: "schedulerEnabled", env: "SCHEDULER_ENABLED", usage: "Run the background importer", value: boolValue{&cfg.Scheduler.Enabled}},
+		{key: "scheduler.schedule", flag: "schedulerSchedule", env: "SCHEDULER_SCHEDULE", usage: "Cron expression for importer runs, e.g. \"0 * * * *\" or \"@every 30m\"", value: stringValue{&cfg.Scheduler.Schedule}},
+		{key: "scheduler.jitter", flag: "schedulerJitter", env: "SCHEDULER_JITTER", usage: "Random delay added to each scheduled importer run", value: durationValue{&cfg.Scheduler.Jitter}},
+		{key: "scheduler.timeout", flag: "schedulerTimeout", env: "SCHEDULER_TIMEOUT", usage: "Abort an importer run after this duration (0 means no limit)", value: durationValue{&cfg.Scheduler.Timeout}},
+		{key: "scheduler.dry_run", flag: "dryRun", env: "SCHEDULER_DRY_RUN", usage: "Report what the importer would change without writing to the database", value: boolValue{&cfg.Scheduler.DryRun}},
+
+		{key: "trash.retention", flag: "trashRetention", env: "TRASH_RETENTION", usage: "Permanently delete trashed items and categories after this duration (0 keeps them forever)", value: durationValue{&cfg.Trash.Retention}},
+		{key: "trash.schedule", flag: "trashSchedule", env: "TRASH_SCHEDULE", usage: "Cron expression for purging the trash", value: stringValue{&cfg.Trash.Schedule}},
+
+		{key: "pricing.schedule", flag: "pricingSchedule", env: "PRICING_SCHEDULE", usage: "Cron expression for applying scheduled price changes", value: stringValue{&cfg.Pricing.Schedule}},
+		{key: "pricing.currency", flag: "pricingCurrency", env: "PRICING_CURRENCY", usage: "ISO 4217 currency for items created without one and for items that predate currencies when migrating", value: stringValue{&cfg.Pricing.Currency}},
+		{key: "pricing.rates_url", flag: "pricingRatesURL", env: "PRICING_RATES_URL", usage: "URL to import exchange rates from (empty disables the import)", value: stringValue{&cfg.Pricing.RatesURL}},
+		{key: "pricing.rates_schedule", flag: "pricingRatesSchedule", env: "PRICING_RATES_SCHEDULE", usage: "Cron expression for importing exchange rates", value: stringValue{&cfg.Pricing.RatesSchedule}},
+
+		{key: "log.level", flag: "logLevel", env: "LOG_LEVEL", usage: "Log level (debug|info|warn|error)", value: stringValue{&cfg.Log.Level}},
+		{key: "log.format", flag: "logFormat", env: "LOG_FORMAT", usage: "Log format (json|console)", value: stringValue{&cfg.Log.Format}},
+		{key: "log.sampling", flag: "logSampling", env: "LOG_SAMPLING", usage: "Sample repeated log entries", value: boolValue{&cfg.Log.Sampling}},
+		{key: "log.caller", flag: "logCaller", env: "LOG_CALLER", usage: "Annotate log entries with the calling file and line", value: boolValue{&cfg.Log.Caller}},
+		{key: "log.file", flag: "logFile", env: "LOG_FILE", usage: "Also write logs to this file, rotating it by size", value: stringValue{&cfg.Log.File}},
+		{key: "log.max_size_mb", flag: "logMaxSizeMB", env: "LOG_MAX_SIZE_MB", usage: "Log file size in megabytes before it is rotated", value: intValue{&cfg.Log.MaxSizeMB}},
+		{key: "log.max_backups", flag: "logMaxBackups", env: "LOG_MAX_BACKUPS", usage: "Number of rotated log files to keep (0 keeps all)", value: intValue{&cfg.Log.MaxBackups}},
+		{key: "log.max_age_days", flag: "logMaxAgeDays", env: "LOG_MAX_AGE_DAYS", usage: "Days to keep rotated log files (0 keeps them forever)", value: intValue{&cfg.Log.MaxAgeDays}},
+		{key: "log.compress", flag: "logCompress", env: "LOG_COMPRESS", usage: "Gzip rotated log files", value: boolValue{&cfg.Log.Compress}},
+
+		{key: "tracing.exporter", flag: "traceExporter", env: "OTEL_TRACES_EXPORTER", usage: "Trace exporter (otlp|stdout|none)", value: stringValue{&cfg.Tracing.Exporter}},
+		{key: "tracing.otlp_endpoint", flag: "otlpEndpoint", env: "OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", usage: "OTLP/HTTP traces endpoint URL", value: stringValue{&cfg.Tracing.OtlpEndpoint}},
+	}
+}
+
+// applyEnv overrides options from the environment. Every variable can also be
+// given as <NAME>_FILE pointing to a file that holds the value, which is how
+// docker and kubernetes secrets are mounted.
+func applyEnv(cfg *Config, opts []option) error {
+	host, hostSet := os.LookupEnv("APP_HOST")
+	port, portSet := os.LookupEnv("APP_INTERNAL_PORT")
+	if hostSet || portSet {
+		cfg.Server.Address = host + ":" + port
+	}
+
+	for _, o := range opts {
+		raw, ok, err := lookupEnv(o.env)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+
+		if err := o.value.Set(raw); err != nil {
+			return fmt.Errorf("invalid value for %s: %w", o.env, err)
+		}
+	}
+
+	return nil
+}
+
+func lookupEnv(name string) (string, bool, error) {
+	if path, ok := os.LookupEnv(name + "_FILE"); ok && path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", false, fmt.Errorf("read %s_FILE: %w", name, err)
+		}
+		return strings.TrimRight(string(data), "\r\n"), true, nil
+	}
+
+	v, ok := os.LookupEnv(name)
+	if !ok || v == "" {
+		return "", false, nil
+	}
+
+	return v, true, nil
+}
+
+func lookupArg(args []string, name string) (string, bool) {
+	for i, arg := range args {
+		if arg == "--" {
+			break
+		}
+
+		trimmed := strings.TrimLeft(arg, "-")
+		if trimmed == arg {
+			continue
+		}
+
+		if trimmed == name && i+1 < len(args) {
+			return args[i+1], true
+		}
+
+		if v, ok := strings.CutPrefix(trimmed, name+"="); ok {
+			return v, true
+		}
+	}
+
+	return "", false
+}
+
+func (cfg *Config) Print(w io.Writer) error {
+	out := map[string]any{}
+
+	for _, o := range cfg.options() {
+		section, key, _ := strings.Cut(o.key, ".")
+		if out[section] == nil {
+			out[section] = map[string]any{}
+		}
+
+		v := o.value.Get()
+		if o.secret && o.value.String() != "" {
+			v = redacted
+		}
+		out[section].(map[string]any)[key] = v
+	}
+
+	importers := make([]importer.SourceConfig, len(cfg.Importers))
+	for n, src := range cfg.Importers {
+		headers := make(map[string]string, len(src.Headers))
+		for k := range src.Headers {
+			headers[k] = redacted
+		}
+		src.Headers = headers
+		importers[n] = src
+	}
+	out["importers"] = importers
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	defer enc.Close()
+
+	return enc.Encode(out)
+}
+
+type stringValue struct{ p *string }
+
+func (v stringValue) String() string     { return *v.p }
+func (v stringValue) Get() any           { return *v.p }
+func (v stringValue) Set(s string) error { *v.p = s; return nil }
+
+type intValue struct{ p *int }
+
+func (v intValue) String() string { return strconv.Itoa(*v.p) }
+func (v intValue) Get() any       { return *v.p }
+func (v intValue) Set(s string) error {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return err
+	}
+	*v.p = n
+	return nil
+}
+
+type boolValue struct{ p *bool }
+
+func (v boolValue) String() string   { return strconv.FormatBool(*v.p) }
+func (v boolValue) Get() any         { return *v.p }
+func (v boolValue) IsBoolFlag() bool { return true }
+func (v boolValue) Set(s string) error {
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		return err
+	}
+	*v.p = b
+	return nil
+}
+
+type durationValue struct{ p *time.Duration }
+
+func (v durationValue) String() string { return v.p.String() }
+func (v durationValue) Get() any       { return v.p.String() }
+func (v durationValue) Set(s string) error {
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*v.p = d
+	return nil
+}
@@ -0,0 +1,280 @@
+package coupons
+
+import (
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+	"time"
+	"training/proj/internal/api/models"
+	"training/proj/internal/money"
+)
+
+// Line is a basket entry. The item's price, and its sale price if it has
+// one, must already be in the currency the basket is quoted in.
+type Line struct {
+	Item        models.Item
+	Quantity    int64
+	CategoryIDs []int64
+}
+
+// NormalizeCode trims a code and upper-cases it so that codes match
+// regardless of how they were typed.
+func NormalizeCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// Quote prices the lines in currency and applies the codes in the order
+// given. Each discount is taken from what is left of a line after the
+// codes before it, so no line goes below zero. Codes that cannot be
+// applied are listed in Rejected with the reason.
+func Quote(currency string, lines []Line, codes []string, available []models.Coupon, rates *money.Rates, now time.Time) models.Quote {
+	quote := models.Quote{
+		Currency: currency,
+		Lines:    make([]models.QuoteLine, len(lines)),
+		Applied:  make([]models.AppliedCoupon, 0),
+		Rejected: make([]models.RejectedCode, 0),
+	}
+
+	for i, line := range lines {
+		quoted := models.QuoteLine{
+			ItemID:    line.Item.ItemID,
+			Item:      line.Item.Item,
+			Quantity:  line.Quantity,
+			UnitPrice: line.Item.Price,
+			Discounts: make([]models.LineDiscount, 0),
+		}
+
+		if line.Item.SalePrice != nil {
+			quoted.ListPrice = line.Item.Price
+			quoted.Promotion = line.Item.Promotion
+			quoted.UnitPrice = *line.Item.SalePrice
+		}
+
+		quoted.Subtotal = quoted.UnitPrice * line.Quantity
+		quoted.Total = quoted.Subtotal
+		quote.Lines[i] = quoted
+		quote.Subtotal += quoted.Subtotal
+	}
+
+	var applied []models.Coupon
+
+	for _, raw := range codes {
+		code := NormalizeCode(raw)
+
+		reject := func(reason string) {
+			quote.Rejected = append(quote.Rejected, models.RejectedCode{Code: code, Reason: reason})
+		}
+
+		if code == "" {
+			reject("code is empty")
+			continue
+		}
+
+		if slices.ContainsFunc(applied, func(c models.Coupon) bool { return NormalizeCode(c.Code) == code }) {
+			reject("code has already been applied")
+			continue
+		}
+
+		i := slices.IndexFunc(available, func(c models.Coupon) bool { return NormalizeCode(c.Code) == code })
+		if i < 0 {
+			reject("code does not exist")
+			continue
+		}
+		coupon := available[i]
+
+		if reason := checkValidity(coupon, now); reason != "" {
+			reject(reason)
+			continue
+		}
+
+		if reason := checkStacking(coupon, applied); reason != "" {
+			reject(reason)
+			continue
+		}
+
+		eligible := eligibleLines(coupon, lines)
+		if len(eligible) == 0 {
+			reject("no item in the basket qualifies for this code")
+			continue
+		}
+
+		discounts, reason := discount(coupon, currency, quote.Lines, lines, eligible, rates)
+		if reason != "" {
+			reject(reason)
+			continue
+		}
+
+		var total int64
+		for i, d := range discounts {
+			if d.Amount == 0 {
+				continue
+			}
+			d.Code = code
+			quote.Lines[i].Discounts = append(quote.Lines[i].Discounts, d)
+			quote.Lines[i].Total -= d.Amount
+			total += d.Amount
+		}
+
+		if total == 0 {
+			reject("nothing left to discount")
+			continue
+		}
+
+		applied = append(applied, coupon)
+		quote.Applied = append(quote.Applied, models.AppliedCoupon{CouponID: coupon.CouponID, Code: code, Amount: total})
+		quote.Discount += total
+	}
+
+	quote.Total = quote.Subtotal - quote.Discount
+
+	return quote
+}
+
+func checkValidity(coupon models.Coupon, now time.Time) string {
+	if coupon.ValidFrom != nil && now.Before(*coupon.ValidFrom) {
+		return fmt.Sprintf("code is not valid until %s", coupon.ValidFrom.Format(time.RFC3339))
+	}
+
+	if coupon.ValidUntil != nil && !now.Before(*coupon.ValidUntil) {
+		return fmt.Sprintf("code expired at %s", coupon.ValidUntil.Format(time.RFC3339))
+	}
+
+	if coupon.MaxUses != nil && coupon.Uses >= *coupon.MaxUses {
+		return "code has reached its usage limit"
+	}
+
+	return ""
+}
+
+func checkStacking(coupon models.Coupon, applied []models.Coupon) string {
+	if len(applied) == 0 {
+		return ""
+	}
+
+	if !coupon.Stackable {
+		return "code cannot be combined with other codes"
+	}
+
+	for _, other := range applied {
+		if !other.Stackable {
+			return fmt.Sprintf("code cannot be combined with %s", NormalizeCode(other.Code))
+		}
+	}
+
+	return ""
+}
+
+// eligibleLines returns the indexes of the lines the coupon applies to.
+func eligibleLines(coupon models.Coupon, lines []Line) []int {
+	eligible := make([]int, 0, len(lines))
+
+	for i, line := range lines {
+		if len(coupon.ItemIDs) == 0 && len(coupon.CategoryIDs) == 0 ||
+			slices.Contains(coupon.ItemIDs, line.Item.ItemID) ||
+			slices.ContainsFunc(line.CategoryIDs, func(id int64) bool { return slices.Contains(coupon.CategoryIDs, id) }) {
+			eligible = append(eligible, i)
+		}
+	}
+
+	return eligible
+}
+
+// discount works out the coupon's discount on each eligible line, keyed by
+// line index, or the reason it cannot be applied.
+func discount(coupon models.Coupon, currency string, quoted []models.QuoteLine, lines []Line, eligible []int, rates *money.Rates) (map[int]models.LineDiscount, string) {
+	discounts := make(map[int]models.LineDiscount, len(eligible))
+
+	switch coupon.Kind {
+	case models.CouponPercent:
+		rate := big.NewRat(coupon.PercentOff, 100)
+		reason := fmt.Sprintf("%d%% off", coupon.PercentOff)
+
+		for _, i := range eligible {
+			off, err := money.Convert(money.Money{Amount: quoted[i].Total, Currency: currency}, currency, rate)
+			if err != nil {
+				return nil, err.Error()
+			}
+			discounts[i] = models.LineDiscount{Amount: off.Amount, Reason: reason}
+		}
+
+	case models.CouponFixed:
+		amount, err := rates.Convert(money.Money{Amount: coupon.AmountOff, Currency: coupon.Currency}, currency)
+		if err != nil {
+			return nil, fmt.Sprintf("code cannot be used with %s prices: %v", currency, err)
+		}
+
+		reason := fmt.Sprintf("%s off the qualifying items, split by line value", amount.Format())
+		if amount.Currency != coupon.Currency {
+			reason = fmt.Sprintf("%s (%s) off the qualifying items, split by line value",
+				money.Money{Amount: coupon.AmountOff, Currency: coupon.Currency}.Format(), amount.Format())
+		}
+
+		for i, off := range split(amount.Amount, quoted, eligible) {
+			discounts[i] = models.LineDiscount{Amount: off, Reason: reason}
+		}
+
+	case models.CouponBuyXGetY:
+		group := coupon.BuyQuantity + coupon.GetQuantity
+		var free int64
+
+		for _, i := range eligible {
+			units := lines[i].Quantity / group * coupon.GetQuantity
+			if units == 0 {
+				continue
+			}
+			free += units
+
+			discounts[i] = models.LineDiscount{
+				Amount: min(units*quoted[i].UnitPrice, quoted[i].Total),
+				Reason: fmt.Sprintf("buy %d get %d free: %d free", coupon.BuyQuantity, coupon.GetQuantity, units),
+			}
+		}
+
+		if free == 0 {
+			return nil, fmt.Sprintf("add %d of a qualifying item to the basket to get %d of them free", group, coupon.GetQuantity)
+		}
+
+	default:
+		return nil, fmt.Sprintf("unsupported coupon kind %q", coupon.Kind)
+	}
+
+	return discounts, ""
+}
+
+// split divides amount across the eligible lines in proportion to what is
+// left of each, never taking more than the lines are worth. Minor units
+// lost to rounding go to the first lines.
+func split(amount int64, quoted []models.QuoteLine, eligible []int) map[int]int64 {
+	var left int64
+	for _, i := range eligible {
+		left += quoted[i].Total
+	}
+
+	amount = min(amount, left)
+	shares := make(map[int]int64, len(eligible))
+
+	if left == 0 {
+		return shares
+	}
+
+	var given int64
+	for _, i := range eligible {
+		share := new(big.Int).Mul(big.NewInt(amount), big.NewInt(quoted[i].Total))
+		share.Quo(share, big.NewInt(left))
+		shares[i] = share.Int64()
+		given += shares[i]
+	}
+
+	for _, i := range eligible {
+		if given == amount {
+			break
+		}
+		if shares[i] < quoted[i].Total {
+			shares[i]++
+			given++
+		}
+	}
+
+	return shares
+}
@@ -1,6 +1,8 @@
 package customerrors
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
 
@@ -27,7 +29,21 @@ func ErrorResponse(w http.ResponseWriter, r *http.Request, status int, message i
 	}
 }
 
+// StatusClientClosedRequest is the non-standard status, borrowed from nginx,
+// recorded for requests the client abandoned before they finished.
+const StatusClientClosedRequest = 499
+
 func ServerErrorResponse(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, context.DeadlineExceeded) {
+		TimeoutResponse(w, r, err)
+		return
+	}
+
+	if errors.Is(err, context.Canceled) {
+		ClientClosedRequestResponse(w, r, err)
+		return
+	}
+
 	logger.Logger.Error("The server encountered a problem and could not process the request", zap.Error(err))
 	ErrorResponse(w, r, http.StatusInternalServerError, "the server encountered a problem and could not process your request")
 }
@@ -55,3 +71,20 @@ func InvalidCredentialsResponse(w http.ResponseWriter, r *http.Request) {
 func AuthenticationRequiredResponse(w http.ResponseWriter, r *http.Request) {
 	ErrorResponse(w, r, http.StatusUnauthorized, "you must be authenticated to access this resource")
 }
+
+func NotPermittedResponse(w http.ResponseWriter, r *http.Request) {
+	ErrorResponse(w, r, http.StatusForbidden, "your user account doesn't have the necessary permissions to access this resource")
+}
+
+func TimeoutResponse(w http.ResponseWriter, r *http.Request, err error) {
+	logger.Logger.Warn("The request timed out", zap.Error(err), zap.String("request_url", r.URL.String()))
+	ErrorResponse(w, r, http.StatusGatewayTimeout, "the server timed out while processing your request")
+}
+
+// ClientClosedRequestResponse handles a request whose context was canceled
+// because the client went away. It is not a server fault, so it is only
+// logged at debug level and the client, which is gone, gets no body.
+func ClientClosedRequestResponse(w http.ResponseWriter, r *http.Request, err error) {
+	logger.Logger.Debug("The client closed the request", zap.Error(err), zap.String("request_url", r.URL.String()))
+	w.WriteHeader(StatusClientClosedRequest)
+}
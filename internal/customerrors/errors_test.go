@@ -0,0 +1,32 @@
+package customerrors
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"training/proj/internal/logger"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestServerErrorResponseTreatsCanceledRequestAsClientClosed(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	logger.Logger = zap.New(core).Sugar()
+
+	rec := httptest.NewRecorder()
+	ServerErrorResponse(rec, httptest.NewRequest(http.MethodGet, "/items", nil), fmt.Errorf("load items: %w", context.Canceled))
+
+	if rec.Code != StatusClientClosedRequest {
+		t.Errorf("status = %d, want %d", rec.Code, StatusClientClosedRequest)
+	}
+	if rec.Body.Len() != 0 {
+		t.Errorf("body = %q, want none", rec.Body.String())
+	}
+	if n := logs.FilterLevelExact(zapcore.ErrorLevel).Len(); n != 0 {
+		t.Errorf("got %d error logs, want none", n)
+	}
+}
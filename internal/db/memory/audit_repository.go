@@ -0,0 +1,54 @@
+package memory
+
+import (
+	"context"
+	"time"
+	"training/proj/internal/api/models"
+	"training/proj/internal/db/repositories"
+)
+
+var _ repositories.AuditRepositoryInterface = (*AuditRepository)(nil)
+
+type AuditRepository struct {
+	store *Store
+}
+
+func NewAuditRepository(s *Store) *AuditRepository {
+	return &AuditRepository{
+		store: s,
+	}
+}
+
+func (r *AuditRepository) Create(ctx context.Context, entry *models.AuditEntry) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	entry.AuditID = int64(len(r.store.audit) + 1)
+	entry.OccurredAt = time.Now()
+	r.store.audit = append(r.store.audit, *entry)
+
+	return nil
+}
+
+func (r *AuditRepository) GetAll(ctx context.Context, filter repositories.AuditFilter) ([]models.AuditEntry, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	entries := make([]models.AuditEntry, 0)
+
+	for i := len(r.store.audit) - 1; i >= 0 && len(entries) < filter.Limit; i-- {
+		e := r.store.audit[i]
+
+		switch {
+		case filter.Actor != "" && e.ActorID != filter.Actor && e.Actor != filter.Actor:
+		case filter.EntityType != "" && e.EntityType != filter.EntityType:
+		case filter.EntityID != "" && e.EntityID != filter.EntityID:
+		case filter.From != nil && e.OccurredAt.Before(*filter.From):
+		case filter.To != nil && !e.OccurredAt.Before(*filter.To):
+		default:
+			entries = append(entries, e)
+		}
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,110 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"training/proj/internal/api/models"
+	"training/proj/internal/db/repositories"
+)
+
+var _ repositories.CategoryItemRepositoryInterface = (*CategoryItemRepository)(nil)
+
+type CategoryItemRepository struct {
+	store *Store
+}
+
+func NewCategoryItemRepository(s *Store) *CategoryItemRepository {
+	return &CategoryItemRepository{
+		store: s,
+	}
+}
+
+func (r *CategoryItemRepository) Create(ctx context.Context, categoryId int64, itemId int64) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	_, categoryExists := r.store.liveCategory(categoryId)
+	_, itemExists := r.store.liveItem(itemId)
+
+	if !categoryExists || !itemExists {
+		return repositories.ErrNotFound
+	}
+
+	l := link{categoryId: categoryId, itemId: itemId}
+
+	if _, ok := r.store.links[l]; ok {
+		return errUniqueViolation
+	}
+
+	r.store.links[l] = ""
+
+	return nil
+}
+
+func (r *CategoryItemRepository) Delete(ctx context.Context, categoryId int64, itemId int64) (int64, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	l := link{categoryId: categoryId, itemId: itemId}
+
+	if _, ok := r.store.links[l]; !ok {
+		return 0, nil
+	}
+
+	delete(r.store.links, l)
+
+	return 1, nil
+}
+
+func (r *CategoryItemRepository) GetAll(ctx context.Context) ([]models.CategoryItem, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	return r.store.liveLinks(func(string) bool { return true }), nil
+}
+
+func (r *CategoryItemRepository) GetBySource(ctx context.Context, source string) ([]models.CategoryItem, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	return r.store.liveLinks(func(linkSource string) bool { return source != "" && linkSource == source }), nil
+}
+
+func (r *CategoryItemRepository) SetSource(ctx context.Context, categoryId int64, itemId int64, source string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	l := link{categoryId: categoryId, itemId: itemId}
+
+	if _, ok := r.store.links[l]; !ok {
+		return repositories.ErrNotFound
+	}
+
+	r.store.links[l] = source
+
+	return nil
+}
+
+// liveLinks returns the links whose source matches and whose category and
+// item are not in the trash, in order. The caller must hold the lock.
+func (s *Store) liveLinks(match func(source string) bool) []models.CategoryItem {
+	links := make([]models.CategoryItem, 0, len(s.links))
+
+	for l, source := range s.links {
+		_, categoryLive := s.liveCategory(l.categoryId)
+		_, itemLive := s.liveItem(l.itemId)
+
+		if categoryLive && itemLive && match(source) {
+			links = append(links, models.CategoryItem{CategoryID: l.categoryId, ItemID: l.itemId})
+		}
+	}
+
+	sort.Slice(links, func(i, j int) bool {
+		if links[i].CategoryID != links[j].CategoryID {
+			return links[i].CategoryID < links[j].CategoryID
+		}
+		return links[i].ItemID < links[j].ItemID
+	})
+
+	return links
+}
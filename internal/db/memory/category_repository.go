@@ -0,0 +1,196 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+	"training/proj/internal/api/models"
+	"training/proj/internal/db/repositories"
+)
+
+var _ repositories.CategoryRepositoryInterface = (*CategoryRepository)(nil)
+
+type CategoryRepository struct {
+	store *Store
+}
+
+func NewCategoryRepository(s *Store) *CategoryRepository {
+	return &CategoryRepository{
+		store: s,
+	}
+}
+
+func (r *CategoryRepository) Create(ctx context.Context, categoryReq *models.Category) (models.Category, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	r.store.categorySeq++
+	category := models.Category{
+		CategoryID: r.store.categorySeq,
+		Category:   categoryReq.Category,
+	}
+	r.store.categories[category.CategoryID] = category
+
+	return category, nil
+}
+
+func (r *CategoryRepository) GetAll(ctx context.Context) ([]models.Category, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	categories := make([]models.Category, 0, len(r.store.categories))
+
+	for _, id := range sortedKeys(r.store.categories) {
+		if category := r.store.categories[id]; category.DeletedAt == nil {
+			categories = append(categories, category)
+		}
+	}
+
+	return categories, nil
+}
+
+func (r *CategoryRepository) GetByName(ctx context.Context, name string) (models.Category, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	for _, id := range sortedKeys(r.store.categories) {
+		if category := r.store.categories[id]; category.Category == name && category.DeletedAt == nil {
+			return category, nil
+		}
+	}
+
+	return models.Category{}, repositories.ErrNotFound
+}
+
+func (r *CategoryRepository) GetById(ctx context.Context, id int64) (models.Category, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	category, ok := r.store.liveCategory(id)
+
+	if !ok {
+		return models.Category{}, repositories.ErrNotFound
+	}
+
+	return category, nil
+}
+
+func (r *CategoryRepository) Delete(ctx context.Context, id int64) (int64, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	category, ok := r.store.liveCategory(id)
+
+	if !ok {
+		return 0, nil
+	}
+
+	now := time.Now()
+	category.DeletedAt = &now
+	r.store.categories[id] = category
+
+	return 1, nil
+}
+
+func (r *CategoryRepository) Update(ctx context.Context, id int64, categoryReq *models.Category) (models.Category, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	category, ok := r.store.liveCategory(id)
+
+	if !ok {
+		return models.Category{}, repositories.ErrNotFound
+	}
+
+	category.Category = categoryReq.Category
+	r.store.categories[id] = category
+
+	return category, nil
+}
+
+func (r *CategoryRepository) GetCategoryItems(ctx context.Context, id int64) ([]models.Item, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	if _, ok := r.store.liveCategory(id); !ok {
+		return nil, repositories.ErrNotFound
+	}
+
+	items := make([]models.Item, 0)
+
+	for _, itemId := range sortedKeys(r.store.items) {
+		item := r.store.items[itemId]
+		if _, linked := r.store.links[link{categoryId: id, itemId: itemId}]; linked && item.DeletedAt == nil {
+			items = append(items, item)
+		}
+	}
+
+	return items, nil
+}
+
+func (r *CategoryRepository) GetDeleted(ctx context.Context) ([]models.Category, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	categories := make([]models.Category, 0)
+
+	for _, id := range sortedKeys(r.store.categories) {
+		if category := r.store.categories[id]; category.DeletedAt != nil {
+			categories = append(categories, category)
+		}
+	}
+
+	sort.SliceStable(categories, func(i, j int) bool {
+		return categories[i].DeletedAt.After(*categories[j].DeletedAt)
+	})
+
+	return categories, nil
+}
+
+func (r *CategoryRepository) Restore(ctx context.Context, id int64) (models.Category, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	category, ok := r.store.categories[id]
+
+	if !ok || category.DeletedAt == nil {
+		return models.Category{}, repositories.ErrNotFound
+	}
+
+	category.DeletedAt = nil
+	r.store.categories[id] = category
+
+	return category, nil
+}
+
+func (r *CategoryRepository) Purge(ctx context.Context, before time.Time) (int64, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	var purged int64
+
+	for id, category := range r.store.categories {
+		if category.DeletedAt == nil || !category.DeletedAt.Before(before) {
+			continue
+		}
+
+		delete(r.store.categories, id)
+		delete(r.store.categoryTaxClasses, id)
+
+		for l := range r.store.links {
+			if l.categoryId == id {
+				delete(r.store.links, l)
+			}
+		}
+
+		for saleId, sale := range r.store.sales {
+			if sale.CategoryID == id {
+				delete(r.store.sales, saleId)
+			}
+		}
+
+		purged++
+	}
+
+	return purged, nil
+}
@@ -0,0 +1,154 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+	"training/proj/internal/api/models"
+	"training/proj/internal/db/repositories"
+)
+
+var _ repositories.CouponRepositoryInterface = (*CouponRepository)(nil)
+
+type CouponRepository struct {
+	store *Store
+}
+
+func NewCouponRepository(s *Store) *CouponRepository {
+	return &CouponRepository{
+		store: s,
+	}
+}
+
+func (r *CouponRepository) GetAll(ctx context.Context) ([]models.Coupon, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	coupons := make([]models.Coupon, 0, len(r.store.coupons))
+
+	for _, coupon := range r.store.coupons {
+		coupons = append(coupons, coupon)
+	}
+
+	sort.Slice(coupons, func(i, j int) bool { return coupons[i].Code < coupons[j].Code })
+
+	return coupons, nil
+}
+
+func (r *CouponRepository) GetById(ctx context.Context, id int64) (models.Coupon, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	coupon, ok := r.store.coupons[id]
+	if !ok {
+		return models.Coupon{}, repositories.ErrNotFound
+	}
+
+	return coupon, nil
+}
+
+func (r *CouponRepository) GetByCodes(ctx context.Context, codes []string) ([]models.Coupon, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	coupons := make([]models.Coupon, 0)
+
+	for _, id := range sortedKeys(r.store.coupons) {
+		coupon := r.store.coupons[id]
+		if slices.ContainsFunc(codes, func(code string) bool { return strings.EqualFold(code, coupon.Code) }) {
+			coupons = append(coupons, coupon)
+		}
+	}
+
+	return coupons, nil
+}
+
+// codeTaken reports whether another coupon already uses code. The caller
+// must hold the lock.
+func (r *CouponRepository) codeTaken(code string, id int64) bool {
+	for _, coupon := range r.store.coupons {
+		if coupon.CouponID != id && strings.EqualFold(coupon.Code, code) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r *CouponRepository) Create(ctx context.Context, couponReq *models.Coupon) (models.Coupon, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if r.codeTaken(couponReq.Code, 0) {
+		return models.Coupon{}, errUniqueViolation
+	}
+
+	r.store.couponSeq++
+	now := time.Now()
+
+	coupon := *couponReq
+	coupon.CouponID = r.store.couponSeq
+	coupon.ItemIDs = slices.Clone(couponReq.ItemIDs)
+	coupon.CategoryIDs = slices.Clone(couponReq.CategoryIDs)
+	coupon.Uses = 0
+	coupon.CreatedAt = now
+	coupon.UpdatedAt = now
+	r.store.coupons[coupon.CouponID] = coupon
+
+	return coupon, nil
+}
+
+func (r *CouponRepository) Update(ctx context.Context, id int64, couponReq *models.Coupon) (models.Coupon, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	existing, ok := r.store.coupons[id]
+	if !ok {
+		return models.Coupon{}, repositories.ErrNotFound
+	}
+
+	if r.codeTaken(couponReq.Code, id) {
+		return models.Coupon{}, errUniqueViolation
+	}
+
+	coupon := *couponReq
+	coupon.CouponID = id
+	coupon.ItemIDs = slices.Clone(couponReq.ItemIDs)
+	coupon.CategoryIDs = slices.Clone(couponReq.CategoryIDs)
+	coupon.Uses = existing.Uses
+	coupon.CreatedAt = existing.CreatedAt
+	coupon.UpdatedAt = time.Now()
+	r.store.coupons[id] = coupon
+
+	return coupon, nil
+}
+
+func (r *CouponRepository) Delete(ctx context.Context, id int64) (int64, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.coupons[id]; !ok {
+		return 0, nil
+	}
+
+	delete(r.store.coupons, id)
+
+	return 1, nil
+}
+
+func (r *CouponRepository) Redeem(ctx context.Context, id int64) (int64, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	coupon, ok := r.store.coupons[id]
+	if !ok || (coupon.MaxUses != nil && coupon.Uses >= *coupon.MaxUses) {
+		return 0, nil
+	}
+
+	coupon.Uses++
+	r.store.coupons[id] = coupon
+
+	return 1, nil
+}
@@ -0,0 +1,66 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+	"training/proj/internal/api/models"
+	"training/proj/internal/db/repositories"
+)
+
+var _ repositories.ExchangeRateRepositoryInterface = (*ExchangeRateRepository)(nil)
+
+type ExchangeRateRepository struct {
+	store *Store
+}
+
+func NewExchangeRateRepository(s *Store) *ExchangeRateRepository {
+	return &ExchangeRateRepository{
+		store: s,
+	}
+}
+
+func (r *ExchangeRateRepository) GetAll(ctx context.Context) ([]models.ExchangeRate, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	rates := make([]models.ExchangeRate, 0, len(r.store.rates))
+
+	for _, rate := range r.store.rates {
+		rates = append(rates, rate)
+	}
+
+	sort.Slice(rates, func(i, j int) bool {
+		if rates[i].Base != rates[j].Base {
+			return rates[i].Base < rates[j].Base
+		}
+		return rates[i].Quote < rates[j].Quote
+	})
+
+	return rates, nil
+}
+
+func (r *ExchangeRateRepository) Upsert(ctx context.Context, rate *models.ExchangeRate) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	rate.UpdatedAt = time.Now()
+	r.store.rates[[2]string{rate.Base, rate.Quote}] = *rate
+
+	return nil
+}
+
+func (r *ExchangeRateRepository) Delete(ctx context.Context, base string, quote string) (int64, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	key := [2]string{base, quote}
+
+	if _, ok := r.store.rates[key]; !ok {
+		return 0, nil
+	}
+
+	delete(r.store.rates, key)
+
+	return 1, nil
+}
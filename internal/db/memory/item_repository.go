@@ -0,0 +1,280 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"time"
+	"training/proj/internal/api/models"
+	"training/proj/internal/db/repositories"
+)
+
+var _ repositories.ItemRepositoryInterface = (*ItemRepository)(nil)
+
+type ItemRepository struct {
+	store *Store
+}
+
+func NewItemRepository(s *Store) *ItemRepository {
+	return &ItemRepository{
+		store: s,
+	}
+}
+
+func (r *ItemRepository) GetAll(ctx context.Context) ([]models.Item, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	items := make([]models.Item, 0, len(r.store.items))
+
+	for _, id := range sortedKeys(r.store.items) {
+		if item := r.store.items[id]; item.DeletedAt == nil {
+			items = append(items, item)
+		}
+	}
+
+	return items, nil
+}
+
+func (r *ItemRepository) GetById(ctx context.Context, id int64) (models.Item, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	item, ok := r.store.liveItem(id)
+
+	if !ok {
+		return models.Item{}, repositories.ErrNotFound
+	}
+
+	return item, nil
+}
+
+func (r *ItemRepository) GetByName(ctx context.Context, name string) (models.Item, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	for _, id := range sortedKeys(r.store.items) {
+		if item := r.store.items[id]; item.Item == name && item.DeletedAt == nil {
+			return item, nil
+		}
+	}
+
+	return models.Item{}, repositories.ErrNotFound
+}
+
+func (r *ItemRepository) Create(ctx context.Context, itemReq *models.Item) (models.Item, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	r.store.itemSeq++
+	item := models.Item{
+		ItemID:      r.store.itemSeq,
+		Item:        itemReq.Item,
+		Price:       itemReq.Price,
+		Currency:    itemReq.Currency,
+		WeightGrams: itemReq.WeightGrams,
+		LengthMM:    itemReq.LengthMM,
+		WidthMM:     itemReq.WidthMM,
+		HeightMM:    itemReq.HeightMM,
+	}
+	r.store.items[item.ItemID] = item
+
+	return item, nil
+}
+
+func (r *ItemRepository) CreateMany(ctx context.Context, items []models.Item) (int64, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, itemReq := range items {
+		r.store.itemSeq++
+		r.store.items[r.store.itemSeq] = models.Item{
+			ItemID:      r.store.itemSeq,
+			Item:        itemReq.Item,
+			Price:       itemReq.Price,
+			Currency:    itemReq.Currency,
+			WeightGrams: itemReq.WeightGrams,
+			LengthMM:    itemReq.LengthMM,
+			WidthMM:     itemReq.WidthMM,
+			HeightMM:    itemReq.HeightMM,
+		}
+	}
+
+	return int64(len(items)), nil
+}
+
+func (r *ItemRepository) Delete(ctx context.Context, id int64) (int64, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	item, ok := r.store.liveItem(id)
+
+	if !ok {
+		return 0, nil
+	}
+
+	now := time.Now()
+	item.DeletedAt = &now
+	r.store.items[id] = item
+
+	return 1, nil
+}
+
+func (r *ItemRepository) Update(ctx context.Context, id int64, itemReq *models.Item) (models.Item, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	item, ok := r.store.liveItem(id)
+
+	if !ok {
+		return models.Item{}, repositories.ErrNotFound
+	}
+
+	item.Item = itemReq.Item
+	item.Price = itemReq.Price
+	item.WeightGrams = itemReq.WeightGrams
+	item.LengthMM = itemReq.LengthMM
+	item.WidthMM = itemReq.WidthMM
+	item.HeightMM = itemReq.HeightMM
+	r.store.items[id] = item
+
+	return item, nil
+}
+
+func (r *ItemRepository) GetItemCategories(ctx context.Context, id int64) ([]models.Category, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	if _, ok := r.store.liveItem(id); !ok {
+		return nil, repositories.ErrNotFound
+	}
+
+	categories := make([]models.Category, 0)
+
+	for _, categoryId := range sortedKeys(r.store.categories) {
+		category := r.store.categories[categoryId]
+		if _, linked := r.store.links[link{categoryId: categoryId, itemId: id}]; linked && category.DeletedAt == nil {
+			categories = append(categories, category)
+		}
+	}
+
+	return categories, nil
+}
+
+func (r *ItemRepository) GetBySource(ctx context.Context, source string) ([]models.Item, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	items := make([]models.Item, 0)
+
+	for _, id := range sortedKeys(r.store.items) {
+		if item := r.store.items[id]; r.store.itemSources[id] == source && item.DeletedAt == nil {
+			items = append(items, item)
+		}
+	}
+
+	return items, nil
+}
+
+func (r *ItemRepository) GetDeletedBySource(ctx context.Context, source string) ([]models.Item, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	items := make([]models.Item, 0)
+
+	for _, id := range sortedKeys(r.store.items) {
+		if item := r.store.items[id]; r.store.itemSources[id] == source && item.DeletedAt != nil {
+			items = append(items, item)
+		}
+	}
+
+	return items, nil
+}
+
+func (r *ItemRepository) SetSource(ctx context.Context, id int64, source string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.liveItem(id); !ok {
+		return repositories.ErrNotFound
+	}
+
+	if source == "" {
+		delete(r.store.itemSources, id)
+	} else {
+		r.store.itemSources[id] = source
+	}
+
+	return nil
+}
+
+func (r *ItemRepository) GetDeleted(ctx context.Context) ([]models.Item, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	items := make([]models.Item, 0)
+
+	for _, id := range sortedKeys(r.store.items) {
+		if item := r.store.items[id]; item.DeletedAt != nil {
+			items = append(items, item)
+		}
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].DeletedAt.After(*items[j].DeletedAt)
+	})
+
+	return items, nil
+}
+
+func (r *ItemRepository) Restore(ctx context.Context, id int64) (models.Item, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	item, ok := r.store.items[id]
+
+	if !ok || item.DeletedAt == nil {
+		return models.Item{}, repositories.ErrNotFound
+	}
+
+	item.DeletedAt = nil
+	r.store.items[id] = item
+
+	return item, nil
+}
+
+func (r *ItemRepository) Purge(ctx context.Context, before time.Time) (int64, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	var purged int64
+
+	for id, item := range r.store.items {
+		if item.DeletedAt == nil || !item.DeletedAt.Before(before) {
+			continue
+		}
+
+		delete(r.store.items, id)
+		delete(r.store.itemSources, id)
+		delete(r.store.itemTaxClasses, id)
+		delete(r.store.ratingSums, id)
+
+		for reviewId, review := range r.store.reviews {
+			if review.ItemID == id {
+				r.store.deleteReview(reviewId)
+			}
+		}
+
+		r.store.prices = slices.DeleteFunc(r.store.prices, func(c models.PriceChange) bool { return c.ItemID == id })
+
+		for l := range r.store.links {
+			if l.itemId == id {
+				delete(r.store.links, l)
+			}
+		}
+
+		purged++
+	}
+
+	return purged, nil
+}
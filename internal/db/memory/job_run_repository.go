@@ -0,0 +1,79 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+	"training/proj/internal/api/models"
+	"training/proj/internal/db/repositories"
+)
+
+var _ repositories.JobRunRepositoryInterface = (*JobRunRepository)(nil)
+
+type JobRunRepository struct {
+	store *Store
+}
+
+func NewJobRunRepository(s *Store) *JobRunRepository {
+	return &JobRunRepository{
+		store: s,
+	}
+}
+
+func (r *JobRunRepository) Create(ctx context.Context, job string, triggeredBy string) (models.JobRun, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	r.store.jobRunSeq++
+	run := models.JobRun{
+		JobRunID:    r.store.jobRunSeq,
+		Job:         job,
+		TriggeredBy: triggeredBy,
+		Status:      "running",
+		StartedAt:   time.Now(),
+	}
+	r.store.jobRuns[run.JobRunID] = run
+
+	return run, nil
+}
+
+func (r *JobRunRepository) Finish(ctx context.Context, id int64, status string, stats []byte, errMsg string) (models.JobRun, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	run, ok := r.store.jobRuns[id]
+
+	if !ok {
+		return models.JobRun{}, repositories.ErrNotFound
+	}
+
+	finished := time.Now()
+	run.Status = status
+	run.Stats = stats
+	run.Error = errMsg
+	run.FinishedAt = &finished
+	r.store.jobRuns[id] = run
+
+	return run, nil
+}
+
+func (r *JobRunRepository) GetByJob(ctx context.Context, job string, limit int) ([]models.JobRun, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	runs := make([]models.JobRun, 0)
+
+	for _, run := range r.store.jobRuns {
+		if run.Job == job {
+			runs = append(runs, run)
+		}
+	}
+
+	sort.Slice(runs, func(i, j int) bool { return runs[i].JobRunID > runs[j].JobRunID })
+
+	if len(runs) > limit {
+		runs = runs[:limit]
+	}
+
+	return runs, nil
+}
@@ -0,0 +1,148 @@
+package memory
+
+import (
+	"sort"
+	"training/proj/internal/api/models"
+	"training/proj/internal/db/repositories"
+
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+type link struct {
+	categoryId int64
+	itemId     int64
+}
+
+type Store struct {
+	mu storeMutex
+
+	categories map[int64]models.Category
+	items      map[int64]models.Item
+	users      map[int64]storedUser
+	// links maps each link to the importer that made it, or to "" for links
+	// made by hand.
+	links map[link]string
+
+	itemSources map[int64]string
+	jobRuns     map[int64]models.JobRun
+	audit       []models.AuditEntry
+	revisions   []models.Revision
+	prices      []models.PriceChange
+	rates       map[[2]string]models.ExchangeRate
+	coupons     map[int64]models.Coupon
+	sales       map[int64]models.Sale
+
+	taxZones           map[string]models.TaxZone
+	taxClasses         map[string]models.TaxClass
+	taxRates           map[int64]models.TaxRate
+	itemTaxClasses     map[int64]string
+	categoryTaxClasses map[int64]string
+
+	shippingMethods map[int64]models.ShippingMethod
+
+	reviews     map[int64]models.Review
+	reviewVotes map[[2]int64]struct{}
+	ratingSums  map[int64]int64
+
+	categorySeq int64
+	itemSeq     int64
+	userSeq     int64
+	jobRunSeq   int64
+	priceSeq    int64
+	couponSeq   int64
+	saleSeq     int64
+	taxRateSeq  int64
+
+	shippingMethodSeq int64
+	shippingRuleSeq   int64
+	reviewSeq         int64
+}
+
+func NewStore() *Store {
+	return &Store{
+		categories: make(map[int64]models.Category),
+		items:      make(map[int64]models.Item),
+		users:      make(map[int64]storedUser),
+		links:      make(map[link]string),
+
+		itemSources: make(map[int64]string),
+		jobRuns:     make(map[int64]models.JobRun),
+		rates:       make(map[[2]string]models.ExchangeRate),
+		coupons:     make(map[int64]models.Coupon),
+		sales:       make(map[int64]models.Sale),
+
+		taxZones: make(map[string]models.TaxZone),
+		taxClasses: map[string]models.TaxClass{
+			models.DefaultTaxClass: {Code: models.DefaultTaxClass, Name: "Standard rate"},
+		},
+		taxRates:           make(map[int64]models.TaxRate),
+		itemTaxClasses:     make(map[int64]string),
+		categoryTaxClasses: make(map[int64]string),
+
+		shippingMethods: make(map[int64]models.ShippingMethod),
+
+		reviews:     make(map[int64]models.Review),
+		reviewVotes: make(map[[2]int64]struct{}),
+		ratingSums:  make(map[int64]int64),
+	}
+}
+
+func NewRepositories(s *Store) *repositories.Repositories {
+	r := newRepositories(s)
+	r.TxManager = NewTxManager(s)
+	return r
+}
+
+func newRepositories(s *Store) *repositories.Repositories {
+	return &repositories.Repositories{
+		CategoryRepository:     NewCategoryRepository(s),
+		ItemRepository:         NewItemRepository(s),
+		UserRepository:         NewUserRepository(s),
+		CategoryItemRepository: NewCategoryItemRepository(s),
+		JobRunRepository:       NewJobRunRepository(s),
+		AuditRepository:        NewAuditRepository(s),
+		RevisionRepository:     NewRevisionRepository(s),
+		PriceRepository:        NewPriceRepository(s),
+		ExchangeRateRepository: NewExchangeRateRepository(s),
+		CouponRepository:       NewCouponRepository(s),
+		SaleRepository:         NewSaleRepository(s),
+		TaxRepository:          NewTaxRepository(s),
+		ShippingRepository:     NewShippingRepository(s),
+		ReviewRepository:       NewReviewRepository(s),
+	}
+}
+
+func sortedKeys[V any](m map[int64]V) []int64 {
+	keys := make([]int64, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	return keys
+}
+
+// liveCategory returns the category unless it is missing or in the trash.
+// The caller must hold the lock.
+func (s *Store) liveCategory(id int64) (models.Category, bool) {
+	category, ok := s.categories[id]
+	return category, ok && category.DeletedAt == nil
+}
+
+// liveItem returns the item unless it is missing or in the trash. The caller
+// must hold the lock.
+func (s *Store) liveItem(id int64) (models.Item, bool) {
+	item, ok := s.items[id]
+	return item, ok && item.DeletedAt == nil
+}
+
+func pgError(code string) *pgconn.PgError {
+	return &pgconn.PgError{Severity: "ERROR", Code: code}
+}
+
+var (
+	errUniqueViolation      = pgError(pgerrcode.UniqueViolation)
+	errForeignKeyViolation  = pgError(pgerrcode.ForeignKeyViolation)
+	errSerializationFailure = pgError(pgerrcode.SerializationFailure)
+	errReadOnlyTransaction  = pgError(pgerrcode.ReadOnlySQLTransaction)
+)
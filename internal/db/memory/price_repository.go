@@ -0,0 +1,153 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"time"
+	"training/proj/internal/api/models"
+	"training/proj/internal/db/repositories"
+)
+
+var _ repositories.PriceRepositoryInterface = (*PriceRepository)(nil)
+
+type PriceRepository struct {
+	store *Store
+}
+
+func NewPriceRepository(s *Store) *PriceRepository {
+	return &PriceRepository{
+		store: s,
+	}
+}
+
+func (r *PriceRepository) Create(ctx context.Context, change *models.PriceChange) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.items[change.ItemID]; !ok {
+		return errForeignKeyViolation
+	}
+
+	r.store.priceSeq++
+	change.PriceID = r.store.priceSeq
+	change.CreatedAt = time.Now()
+	r.store.prices = append(r.store.prices, *change)
+
+	return nil
+}
+
+// find returns the matching price changes ordered by effective time. The
+// caller must hold the lock.
+func (r *PriceRepository) find(match func(models.PriceChange) bool) []models.PriceChange {
+	changes := make([]models.PriceChange, 0)
+
+	for _, c := range r.store.prices {
+		if match(c) {
+			changes = append(changes, c)
+		}
+	}
+
+	slices.SortStableFunc(changes, func(a, b models.PriceChange) int {
+		return a.EffectiveAt.Compare(b.EffectiveAt)
+	})
+
+	return changes
+}
+
+func (r *PriceRepository) GetByItem(ctx context.Context, itemId int64, from *time.Time, to *time.Time) ([]models.PriceChange, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	return r.find(func(c models.PriceChange) bool {
+		return c.ItemID == itemId && c.AppliedAt != nil &&
+			(from == nil || !c.EffectiveAt.Before(*from)) &&
+			(to == nil || c.EffectiveAt.Before(*to))
+	}), nil
+}
+
+func (r *PriceRepository) GetScheduled(ctx context.Context, itemId int64) ([]models.PriceChange, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	return r.find(func(c models.PriceChange) bool {
+		return c.ItemID == itemId && c.AppliedAt == nil
+	}), nil
+}
+
+func (r *PriceRepository) GetDue(ctx context.Context, now time.Time) ([]models.PriceChange, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	return r.find(func(c models.PriceChange) bool {
+		return c.AppliedAt == nil && !c.EffectiveAt.After(now)
+	}), nil
+}
+
+func (r *PriceRepository) MarkApplied(ctx context.Context, id int64, at time.Time) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for i, c := range r.store.prices {
+		if c.PriceID == id && c.AppliedAt == nil {
+			r.store.prices[i].AppliedAt = &at
+			return nil
+		}
+	}
+
+	return repositories.ErrNotFound
+}
+
+func (r *PriceRepository) CancelScheduled(ctx context.Context, itemId int64, id int64) (int64, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	n := len(r.store.prices)
+	r.store.prices = slices.DeleteFunc(r.store.prices, func(c models.PriceChange) bool {
+		return c.PriceID == id && c.ItemID == itemId && c.AppliedAt == nil
+	})
+
+	return int64(n - len(r.store.prices)), nil
+}
+
+func (r *PriceRepository) CancelDue(ctx context.Context, itemId int64, now time.Time) (int64, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	n := len(r.store.prices)
+	r.store.prices = slices.DeleteFunc(r.store.prices, func(c models.PriceChange) bool {
+		return c.ItemID == itemId && c.AppliedAt == nil && !c.EffectiveAt.After(now)
+	})
+
+	return int64(n - len(r.store.prices)), nil
+}
+
+func (r *PriceRepository) LowestSince(ctx context.Context, itemId int64, since time.Time) (int64, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	applied := r.find(func(c models.PriceChange) bool {
+		return c.ItemID == itemId && c.AppliedAt != nil
+	})
+
+	var lowest int64
+	found := false
+
+	for i, c := range applied {
+		// The last change before the window is the price in effect when it
+		// starts.
+		inEffect := c.EffectiveAt.Before(since) && (i+1 == len(applied) || !applied[i+1].EffectiveAt.Before(since))
+
+		if !c.EffectiveAt.Before(since) || inEffect {
+			if !found || c.Price < lowest {
+				lowest = c.Price
+			}
+			found = true
+		}
+	}
+
+	if !found {
+		return 0, repositories.ErrNotFound
+	}
+
+	return lowest, nil
+}
@@ -0,0 +1,216 @@
+package memory
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+	"training/proj/internal/api/models"
+	"training/proj/internal/db/repositories"
+)
+
+var _ repositories.ReviewRepositoryInterface = (*ReviewRepository)(nil)
+
+type ReviewRepository struct {
+	store *Store
+}
+
+func NewReviewRepository(s *Store) *ReviewRepository {
+	return &ReviewRepository{
+		store: s,
+	}
+}
+
+func (r *ReviewRepository) GetForItem(ctx context.Context, itemId int64, filter repositories.ReviewFilter) ([]models.Review, int64, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	matched := make([]models.Review, 0)
+
+	for _, review := range r.store.reviews {
+		if review.ItemID == itemId {
+			matched = append(matched, review)
+		}
+	}
+
+	sort.Slice(matched, func(i, j int) bool {
+		a, b := matched[i], matched[j]
+
+		if filter.Sort == repositories.ReviewsByNewest {
+			if !a.CreatedAt.Equal(b.CreatedAt) {
+				return a.CreatedAt.After(b.CreatedAt)
+			}
+			return a.ReviewID > b.ReviewID
+		}
+
+		if a.HelpfulCount != b.HelpfulCount {
+			return a.HelpfulCount > b.HelpfulCount
+		}
+		return a.ReviewID < b.ReviewID
+	})
+
+	total := int64(len(matched))
+
+	start := min(filter.Offset, len(matched))
+	end := min(start+filter.Limit, len(matched))
+
+	reviews := make([]models.Review, 0, end-start)
+	reviews = append(reviews, matched[start:end]...)
+
+	return reviews, total, nil
+}
+
+func (r *ReviewRepository) GetById(ctx context.Context, id int64) (models.Review, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	review, ok := r.store.reviews[id]
+	if !ok {
+		return models.Review{}, repositories.ErrNotFound
+	}
+
+	return review, nil
+}
+
+func (r *ReviewRepository) Create(ctx context.Context, reviewReq *models.Review) (models.Review, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.items[reviewReq.ItemID]; !ok {
+		return models.Review{}, errForeignKeyViolation
+	}
+
+	for _, review := range r.store.reviews {
+		if review.ItemID == reviewReq.ItemID && review.UserID == reviewReq.UserID {
+			return models.Review{}, errUniqueViolation
+		}
+	}
+
+	r.store.reviewSeq++
+	now := time.Now()
+
+	review := models.Review{
+		ReviewID:         r.store.reviewSeq,
+		ItemID:           reviewReq.ItemID,
+		UserID:           reviewReq.UserID,
+		Rating:           reviewReq.Rating,
+		Title:            reviewReq.Title,
+		Body:             reviewReq.Body,
+		VerifiedPurchase: reviewReq.VerifiedPurchase,
+		CreatedAt:        now,
+		UpdatedAt:        now,
+	}
+	r.store.reviews[review.ReviewID] = review
+	r.store.rateItem(review.ItemID, 1, review.Rating)
+
+	return review, nil
+}
+
+func (r *ReviewRepository) Update(ctx context.Context, id int64, reviewReq *models.Review) (models.Review, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	review, ok := r.store.reviews[id]
+	if !ok {
+		return models.Review{}, repositories.ErrNotFound
+	}
+
+	r.store.rateItem(review.ItemID, 0, reviewReq.Rating-review.Rating)
+
+	review.Rating = reviewReq.Rating
+	review.Title = reviewReq.Title
+	review.Body = reviewReq.Body
+	review.UpdatedAt = time.Now()
+	r.store.reviews[id] = review
+
+	return review, nil
+}
+
+func (r *ReviewRepository) Delete(ctx context.Context, id int64) (int64, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.reviews[id]; !ok {
+		return 0, nil
+	}
+
+	r.store.deleteReview(id)
+
+	return 1, nil
+}
+
+func (r *ReviewRepository) AddVote(ctx context.Context, reviewId int64, userId int64) (int64, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	review, ok := r.store.reviews[reviewId]
+	if !ok {
+		return 0, errForeignKeyViolation
+	}
+
+	key := [2]int64{reviewId, userId}
+	if _, ok := r.store.reviewVotes[key]; ok {
+		return 0, nil
+	}
+
+	r.store.reviewVotes[key] = struct{}{}
+	review.HelpfulCount++
+	r.store.reviews[reviewId] = review
+
+	return 1, nil
+}
+
+func (r *ReviewRepository) RemoveVote(ctx context.Context, reviewId int64, userId int64) (int64, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	key := [2]int64{reviewId, userId}
+	if _, ok := r.store.reviewVotes[key]; !ok {
+		return 0, nil
+	}
+
+	delete(r.store.reviewVotes, key)
+
+	review := r.store.reviews[reviewId]
+	review.HelpfulCount--
+	r.store.reviews[reviewId] = review
+
+	return 1, nil
+}
+
+// deleteReview removes a review and its votes and takes its rating out of
+// the item's aggregate. The caller must hold the lock.
+func (s *Store) deleteReview(id int64) {
+	review := s.reviews[id]
+
+	delete(s.reviews, id)
+
+	for key := range s.reviewVotes {
+		if key[0] == id {
+			delete(s.reviewVotes, key)
+		}
+	}
+
+	s.rateItem(review.ItemID, -1, -review.Rating)
+}
+
+// rateItem adjusts the item's rating count and sum by the given amounts and
+// recomputes its average the way the database query does. The caller must
+// hold the lock.
+func (s *Store) rateItem(itemId int64, count int64, sum int64) {
+	item, ok := s.items[itemId]
+	if !ok {
+		return
+	}
+
+	item.RatingCount += count
+	s.ratingSums[itemId] += sum
+
+	item.RatingAvg = nil
+	if item.RatingCount > 0 {
+		avg := math.Round(float64(s.ratingSums[itemId])/float64(item.RatingCount)*100) / 100
+		item.RatingAvg = &avg
+	}
+
+	s.items[itemId] = item
+}
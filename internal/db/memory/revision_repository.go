@@ -0,0 +1,67 @@
+package memory
+
+import (
+	"context"
+	"time"
+	"training/proj/internal/api/models"
+	"training/proj/internal/db/repositories"
+)
+
+var _ repositories.RevisionRepositoryInterface = (*RevisionRepository)(nil)
+
+type RevisionRepository struct {
+	store *Store
+}
+
+func NewRevisionRepository(s *Store) *RevisionRepository {
+	return &RevisionRepository{
+		store: s,
+	}
+}
+
+func (r *RevisionRepository) Create(ctx context.Context, rev *models.Revision) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	var latest int64
+	for _, existing := range r.store.revisions {
+		if existing.EntityType == rev.EntityType && existing.EntityID == rev.EntityID {
+			latest = max(latest, existing.Revision)
+		}
+	}
+
+	rev.RevisionID = int64(len(r.store.revisions) + 1)
+	rev.Revision = latest + 1
+	rev.CreatedAt = time.Now()
+	r.store.revisions = append(r.store.revisions, *rev)
+
+	return nil
+}
+
+func (r *RevisionRepository) GetByEntity(ctx context.Context, entityType string, entityId int64) ([]models.Revision, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	revisions := make([]models.Revision, 0)
+
+	for i := len(r.store.revisions) - 1; i >= 0; i-- {
+		if rev := r.store.revisions[i]; rev.EntityType == entityType && rev.EntityID == entityId {
+			revisions = append(revisions, rev)
+		}
+	}
+
+	return revisions, nil
+}
+
+func (r *RevisionRepository) Get(ctx context.Context, entityType string, entityId int64, revision int64) (models.Revision, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	for _, rev := range r.store.revisions {
+		if rev.EntityType == entityType && rev.EntityID == entityId && rev.Revision == revision {
+			return rev, nil
+		}
+	}
+
+	return models.Revision{}, repositories.ErrNotFound
+}
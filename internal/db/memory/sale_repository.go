@@ -0,0 +1,147 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+	"training/proj/internal/api/models"
+	"training/proj/internal/db/repositories"
+)
+
+var _ repositories.SaleRepositoryInterface = (*SaleRepository)(nil)
+
+type SaleRepository struct {
+	store *Store
+}
+
+func NewSaleRepository(s *Store) *SaleRepository {
+	return &SaleRepository{
+		store: s,
+	}
+}
+
+func (r *SaleRepository) GetAll(ctx context.Context) ([]models.Sale, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	sales := make([]models.Sale, 0, len(r.store.sales))
+
+	for _, sale := range r.store.sales {
+		sales = append(sales, sale)
+	}
+
+	sort.Slice(sales, func(i, j int) bool {
+		if !sales[i].StartsAt.Equal(sales[j].StartsAt) {
+			return sales[i].StartsAt.Before(sales[j].StartsAt)
+		}
+		return sales[i].SaleID < sales[j].SaleID
+	})
+
+	return sales, nil
+}
+
+func (r *SaleRepository) GetById(ctx context.Context, id int64) (models.Sale, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	sale, ok := r.store.sales[id]
+	if !ok {
+		return models.Sale{}, repositories.ErrNotFound
+	}
+
+	return sale, nil
+}
+
+func (r *SaleRepository) Create(ctx context.Context, saleReq *models.Sale) (models.Sale, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.categories[saleReq.CategoryID]; !ok {
+		return models.Sale{}, errForeignKeyViolation
+	}
+
+	r.store.saleSeq++
+	now := time.Now()
+
+	sale := *saleReq
+	sale.SaleID = r.store.saleSeq
+	sale.CreatedAt = now
+	sale.UpdatedAt = now
+	r.store.sales[sale.SaleID] = sale
+
+	return sale, nil
+}
+
+func (r *SaleRepository) Update(ctx context.Context, id int64, saleReq *models.Sale) (models.Sale, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	existing, ok := r.store.sales[id]
+	if !ok {
+		return models.Sale{}, repositories.ErrNotFound
+	}
+
+	if _, ok := r.store.categories[saleReq.CategoryID]; !ok {
+		return models.Sale{}, errForeignKeyViolation
+	}
+
+	sale := *saleReq
+	sale.SaleID = id
+	sale.CreatedAt = existing.CreatedAt
+	sale.UpdatedAt = time.Now()
+	r.store.sales[id] = sale
+
+	return sale, nil
+}
+
+func (r *SaleRepository) Delete(ctx context.Context, id int64) (int64, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.sales[id]; !ok {
+		return 0, nil
+	}
+
+	delete(r.store.sales, id)
+
+	return 1, nil
+}
+
+func (r *SaleRepository) ActiveForItems(ctx context.Context, itemIds []int64, at time.Time) (map[int64]models.Sale, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	sales := make(map[int64]models.Sale)
+
+	for _, itemId := range itemIds {
+		for _, sale := range r.store.sales {
+			if _, linked := r.store.links[link{categoryId: sale.CategoryID, itemId: itemId}]; !linked {
+				continue
+			}
+			if _, live := r.store.liveCategory(sale.CategoryID); !live {
+				continue
+			}
+			if at.Before(sale.StartsAt) || !at.Before(sale.EndsAt) {
+				continue
+			}
+			if current, ok := sales[itemId]; !ok || outranks(sale, current) {
+				sales[itemId] = sale
+			}
+		}
+	}
+
+	return sales, nil
+}
+
+// outranks reports whether sale a takes precedence over sale b.
+func outranks(a models.Sale, b models.Sale) bool {
+	if a.Priority != b.Priority {
+		return a.Priority > b.Priority
+	}
+
+	if a.PercentOff != b.PercentOff {
+		return a.PercentOff > b.PercentOff
+	}
+
+	return a.SaleID < b.SaleID
+}
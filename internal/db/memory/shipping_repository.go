@@ -0,0 +1,168 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"time"
+	"training/proj/internal/api/models"
+	"training/proj/internal/db/repositories"
+)
+
+var _ repositories.ShippingRepositoryInterface = (*ShippingRepository)(nil)
+
+type ShippingRepository struct {
+	store *Store
+}
+
+func NewShippingRepository(s *Store) *ShippingRepository {
+	return &ShippingRepository{
+		store: s,
+	}
+}
+
+func (r *ShippingRepository) GetAll(ctx context.Context) ([]models.ShippingMethod, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	methods := make([]models.ShippingMethod, 0, len(r.store.shippingMethods))
+
+	for _, id := range sortedKeys(r.store.shippingMethods) {
+		methods = append(methods, copyShippingMethod(r.store.shippingMethods[id]))
+	}
+
+	return methods, nil
+}
+
+func (r *ShippingRepository) GetById(ctx context.Context, id int64) (models.ShippingMethod, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	method, ok := r.store.shippingMethods[id]
+	if !ok {
+		return models.ShippingMethod{}, repositories.ErrNotFound
+	}
+
+	return copyShippingMethod(method), nil
+}
+
+func (r *ShippingRepository) ForCountry(ctx context.Context, country string) ([]models.ShippingMethod, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	methods := make([]models.ShippingMethod, 0)
+
+	for _, id := range sortedKeys(r.store.shippingMethods) {
+		method := copyShippingMethod(r.store.shippingMethods[id])
+		if !method.Active {
+			continue
+		}
+
+		method.Rules = slices.DeleteFunc(method.Rules, func(rule models.ShippingRule) bool { return rule.Country != country })
+		if len(method.Rules) == 0 {
+			continue
+		}
+
+		methods = append(methods, method)
+	}
+
+	return methods, nil
+}
+
+func (r *ShippingRepository) Create(ctx context.Context, methodReq *models.ShippingMethod) (models.ShippingMethod, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	rules, err := r.newRules(methodReq.Rules)
+	if err != nil {
+		return models.ShippingMethod{}, err
+	}
+
+	r.store.shippingMethodSeq++
+	now := time.Now()
+
+	method := *methodReq
+	method.MethodID = r.store.shippingMethodSeq
+	method.Rules = rules
+	method.CreatedAt = now
+	method.UpdatedAt = now
+	r.store.shippingMethods[method.MethodID] = method
+
+	return copyShippingMethod(method), nil
+}
+
+func (r *ShippingRepository) Update(ctx context.Context, id int64, methodReq *models.ShippingMethod) (models.ShippingMethod, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	existing, ok := r.store.shippingMethods[id]
+	if !ok {
+		return models.ShippingMethod{}, repositories.ErrNotFound
+	}
+
+	rules, err := r.newRules(methodReq.Rules)
+	if err != nil {
+		return models.ShippingMethod{}, err
+	}
+
+	method := *methodReq
+	method.MethodID = id
+	method.Rules = rules
+	method.CreatedAt = existing.CreatedAt
+	method.UpdatedAt = time.Now()
+	r.store.shippingMethods[id] = method
+
+	return copyShippingMethod(method), nil
+}
+
+func (r *ShippingRepository) Delete(ctx context.Context, id int64) (int64, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.shippingMethods[id]; !ok {
+		return 0, nil
+	}
+
+	delete(r.store.shippingMethods, id)
+
+	return 1, nil
+}
+
+// newRules numbers the rules and sorts them like the database does. The
+// caller must hold the lock.
+func (r *ShippingRepository) newRules(rulesReq []models.ShippingRule) ([]models.ShippingRule, error) {
+	rules := make([]models.ShippingRule, 0, len(rulesReq))
+	seen := make(map[[2]string]struct{}, len(rulesReq))
+
+	for _, rule := range rulesReq {
+		key := [2]string{rule.Country, rule.PostalPrefix}
+		if _, ok := seen[key]; ok {
+			return nil, errUniqueViolation
+		}
+		seen[key] = struct{}{}
+
+		r.store.shippingRuleSeq++
+		rule.RuleID = r.store.shippingRuleSeq
+		rules = append(rules, rule)
+	}
+
+	sort.Slice(rules, func(i, j int) bool {
+		if rules[i].Country != rules[j].Country {
+			return rules[i].Country < rules[j].Country
+		}
+		return rules[i].PostalPrefix < rules[j].PostalPrefix
+	})
+
+	return rules, nil
+}
+
+// copyShippingMethod returns the method with its own copy of the rules, so
+// that callers cannot change the stored ones.
+func copyShippingMethod(method models.ShippingMethod) models.ShippingMethod {
+	method.Rules = slices.Clone(method.Rules)
+	if method.Rules == nil {
+		method.Rules = make([]models.ShippingRule, 0)
+	}
+
+	return method
+}
@@ -0,0 +1,293 @@
+package memory
+
+import (
+	"context"
+	"math/big"
+	"sort"
+	"time"
+	"training/proj/internal/api/models"
+	"training/proj/internal/db/repositories"
+
+	"github.com/jackc/pgerrcode"
+)
+
+var _ repositories.TaxRepositoryInterface = (*TaxRepository)(nil)
+
+type TaxRepository struct {
+	store *Store
+}
+
+func NewTaxRepository(s *Store) *TaxRepository {
+	return &TaxRepository{
+		store: s,
+	}
+}
+
+func (r *TaxRepository) GetZones(ctx context.Context) ([]models.TaxZone, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	zones := make([]models.TaxZone, 0, len(r.store.taxZones))
+
+	for _, zone := range r.store.taxZones {
+		zones = append(zones, zone)
+	}
+
+	sort.Slice(zones, func(i, j int) bool { return zones[i].Code < zones[j].Code })
+
+	return zones, nil
+}
+
+func (r *TaxRepository) UpsertZone(ctx context.Context, zone *models.TaxZone) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	r.store.taxZones[zone.Code] = *zone
+
+	return nil
+}
+
+func (r *TaxRepository) DeleteZone(ctx context.Context, code string) (int64, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.taxZones[code]; !ok {
+		return 0, nil
+	}
+
+	delete(r.store.taxZones, code)
+
+	for id, rate := range r.store.taxRates {
+		if rate.Zone == code {
+			delete(r.store.taxRates, id)
+		}
+	}
+
+	return 1, nil
+}
+
+func (r *TaxRepository) GetClasses(ctx context.Context) ([]models.TaxClass, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	classes := make([]models.TaxClass, 0, len(r.store.taxClasses))
+
+	for _, class := range r.store.taxClasses {
+		classes = append(classes, class)
+	}
+
+	sort.Slice(classes, func(i, j int) bool { return classes[i].Code < classes[j].Code })
+
+	return classes, nil
+}
+
+func (r *TaxRepository) UpsertClass(ctx context.Context, class *models.TaxClass) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	r.store.taxClasses[class.Code] = *class
+
+	return nil
+}
+
+func (r *TaxRepository) DeleteClass(ctx context.Context, code string) (int64, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.taxClasses[code]; !ok {
+		return 0, nil
+	}
+
+	delete(r.store.taxClasses, code)
+
+	for id, rate := range r.store.taxRates {
+		if rate.TaxClass == code {
+			delete(r.store.taxRates, id)
+		}
+	}
+
+	for id, class := range r.store.itemTaxClasses {
+		if class == code {
+			delete(r.store.itemTaxClasses, id)
+		}
+	}
+
+	for id, class := range r.store.categoryTaxClasses {
+		if class == code {
+			delete(r.store.categoryTaxClasses, id)
+		}
+	}
+
+	return 1, nil
+}
+
+func (r *TaxRepository) GetRates(ctx context.Context, zone string) ([]models.TaxRate, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	rates := make([]models.TaxRate, 0)
+
+	for _, rate := range r.store.taxRates {
+		if zone == "" || rate.Zone == zone {
+			rates = append(rates, rate)
+		}
+	}
+
+	sort.Slice(rates, func(i, j int) bool {
+		if rates[i].Zone != rates[j].Zone {
+			return rates[i].Zone < rates[j].Zone
+		}
+		if rates[i].TaxClass != rates[j].TaxClass {
+			return rates[i].TaxClass < rates[j].TaxClass
+		}
+		return rates[i].EffectiveFrom.After(rates[j].EffectiveFrom)
+	})
+
+	return rates, nil
+}
+
+func (r *TaxRepository) CreateRate(ctx context.Context, rate *models.TaxRate) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.taxZones[rate.Zone]; !ok {
+		return errForeignKeyViolation
+	}
+
+	if _, ok := r.store.taxClasses[rate.TaxClass]; !ok {
+		return errForeignKeyViolation
+	}
+
+	value, ok := new(big.Rat).SetString(rate.Rate)
+	if !ok || value.Sign() < 0 || value.Cmp(big.NewRat(100, 1)) > 0 {
+		return pgError(pgerrcode.CheckViolation)
+	}
+
+	for _, existing := range r.store.taxRates {
+		if existing.Zone == rate.Zone && existing.TaxClass == rate.TaxClass && existing.EffectiveFrom.Equal(rate.EffectiveFrom) {
+			return errUniqueViolation
+		}
+	}
+
+	r.store.taxRateSeq++
+
+	rate.RateID = r.store.taxRateSeq
+	rate.Rate = value.FloatString(4)
+	rate.CreatedAt = time.Now()
+	r.store.taxRates[rate.RateID] = *rate
+
+	return nil
+}
+
+func (r *TaxRepository) DeleteRate(ctx context.Context, id int64) (int64, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.taxRates[id]; !ok {
+		return 0, nil
+	}
+
+	delete(r.store.taxRates, id)
+
+	return 1, nil
+}
+
+func (r *TaxRepository) SetItemClass(ctx context.Context, itemId int64, class *string) (int64, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.liveItem(itemId); !ok {
+		return 0, nil
+	}
+
+	return 1, setClass(r.store, r.store.itemTaxClasses, itemId, class)
+}
+
+func (r *TaxRepository) SetCategoryClass(ctx context.Context, categoryId int64, class *string) (int64, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.liveCategory(categoryId); !ok {
+		return 0, nil
+	}
+
+	return 1, setClass(r.store, r.store.categoryTaxClasses, categoryId, class)
+}
+
+// setClass assigns class to id in classes, or removes the assignment if
+// class is nil. The caller must hold the lock.
+func setClass(s *Store, classes map[int64]string, id int64, class *string) error {
+	if class == nil {
+		delete(classes, id)
+		return nil
+	}
+
+	if _, ok := s.taxClasses[*class]; !ok {
+		return errForeignKeyViolation
+	}
+
+	classes[id] = *class
+
+	return nil
+}
+
+func (r *TaxRepository) ItemClasses(ctx context.Context, itemIds []int64) (map[int64]string, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	classes := make(map[int64]string, len(itemIds))
+
+	for _, itemId := range itemIds {
+		if _, ok := r.store.items[itemId]; !ok {
+			continue
+		}
+
+		if class, ok := r.store.itemTaxClasses[itemId]; ok {
+			classes[itemId] = class
+			continue
+		}
+
+		classes[itemId] = models.DefaultTaxClass
+
+		for _, categoryId := range sortedKeys(r.store.categoryTaxClasses) {
+			if _, linked := r.store.links[link{categoryId: categoryId, itemId: itemId}]; !linked {
+				continue
+			}
+			if _, live := r.store.liveCategory(categoryId); !live {
+				continue
+			}
+
+			classes[itemId] = r.store.categoryTaxClasses[categoryId]
+			break
+		}
+	}
+
+	return classes, nil
+}
+
+func (r *TaxRepository) RatesAt(ctx context.Context, zone string, at time.Time) (map[string]string, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	if _, ok := r.store.taxZones[zone]; !ok {
+		return nil, repositories.ErrNotFound
+	}
+
+	rates := make(map[string]string)
+	effective := make(map[string]time.Time)
+
+	for _, rate := range r.store.taxRates {
+		if rate.Zone != zone || rate.EffectiveFrom.After(at) {
+			continue
+		}
+
+		if from, ok := effective[rate.TaxClass]; ok && !rate.EffectiveFrom.After(from) {
+			continue
+		}
+
+		rates[rate.TaxClass] = rate.Rate
+		effective[rate.TaxClass] = rate.EffectiveFrom
+	}
+
+	return rates, nil
+}
@@ -0,0 +1,182 @@
+package memory
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"sync"
+	"training/proj/internal/db/repositories"
+)
+
+var _ repositories.TxManager = (*TxManager)(nil)
+
+// TxManager runs each transaction against a private copy of the store and
+// swaps the copy in when the transaction commits, so other callers never
+// see its writes before then and an error simply discards them.
+// Transactions are serialized, which is at least as strong as any isolation
+// level asked for. A commit fails with a serialization failure, and is
+// retried like on PostgreSQL, when the store was written outside the
+// transaction since the copy was taken.
+type TxManager struct {
+	store *Store
+	txMu  sync.Mutex
+}
+
+func NewTxManager(s *Store) *TxManager {
+	return &TxManager{
+		store: s,
+	}
+}
+
+func (m *TxManager) WithinTx(ctx context.Context, fn repositories.TxFunc, opts ...repositories.TxOption) error {
+	o := repositories.NewTxOptions(opts...)
+
+	m.txMu.Lock()
+	defer m.txMu.Unlock()
+
+	for attempt := 0; ; attempt++ {
+		err := m.run(ctx, o, fn)
+
+		if err == nil || !repositories.IsRetryable(err) || attempt >= o.MaxRetries {
+			return err
+		}
+	}
+}
+
+func (m *TxManager) run(ctx context.Context, o repositories.TxOptions, fn repositories.TxFunc) error {
+	m.store.mu.RLock()
+	tx := m.store.clone()
+	version := m.store.mu.writes
+	m.store.mu.RUnlock()
+
+	r := newRepositories(tx)
+	r.TxManager = joinedTx{repositories: r}
+
+	if err := fn(ctx, r); err != nil {
+		return err
+	}
+
+	if o.ReadOnly {
+		if tx.mu.writes != 0 {
+			return errReadOnlyTransaction
+		}
+		return nil
+	}
+
+	if tx.mu.writes == 0 {
+		return nil
+	}
+
+	return m.store.commit(tx, version)
+}
+
+type joinedTx struct {
+	repositories *repositories.Repositories
+}
+
+func (j joinedTx) WithinTx(ctx context.Context, fn repositories.TxFunc, opts ...repositories.TxOption) error {
+	return fn(ctx, j.repositories)
+}
+
+// storeMutex counts the times it is locked for writing, so that a
+// transaction can tell whether the store changed under it.
+type storeMutex struct {
+	sync.RWMutex
+	writes uint64
+}
+
+func (m *storeMutex) Lock() {
+	m.RWMutex.Lock()
+	m.writes++
+}
+
+// commit replaces the contents of the store with those of tx, unless the
+// store was written since version.
+func (s *Store) commit(tx *Store, version uint64) error {
+	s.mu.RWMutex.Lock()
+	defer s.mu.RWMutex.Unlock()
+
+	if s.mu.writes != version {
+		return errSerializationFailure
+	}
+
+	s.replace(tx)
+	s.mu.writes++
+
+	return nil
+}
+
+// clone copies the contents of the store. The caller must hold the lock.
+func (s *Store) clone() *Store {
+	return &Store{
+		categories:         maps.Clone(s.categories),
+		items:              maps.Clone(s.items),
+		users:              maps.Clone(s.users),
+		links:              maps.Clone(s.links),
+		itemSources:        maps.Clone(s.itemSources),
+		jobRuns:            maps.Clone(s.jobRuns),
+		audit:              slices.Clone(s.audit),
+		revisions:          slices.Clone(s.revisions),
+		prices:             slices.Clone(s.prices),
+		rates:              maps.Clone(s.rates),
+		coupons:            maps.Clone(s.coupons),
+		sales:              maps.Clone(s.sales),
+		taxZones:           maps.Clone(s.taxZones),
+		taxClasses:         maps.Clone(s.taxClasses),
+		taxRates:           maps.Clone(s.taxRates),
+		itemTaxClasses:     maps.Clone(s.itemTaxClasses),
+		categoryTaxClasses: maps.Clone(s.categoryTaxClasses),
+		shippingMethods:    maps.Clone(s.shippingMethods),
+		reviews:            maps.Clone(s.reviews),
+		reviewVotes:        maps.Clone(s.reviewVotes),
+		ratingSums:         maps.Clone(s.ratingSums),
+		categorySeq:        s.categorySeq,
+		itemSeq:            s.itemSeq,
+		userSeq:            s.userSeq,
+		jobRunSeq:          s.jobRunSeq,
+		priceSeq:           s.priceSeq,
+		couponSeq:          s.couponSeq,
+		saleSeq:            s.saleSeq,
+		taxRateSeq:         s.taxRateSeq,
+		shippingMethodSeq:  s.shippingMethodSeq,
+		shippingRuleSeq:    s.shippingRuleSeq,
+		reviewSeq:          s.reviewSeq,
+	}
+}
+
+// replace sets the contents of the store to those of snapshot. The caller
+// must hold the lock.
+func (s *Store) replace(snapshot *Store) {
+	s.categories = snapshot.categories
+	s.items = snapshot.items
+	s.users = snapshot.users
+	s.links = snapshot.links
+	s.itemSources = snapshot.itemSources
+	s.jobRuns = snapshot.jobRuns
+	s.audit = snapshot.audit
+	s.revisions = snapshot.revisions
+	s.prices = snapshot.prices
+	s.rates = snapshot.rates
+	s.coupons = snapshot.coupons
+	s.sales = snapshot.sales
+	s.taxZones = snapshot.taxZones
+	s.taxClasses = snapshot.taxClasses
+	s.taxRates = snapshot.taxRates
+	s.itemTaxClasses = snapshot.itemTaxClasses
+	s.categoryTaxClasses = snapshot.categoryTaxClasses
+	s.shippingMethods = snapshot.shippingMethods
+	s.reviews = snapshot.reviews
+	s.reviewVotes = snapshot.reviewVotes
+	s.ratingSums = snapshot.ratingSums
+	s.categorySeq = snapshot.categorySeq
+	s.itemSeq = snapshot.itemSeq
+	s.userSeq = snapshot.userSeq
+	s.jobRunSeq = snapshot.jobRunSeq
+	s.priceSeq = snapshot.priceSeq
+	s.couponSeq = snapshot.couponSeq
+	s.saleSeq = snapshot.saleSeq
+	s.taxRateSeq = snapshot.taxRateSeq
+	s.shippingMethodSeq = snapshot.shippingMethodSeq
+	s.shippingRuleSeq = snapshot.shippingRuleSeq
+	s.reviewSeq = snapshot.reviewSeq
+}
@@ -0,0 +1,110 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"training/proj/internal/api/models"
+	"training/proj/internal/db/repositories"
+
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestWithinTxDiscardsWritesOnError(t *testing.T) {
+	s := NewStore()
+	repos := NewRepositories(s)
+	ctx := context.Background()
+	errAbort := errors.New("abort")
+
+	err := repos.TxManager.WithinTx(ctx, func(ctx context.Context, tx *repositories.Repositories) error {
+		if _, err := tx.ItemRepository.Create(ctx, &models.Item{Item: "apple", Price: 100}); err != nil {
+			return err
+		}
+		return errAbort
+	})
+	if !errors.Is(err, errAbort) {
+		t.Fatalf("WithinTx = %v, want %v", err, errAbort)
+	}
+
+	items, _ := repos.ItemRepository.GetAll(ctx)
+	if len(items) != 0 {
+		t.Fatalf("rolled back transaction left %d items", len(items))
+	}
+}
+
+func TestWithinTxHidesWritesUntilCommit(t *testing.T) {
+	s := NewStore()
+	repos := NewRepositories(s)
+	ctx := context.Background()
+
+	err := repos.TxManager.WithinTx(ctx, func(ctx context.Context, tx *repositories.Repositories) error {
+		if _, err := tx.ItemRepository.Create(ctx, &models.Item{Item: "apple", Price: 100}); err != nil {
+			return err
+		}
+
+		if items, _ := repos.ItemRepository.GetAll(ctx); len(items) != 0 {
+			t.Errorf("uncommitted item is visible outside the transaction")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("WithinTx: %v", err)
+	}
+
+	if items, _ := repos.ItemRepository.GetAll(ctx); len(items) != 1 {
+		t.Fatalf("got %d items after commit, want 1", len(items))
+	}
+}
+
+func TestWithinTxRetriesAfterConcurrentWrite(t *testing.T) {
+	s := NewStore()
+	repos := NewRepositories(s)
+	ctx := context.Background()
+	attempts := 0
+
+	err := repos.TxManager.WithinTx(ctx, func(ctx context.Context, tx *repositories.Repositories) error {
+		attempts++
+		if attempts == 1 {
+			// A write that commits outside the transaction while it runs.
+			if _, err := repos.ItemRepository.Create(ctx, &models.Item{Item: "pear", Price: 50}); err != nil {
+				return err
+			}
+		}
+
+		_, err := tx.ItemRepository.Create(ctx, &models.Item{Item: "apple", Price: 100})
+		return err
+	})
+	if err != nil {
+		t.Fatalf("WithinTx: %v", err)
+	}
+
+	if attempts != 2 {
+		t.Errorf("transaction ran %d times, want 2", attempts)
+	}
+
+	items, _ := repos.ItemRepository.GetAll(ctx)
+	if len(items) != 2 {
+		t.Fatalf("got %d items, want the concurrent write and the transaction's", len(items))
+	}
+}
+
+func TestWithinTxReadOnlyRejectsWrites(t *testing.T) {
+	s := NewStore()
+	repos := NewRepositories(s)
+	ctx := context.Background()
+
+	err := repos.TxManager.WithinTx(ctx, func(ctx context.Context, tx *repositories.Repositories) error {
+		_, err := tx.ItemRepository.Create(ctx, &models.Item{Item: "apple", Price: 100})
+		return err
+	}, repositories.WithReadOnly())
+
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) || pgErr.Code != pgerrcode.ReadOnlySQLTransaction {
+		t.Fatalf("WithinTx = %v, want a read-only transaction error", err)
+	}
+
+	if items, _ := repos.ItemRepository.GetAll(ctx); len(items) != 0 {
+		t.Fatalf("read-only transaction left %d items", len(items))
+	}
+}
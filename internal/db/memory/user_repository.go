@@ -0,0 +1,87 @@
+package memory
+
+import (
+	"context"
+	"strconv"
+	"training/proj/internal/api/models"
+	"training/proj/internal/db/repositories"
+)
+
+var _ repositories.UserRepositoryInterface = (*UserRepository)(nil)
+
+type storedUser struct {
+	models.User
+	hashedPassword []byte
+}
+
+type UserRepository struct {
+	store *Store
+}
+
+func NewUserRepository(s *Store) *UserRepository {
+	return &UserRepository{
+		store: s,
+	}
+}
+
+func (r *UserRepository) Create(ctx context.Context, userReq *models.User, hashedPassword []byte) (models.User, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, u := range r.store.users {
+		if u.Email == userReq.Email || u.Username == userReq.Username {
+			return models.User{}, errUniqueViolation
+		}
+	}
+
+	r.store.userSeq++
+	user := models.User{
+		UserID:    strconv.FormatInt(r.store.userSeq, 10),
+		Email:     userReq.Email,
+		Username:  userReq.Username,
+		FirstName: userReq.FirstName,
+		LastName:  userReq.LastName,
+	}
+	r.store.users[r.store.userSeq] = storedUser{User: user, hashedPassword: hashedPassword}
+
+	return user, nil
+}
+
+func (r *UserRepository) GetByEmail(ctx context.Context, email string) (models.User, error) {
+	return r.find(func(u storedUser) bool { return u.Email == email })
+}
+
+func (r *UserRepository) GetByUsername(ctx context.Context, username string) (models.User, error) {
+	return r.find(func(u storedUser) bool { return u.Username == username })
+}
+
+func (r *UserRepository) SetAdmin(ctx context.Context, username string, isAdmin bool) (int64, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for id, u := range r.store.users {
+		if u.Username == username {
+			u.IsAdmin = isAdmin
+			r.store.users[id] = u
+			return 1, nil
+		}
+	}
+
+	return 0, nil
+}
+
+func (r *UserRepository) find(match func(storedUser) bool) (models.User, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	for _, id := range sortedKeys(r.store.users) {
+		u := r.store.users[id]
+		if match(u) {
+			user := u.User
+			user.Password = string(u.hashedPassword)
+			return user, nil
+		}
+	}
+
+	return models.User{}, repositories.ErrNotFound
+}
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS items_import_source_idx;
+
+ALTER TABLE items DROP COLUMN IF EXISTS import_source;
@@ -0,0 +1,3 @@
+ALTER TABLE items ADD COLUMN IF NOT EXISTS import_source TEXT;
+
+CREATE INDEX IF NOT EXISTS items_import_source_idx ON items (import_source) WHERE import_source IS NOT NULL;
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS job_runs (
+    job_run_id BIGSERIAL PRIMARY KEY,
+    job TEXT NOT NULL,
+    triggered_by TEXT NOT NULL,
+    status TEXT NOT NULL,
+    started_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    finished_at TIMESTAMPTZ,
+    stats JSONB,
+    error TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS job_runs_job_started_at_idx ON job_runs (job, started_at DESC);
@@ -0,0 +1,8 @@
+DELETE FROM categories WHERE deleted_at IS NOT NULL;
+DELETE FROM items WHERE deleted_at IS NOT NULL;
+
+DROP INDEX IF EXISTS items_deleted_at_idx;
+DROP INDEX IF EXISTS categories_deleted_at_idx;
+
+ALTER TABLE items DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE categories DROP COLUMN IF EXISTS deleted_at;
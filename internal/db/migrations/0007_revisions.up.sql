@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS revisions (
+    revision_id BIGSERIAL PRIMARY KEY,
+    entity_type TEXT NOT NULL,
+    entity_id BIGINT NOT NULL,
+    revision BIGINT NOT NULL,
+    data JSONB NOT NULL,
+    actor_id TEXT NOT NULL DEFAULT '',
+    actor TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CONSTRAINT revisions_entity_revision_key UNIQUE (entity_type, entity_id, revision)
+);
+
+INSERT INTO revisions (entity_type, entity_id, revision, data)
+SELECT 'item', item_id, 1, jsonb_build_object('item_id', item_id, 'item', item, 'price', price)
+FROM items
+ON CONFLICT DO NOTHING;
+
+INSERT INTO revisions (entity_type, entity_id, revision, data)
+SELECT 'category', category_id, 1, jsonb_build_object('category_id', category_id, 'category', category)
+FROM categories
+ON CONFLICT DO NOTHING;
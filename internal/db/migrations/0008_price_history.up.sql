@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS price_history (
+    price_id BIGSERIAL PRIMARY KEY,
+    item_id INTEGER NOT NULL REFERENCES items (item_id) ON UPDATE CASCADE ON DELETE CASCADE,
+    price INTEGER NOT NULL,
+    effective_at TIMESTAMPTZ NOT NULL,
+    applied_at TIMESTAMPTZ,
+    actor_id TEXT NOT NULL DEFAULT '',
+    actor TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS price_history_item_idx ON price_history (item_id, effective_at);
+CREATE INDEX IF NOT EXISTS price_history_pending_idx ON price_history (effective_at) WHERE applied_at IS NULL;
+
+INSERT INTO price_history (item_id, price, effective_at, applied_at)
+SELECT item_id, price, now(), now() FROM items;
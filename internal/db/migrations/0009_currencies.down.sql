@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS exchange_rates;
+
+ALTER TABLE items DROP COLUMN IF EXISTS currency;
+
+ALTER TABLE price_history ALTER COLUMN price TYPE INTEGER;
+ALTER TABLE items ALTER COLUMN price TYPE INTEGER;
@@ -0,0 +1,20 @@
+ALTER TABLE items ALTER COLUMN price TYPE BIGINT;
+ALTER TABLE price_history ALTER COLUMN price TYPE BIGINT;
+
+-- Existing items are priced in the configured pricing.currency, which the
+-- migrator passes in the app.default_currency setting. Migrations run by
+-- other tools fall back to USD, the default of pricing.currency.
+ALTER TABLE items ADD COLUMN IF NOT EXISTS currency CHAR(3);
+UPDATE items SET currency = COALESCE(NULLIF(current_setting('app.default_currency', true), ''), 'USD')
+WHERE currency IS NULL;
+ALTER TABLE items ALTER COLUMN currency SET NOT NULL;
+
+CREATE TABLE IF NOT EXISTS exchange_rates (
+    base CHAR(3) NOT NULL,
+    quote CHAR(3) NOT NULL,
+    rate NUMERIC(24, 12) NOT NULL CHECK (rate > 0),
+    source TEXT NOT NULL DEFAULT 'manual',
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CONSTRAINT exchange_rates_pkey PRIMARY KEY (base, quote),
+    CONSTRAINT exchange_rates_distinct CHECK (base <> quote)
+);
@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS coupons (
+    coupon_id BIGSERIAL PRIMARY KEY,
+    code TEXT NOT NULL,
+    kind TEXT NOT NULL CHECK (kind IN ('percent', 'fixed', 'buy_x_get_y')),
+    percent_off INTEGER NOT NULL DEFAULT 0 CHECK (percent_off BETWEEN 0 AND 100),
+    amount_off BIGINT NOT NULL DEFAULT 0 CHECK (amount_off >= 0),
+    currency CHAR(3),
+    buy_quantity INTEGER NOT NULL DEFAULT 0 CHECK (buy_quantity >= 0),
+    get_quantity INTEGER NOT NULL DEFAULT 0 CHECK (get_quantity >= 0),
+    item_ids BIGINT[] NOT NULL DEFAULT '{}',
+    category_ids BIGINT[] NOT NULL DEFAULT '{}',
+    valid_from TIMESTAMPTZ,
+    valid_until TIMESTAMPTZ,
+    max_uses INTEGER CHECK (max_uses > 0),
+    uses INTEGER NOT NULL DEFAULT 0,
+    stackable BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CONSTRAINT coupons_validity CHECK (valid_until IS NULL OR valid_from IS NULL OR valid_until > valid_from),
+    CONSTRAINT coupons_uses CHECK (max_uses IS NULL OR uses <= max_uses)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS coupons_code_idx ON coupons (upper(code));
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS sales (
+    sale_id BIGSERIAL PRIMARY KEY,
+    name TEXT NOT NULL,
+    category_id INTEGER NOT NULL REFERENCES categories (category_id) ON UPDATE CASCADE ON DELETE CASCADE,
+    percent_off INTEGER NOT NULL CHECK (percent_off BETWEEN 1 AND 100),
+    priority INTEGER NOT NULL DEFAULT 0,
+    starts_at TIMESTAMPTZ NOT NULL,
+    ends_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CONSTRAINT sales_period CHECK (ends_at > starts_at)
+);
+
+CREATE INDEX IF NOT EXISTS sales_category_idx ON sales (category_id, starts_at, ends_at);
@@ -0,0 +1,6 @@
+ALTER TABLE categories DROP COLUMN IF EXISTS tax_class;
+ALTER TABLE items DROP COLUMN IF EXISTS tax_class;
+
+DROP TABLE IF EXISTS tax_rates;
+DROP TABLE IF EXISTS tax_classes;
+DROP TABLE IF EXISTS tax_zones;
@@ -0,0 +1,27 @@
+CREATE TABLE IF NOT EXISTS tax_zones (
+    code TEXT PRIMARY KEY,
+    name TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS tax_classes (
+    code TEXT PRIMARY KEY,
+    name TEXT NOT NULL
+);
+
+INSERT INTO tax_classes (code, name) VALUES ('standard', 'Standard rate') ON CONFLICT DO NOTHING;
+
+CREATE TABLE IF NOT EXISTS tax_rates (
+    rate_id BIGSERIAL PRIMARY KEY,
+    zone TEXT NOT NULL REFERENCES tax_zones (code) ON UPDATE CASCADE ON DELETE CASCADE,
+    tax_class TEXT NOT NULL REFERENCES tax_classes (code) ON UPDATE CASCADE ON DELETE CASCADE,
+    rate NUMERIC(7, 4) NOT NULL CHECK (rate >= 0 AND rate <= 100),
+    effective_from TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CONSTRAINT tax_rates_effective_key UNIQUE (zone, tax_class, effective_from)
+);
+
+ALTER TABLE items ADD COLUMN IF NOT EXISTS tax_class TEXT
+    REFERENCES tax_classes (code) ON UPDATE CASCADE ON DELETE SET NULL;
+
+ALTER TABLE categories ADD COLUMN IF NOT EXISTS tax_class TEXT
+    REFERENCES tax_classes (code) ON UPDATE CASCADE ON DELETE SET NULL;
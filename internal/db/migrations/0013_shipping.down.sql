@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS shipping_rules;
+DROP TABLE IF EXISTS shipping_methods;
+
+ALTER TABLE items DROP COLUMN IF EXISTS height_mm;
+ALTER TABLE items DROP COLUMN IF EXISTS width_mm;
+ALTER TABLE items DROP COLUMN IF EXISTS length_mm;
+ALTER TABLE items DROP COLUMN IF EXISTS weight_grams;
@@ -0,0 +1,31 @@
+ALTER TABLE items ADD COLUMN IF NOT EXISTS weight_grams BIGINT CHECK (weight_grams > 0);
+ALTER TABLE items ADD COLUMN IF NOT EXISTS length_mm BIGINT CHECK (length_mm > 0);
+ALTER TABLE items ADD COLUMN IF NOT EXISTS width_mm BIGINT CHECK (width_mm > 0);
+ALTER TABLE items ADD COLUMN IF NOT EXISTS height_mm BIGINT CHECK (height_mm > 0);
+
+CREATE TABLE IF NOT EXISTS shipping_methods (
+    method_id BIGSERIAL PRIMARY KEY,
+    name TEXT NOT NULL,
+    kind TEXT NOT NULL CHECK (kind IN ('flat', 'weight', 'free_over')),
+    currency CHAR(3) NOT NULL,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS shipping_rules (
+    rule_id BIGSERIAL PRIMARY KEY,
+    method_id BIGINT NOT NULL REFERENCES shipping_methods (method_id) ON DELETE CASCADE,
+    country CHAR(2) NOT NULL,
+    postal_prefix TEXT NOT NULL DEFAULT '',
+    rate BIGINT NOT NULL CHECK (rate >= 0),
+    per_kg BIGINT NOT NULL DEFAULT 0 CHECK (per_kg >= 0),
+    free_over BIGINT CHECK (free_over >= 0),
+    max_weight_grams BIGINT CHECK (max_weight_grams > 0),
+    min_days INTEGER NOT NULL CHECK (min_days >= 0),
+    max_days INTEGER NOT NULL,
+    CONSTRAINT shipping_rules_zone_key UNIQUE (method_id, country, postal_prefix),
+    CONSTRAINT shipping_rules_days CHECK (max_days >= min_days)
+);
+
+CREATE INDEX IF NOT EXISTS shipping_rules_country_idx ON shipping_rules (country);
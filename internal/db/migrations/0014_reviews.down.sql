@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS review_votes;
+DROP TABLE IF EXISTS reviews;
+
+ALTER TABLE items DROP COLUMN IF EXISTS rating_sum;
+ALTER TABLE items DROP COLUMN IF EXISTS rating_count;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS categories_items_import_source_idx;
+
+ALTER TABLE categories_items DROP COLUMN IF EXISTS import_source;
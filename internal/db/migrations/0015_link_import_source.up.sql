@@ -0,0 +1,3 @@
+ALTER TABLE categories_items ADD COLUMN IF NOT EXISTS import_source TEXT;
+
+CREATE INDEX IF NOT EXISTS categories_items_import_source_idx ON categories_items (import_source) WHERE import_source IS NOT NULL;
@@ -1,45 +1,187 @@
 package db
 
 import (
-	"database/sql"
+	"context"
 	"errors"
-	"log"
+	"fmt"
+	"time"
 	"training/proj/internal/config"
+	"training/proj/internal/db/migrations"
+	"training/proj/internal/logger"
+	"training/proj/internal/tracing"
 
-	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
 
 	"github.com/golang-migrate/migrate/v4"
 	"github.com/golang-migrate/migrate/v4/database/postgres"
-	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
 )
 
-func Connect(cfg *config.Config) (*sql.DB, error) {
-	db, err := sql.Open("pgx", cfg.DSN)
+// migrationLockKey is the advisory lock held while migrations run, so that
+// replicas starting at the same time apply them one after another.
+const migrationLockKey int64 = 7_362_514_001
 
-	return db, err
+func Connect(ctx context.Context, cfg *config.Config) (*pgxpool.Pool, error) {
+	poolConfig, err := pgxpool.ParseConfig(cfg.DB.ConnString())
+
+	if err != nil {
+		return nil, err
+	}
+
+	poolConfig.MinConns = int32(cfg.DB.MinConns)
+	poolConfig.MaxConns = int32(cfg.DB.MaxConns)
+	poolConfig.MaxConnLifetime = cfg.DB.MaxConnLifetime
+	poolConfig.MaxConnIdleTime = cfg.DB.MaxConnIdleTime
+	poolConfig.HealthCheckPeriod = cfg.DB.HealthCheckPeriod
+	poolConfig.ConnConfig.Tracer = tracing.PgxTracer{}
+
+	if cfg.DB.StatementCacheSize > 0 {
+		poolConfig.ConnConfig.StatementCacheCapacity = cfg.DB.StatementCacheSize
+	} else {
+		poolConfig.ConnConfig.DefaultQueryExecMode = pgx.QueryExecModeExec
+	}
+
+	pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := waitForDatabase(ctx, pool, cfg.DB.ConnectRetryDeadline); err != nil {
+		pool.Close()
+		return nil, err
+	}
+
+	return pool, nil
 }
 
-func CreateTables(db *sql.DB, cfg *config.Config) error {
+func waitForDatabase(ctx context.Context, pool *pgxpool.Pool, deadline time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, deadline)
+	defer cancel()
+
+	backoff := 500 * time.Millisecond
+
+	for attempt := 1; ; attempt++ {
+		err := pool.Ping(ctx)
+
+		if err == nil {
+			return nil
+		}
+
+		logger.Logger.Warnw("Database is not ready yet", "attempt", attempt, "retry_in", backoff.String(), "error", err)
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("database not reachable after %d attempts: %w", attempt, err)
+		case <-time.After(backoff):
+		}
+
+		backoff = min(backoff*2, 10*time.Second)
+	}
+}
+
+func NewMigrator(pool *pgxpool.Pool, cfg *config.Config) (*migrate.Migrate, error) {
+	source, err := iofs.New(migrations.FS, ".")
+
+	if err != nil {
+		return nil, err
+	}
+
+	// Migrations that backfill prices read the configured currency from
+	// app.default_currency, so they run on connections of their own that
+	// set it rather than on the pool's.
+	db := stdlib.OpenDB(*pool.Config().ConnConfig, stdlib.OptionAfterConnect(func(ctx context.Context, conn *pgx.Conn) error {
+		_, err := conn.Exec(ctx, `SELECT set_config('app.default_currency', $1, false)`, cfg.Pricing.Currency)
+		return err
+	}))
+
 	driver, err := postgres.WithInstance(db, &postgres.Config{})
 
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return migrate.NewWithInstance("iofs", source, cfg.DB.Name, driver)
+}
+
+func WithMigrationLock(ctx context.Context, pool *pgxpool.Pool, fn func() error) error {
+	conn, err := pool.Acquire(ctx)
+
 	if err != nil {
 		return err
 	}
 
-	m, err := migrate.NewWithDatabaseInstance("file://internal/db/migrations", cfg.DbName, driver)
+	defer conn.Release()
+
+	_, err = conn.Exec(ctx, `SELECT pg_advisory_lock($1)`, migrationLockKey)
+
+	if err != nil {
+		return fmt.Errorf("acquire migration lock: %w", err)
+	}
+
+	defer conn.Exec(context.WithoutCancel(ctx), `SELECT pg_advisory_unlock($1)`, migrationLockKey)
+
+	return fn()
+}
+
+func CreateTables(ctx context.Context, pool *pgxpool.Pool, cfg *config.Config) error {
+	m, err := NewMigrator(pool, cfg)
 
 	if err != nil {
 		return err
 	}
 
-	err = m.Up()
+	defer m.Close()
+
+	err = WithMigrationLock(ctx, pool, m.Up)
 
 	switch {
 	case errors.Is(err, migrate.ErrNoChange):
 	case err != nil:
 		return err
 	default:
-		log.Println("Migrations applied successfully")
+		logger.Logger.Info("Migrations applied successfully")
+	}
+
+	version, dirty, err := m.Version()
+
+	if err != nil {
+		return err
 	}
+
+	logger.Logger.Infow("Database schema version", "version", version, "dirty", dirty)
+
 	return nil
 }
+
+type MigrationStatus struct {
+	Version uint `json:"version"`
+	Dirty   bool `json:"dirty"`
+}
+
+func Ping(ctx context.Context, pool *pgxpool.Pool) error {
+	return pool.Ping(ctx)
+}
+
+func GetMigrationStatus(ctx context.Context, pool *pgxpool.Pool) (MigrationStatus, error) {
+	var status MigrationStatus
+
+	err := pool.QueryRow(ctx, `SELECT version, dirty FROM schema_migrations LIMIT 1`).Scan(&status.Version, &status.Dirty)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return status, fmt.Errorf("no migrations applied")
+	}
+
+	if err != nil {
+		return status, err
+	}
+
+	if status.Dirty {
+		return status, fmt.Errorf("migration %d is dirty", status.Version)
+	}
+
+	return status, nil
+}
@@ -0,0 +1,100 @@
+package repositories
+
+import (
+	"context"
+	"time"
+	"training/proj/internal/api/models"
+)
+
+// AuditFilter narrows an audit log query. Empty fields match everything;
+// Actor matches either the actor id or the actor's email.
+type AuditFilter struct {
+	Actor      string
+	EntityType string
+	EntityID   string
+	From       *time.Time
+	To         *time.Time
+	Limit      int
+}
+
+type AuditRepositoryInterface interface {
+	Create(context.Context, *models.AuditEntry) error
+	GetAll(context.Context, AuditFilter) ([]models.AuditEntry, error)
+}
+
+var _ AuditRepositoryInterface = (*AuditRepository)(nil)
+
+type AuditRepository struct {
+	db      DBTX
+	timeout time.Duration
+}
+
+func NewAuditRepository(db DBTX, timeout time.Duration) *AuditRepository {
+	return &AuditRepository{
+		db:      db,
+		timeout: timeout,
+	}
+}
+
+func (r *AuditRepository) Create(ctx context.Context, entry *models.AuditEntry) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	sqlStatement := `INSERT INTO audit_log (actor_id, actor, action, entity_type, entity_id, before, after, request_id, ip)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING audit_id, occurred_at`
+
+	return r.db.QueryRow(ctx, sqlStatement,
+		entry.ActorID,
+		entry.Actor,
+		entry.Action,
+		entry.EntityType,
+		entry.EntityID,
+		[]byte(entry.Before),
+		[]byte(entry.After),
+		entry.RequestID,
+		entry.IP).Scan(&entry.AuditID, &entry.OccurredAt)
+}
+
+func (r *AuditRepository) GetAll(ctx context.Context, filter AuditFilter) ([]models.AuditEntry, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	entries := make([]models.AuditEntry, 0)
+
+	sqlStatement := `SELECT audit_id, occurred_at, actor_id, actor, action, entity_type, entity_id, before, after, request_id, ip
+	FROM audit_log
+	WHERE ($1 = '' OR actor_id = $1 OR actor = $1)
+	AND ($2 = '' OR entity_type = $2)
+	AND ($3 = '' OR entity_id = $3)
+	AND ($4::timestamptz IS NULL OR occurred_at >= $4)
+	AND ($5::timestamptz IS NULL OR occurred_at < $5)
+	ORDER BY occurred_at DESC, audit_id DESC
+	LIMIT $6`
+
+	rows, queryErr := r.db.Query(ctx, sqlStatement,
+		filter.Actor, filter.EntityType, filter.EntityID, filter.From, filter.To, filter.Limit)
+
+	if queryErr != nil {
+		return nil, queryErr
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var entry models.AuditEntry
+		var before, after []byte
+
+		scanErr := rows.Scan(&entry.AuditID, &entry.OccurredAt, &entry.ActorID, &entry.Actor, &entry.Action,
+			&entry.EntityType, &entry.EntityID, &before, &after, &entry.RequestID, &entry.IP)
+
+		if scanErr != nil {
+			return nil, scanErr
+		}
+
+		entry.Before = before
+		entry.After = after
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
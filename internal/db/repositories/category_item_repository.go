@@ -1,33 +1,161 @@
 package repositories
 
 import (
-	"database/sql"
-	"errors"
-
-	"github.com/jackc/pgx/v5/pgconn"
+	"context"
+	"time"
+	"training/proj/internal/api/models"
 )
 
-type CategoryItemrepositoryInterface interface {
-	Create(int64, int64) *pgconn.PgError
+type CategoryItemRepositoryInterface interface {
+	Create(context.Context, int64, int64) error
+	Delete(context.Context, int64, int64) (int64, error)
+	GetAll(context.Context) ([]models.CategoryItem, error)
+	GetBySource(context.Context, string) ([]models.CategoryItem, error)
+	SetSource(context.Context, int64, int64, string) error
 }
 
+var _ CategoryItemRepositoryInterface = (*CategoryItemRepository)(nil)
+
 type CategoryItemRepository struct {
-	db *sql.DB
+	db      DBTX
+	timeout time.Duration
 }
 
-func NewCategoryItemRepository(db *sql.DB) *CategoryItemRepository {
+func NewCategoryItemRepository(db DBTX, timeout time.Duration) *CategoryItemRepository {
 	return &CategoryItemRepository{
-		db: db,
+		db:      db,
+		timeout: timeout,
+	}
+}
+
+// Create links an item to a category. It returns ErrNotFound when either of
+// them does not exist or is in the trash.
+func (r *CategoryItemRepository) Create(ctx context.Context, categoryId int64, itemId int64) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	sqlStatement := `INSERT INTO categories_items (category_id, item_id)
+	SELECT category_id, item_id FROM categories, items
+	WHERE category_id = $1 AND categories.deleted_at IS NULL
+	AND item_id = $2 AND items.deleted_at IS NULL`
+
+	tag, execErr := r.db.Exec(ctx, sqlStatement, categoryId, itemId)
+
+	if execErr != nil {
+		return execErr
+	}
+
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *CategoryItemRepository) Delete(ctx context.Context, categoryId int64, itemId int64) (int64, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	sqlStatement := `DELETE FROM categories_items WHERE category_id = $1 AND item_id = $2`
+
+	tag, execErr := r.db.Exec(ctx, sqlStatement, categoryId, itemId)
+
+	if execErr != nil {
+		return 0, execErr
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+// GetAll returns the links between categories and items that are not in the
+// trash.
+func (r *CategoryItemRepository) GetAll(ctx context.Context) ([]models.CategoryItem, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	links := make([]models.CategoryItem, 0)
+
+	sqlStatement := `SELECT category_id, item_id FROM categories_items
+	INNER JOIN categories USING (category_id)
+	INNER JOIN items USING (item_id)
+	WHERE categories.deleted_at IS NULL AND items.deleted_at IS NULL`
+
+	rows, queryErr := r.db.Query(ctx, sqlStatement)
+
+	if queryErr != nil {
+		return nil, queryErr
 	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var link models.CategoryItem
+
+		scanErr := rows.Scan(&link.CategoryID, &link.ItemID)
+
+		if scanErr != nil {
+			return nil, scanErr
+		}
+
+		links = append(links, link)
+	}
+
+	return links, rows.Err()
 }
 
-func (r *CategoryItemRepository) Create(categoryId int64, itemId int64) *pgconn.PgError {
-	sqlStatement := `INSERT INTO categories_items (category_id, item_id) VALUES ($1, $2)`
+// GetBySource returns the links an importer made between categories and
+// items that are not in the trash. Links made by hand have no source.
+func (r *CategoryItemRepository) GetBySource(ctx context.Context, source string) ([]models.CategoryItem, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	links := make([]models.CategoryItem, 0)
+
+	sqlStatement := `SELECT category_id, item_id FROM categories_items
+	INNER JOIN categories USING (category_id)
+	INNER JOIN items USING (item_id)
+	WHERE categories_items.import_source = $1
+	AND categories.deleted_at IS NULL AND items.deleted_at IS NULL
+	ORDER BY category_id, item_id`
+
+	rows, queryErr := r.db.Query(ctx, sqlStatement, source)
+
+	if queryErr != nil {
+		return nil, queryErr
+	}
+
+	defer rows.Close()
 
-	_, err := r.db.Exec(sqlStatement, categoryId, itemId)
+	for rows.Next() {
+		var link models.CategoryItem
 
-	var e *pgconn.PgError
-	errors.As(err, &e)
+		scanErr := rows.Scan(&link.CategoryID, &link.ItemID)
+
+		if scanErr != nil {
+			return nil, scanErr
+		}
+
+		links = append(links, link)
+	}
+
+	return links, rows.Err()
+}
+
+func (r *CategoryItemRepository) SetSource(ctx context.Context, categoryId int64, itemId int64, source string) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	sqlStatement := `UPDATE categories_items SET import_source = NULLIF($3, '') WHERE category_id = $1 AND item_id = $2`
+
+	tag, execErr := r.db.Exec(ctx, sqlStatement, categoryId, itemId, source)
+
+	if execErr != nil {
+		return execErr
+	}
+
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
 
-	return e
+	return nil
 }
@@ -1,47 +1,60 @@
 package repositories
 
 import (
-	"database/sql"
-	"log"
+	"context"
+	"time"
 	"training/proj/internal/api/models"
 )
 
 type CategoryRepositoryInterface interface {
-	Create(*models.Category) (models.Category, error)
-	GetAll() ([]models.Category, error)
-	GetByName(string) (models.Category, error)
-	GetById(int64) (models.Category, error)
-	Delete(int64) ([]models.Category, error)
-	Update(int64, *models.Category) (int64, error)
-	GetCategoryItems(int64) ([]models.Item, error)
+	Create(context.Context, *models.Category) (models.Category, error)
+	GetAll(context.Context) ([]models.Category, error)
+	GetByName(context.Context, string) (models.Category, error)
+	GetById(context.Context, int64) (models.Category, error)
+	Delete(context.Context, int64) (int64, error)
+	Update(context.Context, int64, *models.Category) (models.Category, error)
+	GetCategoryItems(context.Context, int64) ([]models.Item, error)
+	GetDeleted(context.Context) ([]models.Category, error)
+	Restore(context.Context, int64) (models.Category, error)
+	Purge(context.Context, time.Time) (int64, error)
 }
 
+var _ CategoryRepositoryInterface = (*CategoryRepository)(nil)
+
 type CategoryRepository struct {
-	db *sql.DB
+	db      DBTX
+	timeout time.Duration
 }
 
-func NewCategoryRepository(db *sql.DB) *CategoryRepository {
+func NewCategoryRepository(db DBTX, timeout time.Duration) *CategoryRepository {
 	return &CategoryRepository{
-		db: db,
+		db:      db,
+		timeout: timeout,
 	}
 }
 
-func (r *CategoryRepository) Create(categoryReq *models.Category) (models.Category, error) {
-	sqlStatement := `INSERT INTO categories (category) VALUES ($1) RETURNING *`
+func (r *CategoryRepository) Create(ctx context.Context, categoryReq *models.Category) (models.Category, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	sqlStatement := `INSERT INTO categories (category) VALUES ($1) RETURNING category_id, category`
 
 	var categoryResp models.Category
 
-	err := r.db.QueryRow(sqlStatement, categoryReq.Category).Scan(&categoryResp.CategoryID, &categoryResp.Category)
+	err := r.db.QueryRow(ctx, sqlStatement, categoryReq.Category).Scan(&categoryResp.CategoryID, &categoryResp.Category)
 
 	return categoryResp, err
 }
 
-func (r *CategoryRepository) GetAll() ([]models.Category, error) {
+func (r *CategoryRepository) GetAll(ctx context.Context) ([]models.Category, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
 	categories := make([]models.Category, 0)
 
-	sqlStatement := `SELECT * FROM categories`
+	sqlStatement := `SELECT category_id, category FROM categories WHERE deleted_at IS NULL`
 
-	rows, queryErr := r.db.Query(sqlStatement)
+	rows, queryErr := r.db.Query(ctx, sqlStatement)
 
 	if queryErr != nil {
 		return nil, queryErr
@@ -62,76 +75,88 @@ func (r *CategoryRepository) GetAll() ([]models.Category, error) {
 
 	}
 
-	return categories, nil
+	return categories, rows.Err()
 }
 
-func (r *CategoryRepository) Delete(id int64) (int64, error) {
-	sqlStatement := `DELETE FROM categories WHERE category_id = $1`
+// Delete moves the category to the trash. Its links to items are kept so
+// that restoring it brings them back.
+func (r *CategoryRepository) Delete(ctx context.Context, id int64) (int64, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	sqlStatement := `UPDATE categories SET deleted_at = now() WHERE category_id = $1 AND deleted_at IS NULL`
 
-	res, execErr := r.db.Exec(sqlStatement, id)
+	tag, execErr := r.db.Exec(ctx, sqlStatement, id)
 
 	if execErr != nil {
 		return 0, execErr
 	}
 
-	rowsAffected, rowsErr := res.RowsAffected()
-
-	if rowsErr != nil {
-		log.Fatalf("Daaaym what's that")
-	}
-
-	return rowsAffected, nil
+	return tag.RowsAffected(), nil
 }
 
-func (r *CategoryRepository) Update(id int64, categoryReq *models.Category) (models.Category, error) {
-	sqlStatement := `UPDATE categories SET category = $2 WHERE category_id = $1 RETURNING *`
+func (r *CategoryRepository) Update(ctx context.Context, id int64, categoryReq *models.Category) (models.Category, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	sqlStatement := `UPDATE categories SET category = $2 WHERE category_id = $1 AND deleted_at IS NULL
+	RETURNING category_id, category`
 
 	var categoryResp models.Category
 
-	err := r.db.QueryRow(sqlStatement, id, categoryReq.Category).Scan(&categoryResp.CategoryID, &categoryResp.Category)
+	err := r.db.QueryRow(ctx, sqlStatement, id, categoryReq.Category).Scan(&categoryResp.CategoryID, &categoryResp.Category)
 
 	return categoryResp, err
 }
 
-func (r *CategoryRepository) GetById(id int64) (models.Category, error) {
+func (r *CategoryRepository) GetById(ctx context.Context, id int64) (models.Category, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
 	var category models.Category
 
-	getCategoryStatement := `SELECT * FROM categories WHERE category_id = $1`
+	getCategoryStatement := `SELECT category_id, category FROM categories WHERE category_id = $1 AND deleted_at IS NULL`
 
-	row := r.db.QueryRow(getCategoryStatement, id)
+	row := r.db.QueryRow(ctx, getCategoryStatement, id)
 
 	err := row.Scan(&category.CategoryID, &category.Category)
 
 	return category, err
 }
 
-func (r *CategoryRepository) GetByName(name string) (models.Category, error) {
+func (r *CategoryRepository) GetByName(ctx context.Context, name string) (models.Category, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
 	var category models.Category
 
-	getCategoryStatement := `SELECT * FROM categories WHERE category = $1`
+	getCategoryStatement := `SELECT category_id, category FROM categories WHERE category = $1 AND deleted_at IS NULL`
 
-	row := r.db.QueryRow(getCategoryStatement, name)
+	row := r.db.QueryRow(ctx, getCategoryStatement, name)
 
 	err := row.Scan(&category.CategoryID, &category.Category)
 
 	return category, err
 }
 
-func (r *CategoryRepository) GetCategoryItems(id int64) ([]models.Item, error) {
+func (r *CategoryRepository) GetCategoryItems(ctx context.Context, id int64) ([]models.Item, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
 	items := make([]models.Item, 0)
 
-	_, getErr := r.GetById(id)
+	_, getErr := r.GetById(ctx, id)
 
 	if getErr != nil {
 		return nil, getErr
 	}
 
-	sqlStatement := `SELECT item_id, item, price FROM items
+	sqlStatement := `SELECT ` + itemColumns + ` FROM items
 	INNER JOIN categories_items
 	USING (item_id)
-	WHERE category_id = $1`
+	WHERE category_id = $1 AND deleted_at IS NULL`
 
-	rows, queryErr := r.db.Query(sqlStatement, id)
+	rows, queryErr := r.db.Query(ctx, sqlStatement, id)
 
 	if queryErr != nil {
 		return nil, queryErr
@@ -142,7 +167,7 @@ func (r *CategoryRepository) GetCategoryItems(id int64) ([]models.Item, error) {
 	for rows.Next() {
 		var item models.Item
 
-		scanErr := rows.Scan(&item.ItemID, &item.Item, &item.Price)
+		scanErr := rows.Scan(itemFields(&item)...)
 
 		if scanErr != nil {
 			return nil, scanErr
@@ -152,5 +177,71 @@ func (r *CategoryRepository) GetCategoryItems(id int64) ([]models.Item, error) {
 
 	}
 
-	return items, nil
+	return items, rows.Err()
+}
+
+func (r *CategoryRepository) GetDeleted(ctx context.Context) ([]models.Category, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	categories := make([]models.Category, 0)
+
+	sqlStatement := `SELECT category_id, category, deleted_at FROM categories
+	WHERE deleted_at IS NOT NULL
+	ORDER BY deleted_at DESC, category_id`
+
+	rows, queryErr := r.db.Query(ctx, sqlStatement)
+
+	if queryErr != nil {
+		return nil, queryErr
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var category models.Category
+
+		scanErr := rows.Scan(&category.CategoryID, &category.Category, &category.DeletedAt)
+
+		if scanErr != nil {
+			return nil, scanErr
+		}
+
+		categories = append(categories, category)
+	}
+
+	return categories, rows.Err()
+}
+
+// Restore takes the category out of the trash. It returns ErrNotFound when
+// the category is not in the trash.
+func (r *CategoryRepository) Restore(ctx context.Context, id int64) (models.Category, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	sqlStatement := `UPDATE categories SET deleted_at = NULL WHERE category_id = $1 AND deleted_at IS NOT NULL
+	RETURNING category_id, category`
+
+	var category models.Category
+
+	err := r.db.QueryRow(ctx, sqlStatement, id).Scan(&category.CategoryID, &category.Category)
+
+	return category, err
+}
+
+// Purge permanently deletes categories trashed before the given time, along
+// with their links.
+func (r *CategoryRepository) Purge(ctx context.Context, before time.Time) (int64, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	sqlStatement := `DELETE FROM categories WHERE deleted_at < $1`
+
+	tag, execErr := r.db.Exec(ctx, sqlStatement, before)
+
+	if execErr != nil {
+		return 0, execErr
+	}
+
+	return tag.RowsAffected(), nil
 }
@@ -0,0 +1,180 @@
+package repositories
+
+import (
+	"context"
+	"time"
+	"training/proj/internal/api/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type CouponRepositoryInterface interface {
+	GetAll(context.Context) ([]models.Coupon, error)
+	GetById(context.Context, int64) (models.Coupon, error)
+	GetByCodes(context.Context, []string) ([]models.Coupon, error)
+	Create(context.Context, *models.Coupon) (models.Coupon, error)
+	Update(context.Context, int64, *models.Coupon) (models.Coupon, error)
+	Delete(context.Context, int64) (int64, error)
+	Redeem(context.Context, int64) (int64, error)
+}
+
+var _ CouponRepositoryInterface = (*CouponRepository)(nil)
+
+type CouponRepository struct {
+	db      DBTX
+	timeout time.Duration
+}
+
+func NewCouponRepository(db DBTX, timeout time.Duration) *CouponRepository {
+	return &CouponRepository{
+		db:      db,
+		timeout: timeout,
+	}
+}
+
+const couponColumns = `coupon_id, code, kind, percent_off, amount_off, COALESCE(currency, ''), buy_quantity, get_quantity,
+	item_ids, category_ids, valid_from, valid_until, max_uses, uses, stackable, created_at, updated_at`
+
+func scanCoupon(row pgx.Row) (models.Coupon, error) {
+	var coupon models.Coupon
+
+	err := row.Scan(&coupon.CouponID, &coupon.Code, &coupon.Kind, &coupon.PercentOff, &coupon.AmountOff, &coupon.Currency,
+		&coupon.BuyQuantity, &coupon.GetQuantity, &coupon.ItemIDs, &coupon.CategoryIDs, &coupon.ValidFrom, &coupon.ValidUntil,
+		&coupon.MaxUses, &coupon.Uses, &coupon.Stackable, &coupon.CreatedAt, &coupon.UpdatedAt)
+
+	return coupon, err
+}
+
+func (r *CouponRepository) queryCoupons(ctx context.Context, sqlStatement string, args ...any) ([]models.Coupon, error) {
+	coupons := make([]models.Coupon, 0)
+
+	rows, queryErr := r.db.Query(ctx, sqlStatement, args...)
+
+	if queryErr != nil {
+		return nil, queryErr
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		coupon, scanErr := scanCoupon(rows)
+
+		if scanErr != nil {
+			return nil, scanErr
+		}
+
+		coupons = append(coupons, coupon)
+	}
+
+	return coupons, rows.Err()
+}
+
+func (r *CouponRepository) GetAll(ctx context.Context) ([]models.Coupon, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	return r.queryCoupons(ctx, `SELECT `+couponColumns+` FROM coupons ORDER BY code`)
+}
+
+func (r *CouponRepository) GetById(ctx context.Context, id int64) (models.Coupon, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	return scanCoupon(r.db.QueryRow(ctx, `SELECT `+couponColumns+` FROM coupons WHERE coupon_id = $1`, id))
+}
+
+// GetByCodes returns the coupons with the given codes, ignoring case. Codes
+// that do not exist are left out.
+func (r *CouponRepository) GetByCodes(ctx context.Context, codes []string) ([]models.Coupon, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	return r.queryCoupons(ctx, `SELECT `+couponColumns+` FROM coupons
+	WHERE upper(code) = ANY (SELECT upper(c) FROM unnest($1::text[]) AS c)`, codes)
+}
+
+func (r *CouponRepository) Create(ctx context.Context, coupon *models.Coupon) (models.Coupon, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	sqlStatement := `INSERT INTO coupons (code, kind, percent_off, amount_off, currency, buy_quantity, get_quantity,
+		item_ids, category_ids, valid_from, valid_until, max_uses, stackable)
+	VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6, $7, $8, $9, $10, $11, $12, $13)
+	RETURNING ` + couponColumns
+
+	return scanCoupon(r.db.QueryRow(ctx, sqlStatement,
+		coupon.Code,
+		coupon.Kind,
+		coupon.PercentOff,
+		coupon.AmountOff,
+		coupon.Currency,
+		coupon.BuyQuantity,
+		coupon.GetQuantity,
+		coupon.ItemIDs,
+		coupon.CategoryIDs,
+		coupon.ValidFrom,
+		coupon.ValidUntil,
+		coupon.MaxUses,
+		coupon.Stackable,
+	))
+}
+
+// Update replaces the coupon's terms. The number of uses is kept.
+func (r *CouponRepository) Update(ctx context.Context, id int64, coupon *models.Coupon) (models.Coupon, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	sqlStatement := `UPDATE coupons SET code = $2, kind = $3, percent_off = $4, amount_off = $5, currency = NULLIF($6, ''),
+		buy_quantity = $7, get_quantity = $8, item_ids = $9, category_ids = $10, valid_from = $11, valid_until = $12,
+		max_uses = $13, stackable = $14, updated_at = now()
+	WHERE coupon_id = $1
+	RETURNING ` + couponColumns
+
+	return scanCoupon(r.db.QueryRow(ctx, sqlStatement,
+		id,
+		coupon.Code,
+		coupon.Kind,
+		coupon.PercentOff,
+		coupon.AmountOff,
+		coupon.Currency,
+		coupon.BuyQuantity,
+		coupon.GetQuantity,
+		coupon.ItemIDs,
+		coupon.CategoryIDs,
+		coupon.ValidFrom,
+		coupon.ValidUntil,
+		coupon.MaxUses,
+		coupon.Stackable,
+	))
+}
+
+func (r *CouponRepository) Delete(ctx context.Context, id int64) (int64, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	tag, execErr := r.db.Exec(ctx, `DELETE FROM coupons WHERE coupon_id = $1`, id)
+
+	if execErr != nil {
+		return 0, execErr
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+// Redeem counts one use of the coupon. It affects no rows if the coupon has
+// reached its usage limit.
+func (r *CouponRepository) Redeem(ctx context.Context, id int64) (int64, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	sqlStatement := `UPDATE coupons SET uses = uses + 1
+	WHERE coupon_id = $1 AND (max_uses IS NULL OR uses < max_uses)`
+
+	tag, execErr := r.db.Exec(ctx, sqlStatement, id)
+
+	if execErr != nil {
+		return 0, execErr
+	}
+
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,84 @@
+package repositories
+
+import (
+	"context"
+	"time"
+	"training/proj/internal/api/models"
+)
+
+type ExchangeRateRepositoryInterface interface {
+	GetAll(context.Context) ([]models.ExchangeRate, error)
+	Upsert(context.Context, *models.ExchangeRate) error
+	Delete(context.Context, string, string) (int64, error)
+}
+
+var _ ExchangeRateRepositoryInterface = (*ExchangeRateRepository)(nil)
+
+type ExchangeRateRepository struct {
+	db      DBTX
+	timeout time.Duration
+}
+
+func NewExchangeRateRepository(db DBTX, timeout time.Duration) *ExchangeRateRepository {
+	return &ExchangeRateRepository{
+		db:      db,
+		timeout: timeout,
+	}
+}
+
+func (r *ExchangeRateRepository) GetAll(ctx context.Context) ([]models.ExchangeRate, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	rates := make([]models.ExchangeRate, 0)
+
+	sqlStatement := `SELECT base, quote, rate::text, source, updated_at FROM exchange_rates ORDER BY base, quote`
+
+	rows, queryErr := r.db.Query(ctx, sqlStatement)
+
+	if queryErr != nil {
+		return nil, queryErr
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var rate models.ExchangeRate
+
+		scanErr := rows.Scan(&rate.Base, &rate.Quote, &rate.Rate, &rate.Source, &rate.UpdatedAt)
+
+		if scanErr != nil {
+			return nil, scanErr
+		}
+
+		rates = append(rates, rate)
+	}
+
+	return rates, rows.Err()
+}
+
+func (r *ExchangeRateRepository) Upsert(ctx context.Context, rate *models.ExchangeRate) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	sqlStatement := `INSERT INTO exchange_rates (base, quote, rate, source) VALUES ($1, $2, $3::numeric, $4)
+	ON CONFLICT (base, quote) DO UPDATE SET rate = EXCLUDED.rate, source = EXCLUDED.source, updated_at = now()
+	RETURNING rate::text, updated_at`
+
+	return r.db.QueryRow(ctx, sqlStatement, rate.Base, rate.Quote, rate.Rate, rate.Source).Scan(&rate.Rate, &rate.UpdatedAt)
+}
+
+func (r *ExchangeRateRepository) Delete(ctx context.Context, base string, quote string) (int64, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	sqlStatement := `DELETE FROM exchange_rates WHERE base = $1 AND quote = $2`
+
+	tag, execErr := r.db.Exec(ctx, sqlStatement, base, quote)
+
+	if execErr != nil {
+		return 0, execErr
+	}
+
+	return tag.RowsAffected(), nil
+}
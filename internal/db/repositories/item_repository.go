@@ -1,37 +1,63 @@
 package repositories
 
 import (
-	"database/sql"
-	"log"
+	"context"
+	"time"
 	"training/proj/internal/api/models"
+
+	"github.com/jackc/pgx/v5"
 )
 
 type ItemRepositoryInterface interface {
-	GetAll() ([]models.Item, error)
-	GetById(int64) (models.Item, error)
-	GetByName(string) (models.Item, error)
-	Create(*models.Item) (models.Item, error)
-	Delete(int64) (int64, error)
-	Update(int64, *models.Item) (models.Item, error)
-	GetItemCategories(int64) ([]models.Category, error)
+	GetAll(context.Context) ([]models.Item, error)
+	GetById(context.Context, int64) (models.Item, error)
+	GetByName(context.Context, string) (models.Item, error)
+	Create(context.Context, *models.Item) (models.Item, error)
+	CreateMany(context.Context, []models.Item) (int64, error)
+	Delete(context.Context, int64) (int64, error)
+	Update(context.Context, int64, *models.Item) (models.Item, error)
+	GetItemCategories(context.Context, int64) ([]models.Category, error)
+	GetBySource(context.Context, string) ([]models.Item, error)
+	SetSource(context.Context, int64, string) error
+	GetDeleted(context.Context) ([]models.Item, error)
+	GetDeletedBySource(context.Context, string) ([]models.Item, error)
+	Restore(context.Context, int64) (models.Item, error)
+	Purge(context.Context, time.Time) (int64, error)
 }
 
+var _ ItemRepositoryInterface = (*ItemRepository)(nil)
+
 type ItemRepository struct {
-	db *sql.DB
+	db      DBTX
+	timeout time.Duration
 }
 
-func NewItemRepository(db *sql.DB) *ItemRepository {
+func NewItemRepository(db DBTX, timeout time.Duration) *ItemRepository {
 	return &ItemRepository{
-		db: db,
+		db:      db,
+		timeout: timeout,
 	}
 }
 
-func (r *ItemRepository) GetAll() ([]models.Item, error) {
+// itemColumns selects the columns read by itemFields. The average rating is
+// derived from the running sum and count kept by ReviewRepository.
+const itemColumns = `item_id, item, price, currency, weight_grams, length_mm, width_mm, height_mm,
+	rating_count, ROUND(rating_sum::numeric / NULLIF(rating_count, 0), 2)::float8`
+
+func itemFields(item *models.Item) []any {
+	return []any{&item.ItemID, &item.Item, &item.Price, &item.Currency, &item.WeightGrams, &item.LengthMM,
+		&item.WidthMM, &item.HeightMM, &item.RatingCount, &item.RatingAvg}
+}
+
+func (r *ItemRepository) GetAll(ctx context.Context) ([]models.Item, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
 	items := make([]models.Item, 0)
 
-	sqlStatement := `SELECT * FROM items`
+	sqlStatement := `SELECT ` + itemColumns + ` FROM items WHERE deleted_at IS NULL`
 
-	rows, queryErr := r.db.Query(sqlStatement)
+	rows, queryErr := r.db.Query(ctx, sqlStatement)
 
 	if queryErr != nil {
 		return nil, queryErr
@@ -42,7 +68,7 @@ func (r *ItemRepository) GetAll() ([]models.Item, error) {
 	for rows.Next() {
 		var item models.Item
 
-		scanErr := rows.Scan(&item.ItemID, &item.Item, &item.Price)
+		scanErr := rows.Scan(itemFields(&item)...)
 
 		if scanErr != nil {
 			return nil, scanErr
@@ -52,75 +78,104 @@ func (r *ItemRepository) GetAll() ([]models.Item, error) {
 
 	}
 
-	return items, nil
+	return items, rows.Err()
 }
 
-func (r *ItemRepository) GetById(id int64) (models.Item, error) {
+func (r *ItemRepository) GetById(ctx context.Context, id int64) (models.Item, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
 	var item models.Item
 
-	sqlStatement := `SELECT * FROM items WHERE item_id = $1`
+	sqlStatement := `SELECT ` + itemColumns + ` FROM items WHERE item_id = $1 AND deleted_at IS NULL`
 
-	row := r.db.QueryRow(sqlStatement, id)
+	row := r.db.QueryRow(ctx, sqlStatement, id)
 
-	err := row.Scan(&item.ItemID, &item.Item, &item.Price)
+	err := row.Scan(itemFields(&item)...)
 
 	return item, err
 }
 
-func (r *ItemRepository) GetByName(name string) (models.Item, error) {
+func (r *ItemRepository) GetByName(ctx context.Context, name string) (models.Item, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
 	var item models.Item
 
-	sqlStatement := `SELECT * FROM items WHERE item = $1`
+	sqlStatement := `SELECT ` + itemColumns + ` FROM items WHERE item = $1 AND deleted_at IS NULL`
 
-	row := r.db.QueryRow(sqlStatement, name)
+	row := r.db.QueryRow(ctx, sqlStatement, name)
 
-	err := row.Scan(&item.ItemID, &item.Item, &item.Price)
+	err := row.Scan(itemFields(&item)...)
 
 	return item, err
 }
 
-func (r *ItemRepository) Create(itemReq *models.Item) (models.Item, error) {
-	sqlStatement := `INSERT INTO items (item, price) VALUES ($1, $2) RETURNING *`
+func (r *ItemRepository) Create(ctx context.Context, itemReq *models.Item) (models.Item, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	sqlStatement := `INSERT INTO items (item, price, currency, weight_grams, length_mm, width_mm, height_mm)
+	VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING ` + itemColumns
 
 	var itemResp models.Item
 
-	err := r.db.QueryRow(sqlStatement, itemReq.Item, itemReq.Price).Scan(&itemResp.ItemID, &itemResp.Item, &itemResp.Price)
+	err := r.db.QueryRow(ctx, sqlStatement, itemReq.Item, itemReq.Price, itemReq.Currency,
+		itemReq.WeightGrams, itemReq.LengthMM, itemReq.WidthMM, itemReq.HeightMM).Scan(itemFields(&itemResp)...)
 
 	return itemResp, err
 }
 
-func (r *ItemRepository) Delete(id int64) (int64, error) {
-	sqlStatement := `DELETE FROM items WHERE item_id = $1`
+func (r *ItemRepository) CreateMany(ctx context.Context, items []models.Item) (int64, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
 
-	res, execErr := r.db.Exec(sqlStatement, id)
+	return r.db.CopyFrom(ctx, pgx.Identifier{"items"}, []string{"item", "price", "currency", "weight_grams", "length_mm", "width_mm", "height_mm"},
+		pgx.CopyFromSlice(len(items), func(i int) ([]any, error) {
+			return []any{items[i].Item, items[i].Price, items[i].Currency,
+				items[i].WeightGrams, items[i].LengthMM, items[i].WidthMM, items[i].HeightMM}, nil
+		}))
+}
 
-	if execErr != nil {
-		return 0, execErr
-	}
+// Delete moves the item to the trash. Its links to categories are kept so
+// that restoring it brings them back.
+func (r *ItemRepository) Delete(ctx context.Context, id int64) (int64, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	sqlStatement := `UPDATE items SET deleted_at = now() WHERE item_id = $1 AND deleted_at IS NULL`
 
-	rowsAffected, rowsErr := res.RowsAffected()
+	tag, execErr := r.db.Exec(ctx, sqlStatement, id)
 
-	if rowsErr != nil {
-		log.Fatalf("Daaaym what's that")
+	if execErr != nil {
+		return 0, execErr
 	}
 
-	return rowsAffected, nil
+	return tag.RowsAffected(), nil
 }
 
-func (r *ItemRepository) Update(id int64, itemReq *models.Item) (models.Item, error) {
-	sqlStatement := `UPDATE items SET item = $2, price = $3 WHERE item_id = $1 RETURNING *`
+func (r *ItemRepository) Update(ctx context.Context, id int64, itemReq *models.Item) (models.Item, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	sqlStatement := `UPDATE items SET item = $2, price = $3, weight_grams = $4, length_mm = $5, width_mm = $6, height_mm = $7 WHERE item_id = $1 AND deleted_at IS NULL
+	RETURNING ` + itemColumns
 
 	var itemResp models.Item
 
-	err := r.db.QueryRow(sqlStatement, id, itemReq.Item, itemReq.Price).Scan(&itemResp.ItemID, &itemResp.Item, &itemResp.Price)
+	err := r.db.QueryRow(ctx, sqlStatement, id, itemReq.Item, itemReq.Price,
+		itemReq.WeightGrams, itemReq.LengthMM, itemReq.WidthMM, itemReq.HeightMM).Scan(itemFields(&itemResp)...)
 
 	return itemResp, err
 }
 
-func (r *ItemRepository) GetItemCategories(id int64) ([]models.Category, error) {
+func (r *ItemRepository) GetItemCategories(ctx context.Context, id int64) ([]models.Category, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
 	categories := make([]models.Category, 0)
 
-	_, getErr := r.GetById(id)
+	_, getErr := r.GetById(ctx, id)
 
 	if getErr != nil {
 		return nil, getErr
@@ -129,9 +184,9 @@ func (r *ItemRepository) GetItemCategories(id int64) ([]models.Category, error)
 	sqlStatement := `SELECT category_id, category FROM categories
 	INNER JOIN categories_items
 	USING (category_id)
-	WHERE item_id = $1`
+	WHERE item_id = $1 AND deleted_at IS NULL`
 
-	rows, queryErr := r.db.Query(sqlStatement, id)
+	rows, queryErr := r.db.Query(ctx, sqlStatement, id)
 
 	if queryErr != nil {
 		return nil, queryErr
@@ -152,5 +207,156 @@ func (r *ItemRepository) GetItemCategories(id int64) ([]models.Category, error)
 
 	}
 
-	return categories, nil
+	return categories, rows.Err()
+}
+
+func (r *ItemRepository) GetBySource(ctx context.Context, source string) ([]models.Item, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	items := make([]models.Item, 0)
+
+	sqlStatement := `SELECT ` + itemColumns + ` FROM items WHERE import_source = $1 AND deleted_at IS NULL ORDER BY item_id`
+
+	rows, queryErr := r.db.Query(ctx, sqlStatement, source)
+
+	if queryErr != nil {
+		return nil, queryErr
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var item models.Item
+
+		scanErr := rows.Scan(itemFields(&item)...)
+
+		if scanErr != nil {
+			return nil, scanErr
+		}
+
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+func (r *ItemRepository) SetSource(ctx context.Context, id int64, source string) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	sqlStatement := `UPDATE items SET import_source = NULLIF($2, '') WHERE item_id = $1 AND deleted_at IS NULL`
+
+	tag, execErr := r.db.Exec(ctx, sqlStatement, id, source)
+
+	if execErr != nil {
+		return execErr
+	}
+
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *ItemRepository) GetDeleted(ctx context.Context) ([]models.Item, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	items := make([]models.Item, 0)
+
+	sqlStatement := `SELECT ` + itemColumns + `, deleted_at FROM items
+	WHERE deleted_at IS NOT NULL
+	ORDER BY deleted_at DESC, item_id`
+
+	rows, queryErr := r.db.Query(ctx, sqlStatement)
+
+	if queryErr != nil {
+		return nil, queryErr
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var item models.Item
+
+		scanErr := rows.Scan(append(itemFields(&item), &item.DeletedAt)...)
+
+		if scanErr != nil {
+			return nil, scanErr
+		}
+
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+// GetDeletedBySource returns the items imported from the source that are in
+// the trash.
+func (r *ItemRepository) GetDeletedBySource(ctx context.Context, source string) ([]models.Item, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	items := make([]models.Item, 0)
+
+	sqlStatement := `SELECT ` + itemColumns + `, deleted_at FROM items
+	WHERE import_source = $1 AND deleted_at IS NOT NULL
+	ORDER BY item_id`
+
+	rows, queryErr := r.db.Query(ctx, sqlStatement, source)
+
+	if queryErr != nil {
+		return nil, queryErr
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var item models.Item
+
+		scanErr := rows.Scan(append(itemFields(&item), &item.DeletedAt)...)
+
+		if scanErr != nil {
+			return nil, scanErr
+		}
+
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+// Restore takes the item out of the trash. It returns ErrNotFound when the
+// item is not in the trash.
+func (r *ItemRepository) Restore(ctx context.Context, id int64) (models.Item, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	sqlStatement := `UPDATE items SET deleted_at = NULL WHERE item_id = $1 AND deleted_at IS NOT NULL
+	RETURNING ` + itemColumns
+
+	var item models.Item
+
+	err := r.db.QueryRow(ctx, sqlStatement, id).Scan(itemFields(&item)...)
+
+	return item, err
+}
+
+// Purge permanently deletes items trashed before the given time, along with
+// their links.
+func (r *ItemRepository) Purge(ctx context.Context, before time.Time) (int64, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	sqlStatement := `DELETE FROM items WHERE deleted_at < $1`
+
+	tag, execErr := r.db.Exec(ctx, sqlStatement, before)
+
+	if execErr != nil {
+		return 0, execErr
+	}
+
+	return tag.RowsAffected(), nil
 }
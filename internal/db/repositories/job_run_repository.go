@@ -0,0 +1,89 @@
+package repositories
+
+import (
+	"context"
+	"time"
+	"training/proj/internal/api/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type JobRunRepositoryInterface interface {
+	Create(context.Context, string, string) (models.JobRun, error)
+	Finish(context.Context, int64, string, []byte, string) (models.JobRun, error)
+	GetByJob(context.Context, string, int) ([]models.JobRun, error)
+}
+
+var _ JobRunRepositoryInterface = (*JobRunRepository)(nil)
+
+type JobRunRepository struct {
+	db      DBTX
+	timeout time.Duration
+}
+
+func NewJobRunRepository(db DBTX, timeout time.Duration) *JobRunRepository {
+	return &JobRunRepository{
+		db:      db,
+		timeout: timeout,
+	}
+}
+
+const jobRunColumns = `job_run_id, job, triggered_by, status, started_at, finished_at, stats, error`
+
+func scanJobRun(row pgx.Row) (models.JobRun, error) {
+	var run models.JobRun
+	var stats []byte
+
+	err := row.Scan(&run.JobRunID, &run.Job, &run.TriggeredBy, &run.Status, &run.StartedAt, &run.FinishedAt, &stats, &run.Error)
+	run.Stats = stats
+
+	return run, err
+}
+
+func (r *JobRunRepository) Create(ctx context.Context, job string, triggeredBy string) (models.JobRun, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	sqlStatement := `INSERT INTO job_runs (job, triggered_by, status) VALUES ($1, $2, 'running') RETURNING ` + jobRunColumns
+
+	return scanJobRun(r.db.QueryRow(ctx, sqlStatement, job, triggeredBy))
+}
+
+func (r *JobRunRepository) Finish(ctx context.Context, id int64, status string, stats []byte, errMsg string) (models.JobRun, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	sqlStatement := `UPDATE job_runs SET status = $2, stats = $3, error = $4, finished_at = now()
+	WHERE job_run_id = $1 RETURNING ` + jobRunColumns
+
+	return scanJobRun(r.db.QueryRow(ctx, sqlStatement, id, status, stats, errMsg))
+}
+
+func (r *JobRunRepository) GetByJob(ctx context.Context, job string, limit int) ([]models.JobRun, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	runs := make([]models.JobRun, 0)
+
+	sqlStatement := `SELECT ` + jobRunColumns + ` FROM job_runs WHERE job = $1 ORDER BY started_at DESC, job_run_id DESC LIMIT $2`
+
+	rows, queryErr := r.db.Query(ctx, sqlStatement, job, limit)
+
+	if queryErr != nil {
+		return nil, queryErr
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		run, scanErr := scanJobRun(rows)
+
+		if scanErr != nil {
+			return nil, scanErr
+		}
+
+		runs = append(runs, run)
+	}
+
+	return runs, rows.Err()
+}
@@ -0,0 +1,205 @@
+package repositories
+
+import (
+	"context"
+	"time"
+	"training/proj/internal/api/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type PriceRepositoryInterface interface {
+	Create(context.Context, *models.PriceChange) error
+	GetByItem(context.Context, int64, *time.Time, *time.Time) ([]models.PriceChange, error)
+	GetScheduled(context.Context, int64) ([]models.PriceChange, error)
+	GetDue(context.Context, time.Time) ([]models.PriceChange, error)
+	MarkApplied(context.Context, int64, time.Time) error
+	CancelScheduled(context.Context, int64, int64) (int64, error)
+	CancelDue(context.Context, int64, time.Time) (int64, error)
+	LowestSince(context.Context, int64, time.Time) (int64, error)
+}
+
+var _ PriceRepositoryInterface = (*PriceRepository)(nil)
+
+type PriceRepository struct {
+	db      DBTX
+	timeout time.Duration
+}
+
+func NewPriceRepository(db DBTX, timeout time.Duration) *PriceRepository {
+	return &PriceRepository{
+		db:      db,
+		timeout: timeout,
+	}
+}
+
+const priceColumns = `price_id, item_id, price, effective_at, applied_at, actor_id, actor, created_at`
+
+func scanPriceChange(row pgx.Row) (models.PriceChange, error) {
+	var change models.PriceChange
+
+	err := row.Scan(&change.PriceID, &change.ItemID, &change.Price, &change.EffectiveAt, &change.AppliedAt,
+		&change.ActorID, &change.Actor, &change.CreatedAt)
+
+	return change, err
+}
+
+func (r *PriceRepository) queryPriceChanges(ctx context.Context, sqlStatement string, args ...any) ([]models.PriceChange, error) {
+	changes := make([]models.PriceChange, 0)
+
+	rows, queryErr := r.db.Query(ctx, sqlStatement, args...)
+
+	if queryErr != nil {
+		return nil, queryErr
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		change, scanErr := scanPriceChange(rows)
+
+		if scanErr != nil {
+			return nil, scanErr
+		}
+
+		changes = append(changes, change)
+	}
+
+	return changes, rows.Err()
+}
+
+func (r *PriceRepository) Create(ctx context.Context, change *models.PriceChange) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	sqlStatement := `INSERT INTO price_history (item_id, price, effective_at, applied_at, actor_id, actor)
+	VALUES ($1, $2, $3, $4, $5, $6) RETURNING price_id, created_at`
+
+	return r.db.QueryRow(ctx, sqlStatement,
+		change.ItemID,
+		change.Price,
+		change.EffectiveAt,
+		change.AppliedAt,
+		change.ActorID,
+		change.Actor).Scan(&change.PriceID, &change.CreatedAt)
+}
+
+// GetByItem returns the applied price changes of an item, oldest first,
+// optionally limited to those effective in [from, to).
+func (r *PriceRepository) GetByItem(ctx context.Context, itemId int64, from *time.Time, to *time.Time) ([]models.PriceChange, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	sqlStatement := `SELECT ` + priceColumns + ` FROM price_history
+	WHERE item_id = $1 AND applied_at IS NOT NULL
+	AND ($2::timestamptz IS NULL OR effective_at >= $2)
+	AND ($3::timestamptz IS NULL OR effective_at < $3)
+	ORDER BY effective_at, price_id`
+
+	return r.queryPriceChanges(ctx, sqlStatement, itemId, from, to)
+}
+
+func (r *PriceRepository) GetScheduled(ctx context.Context, itemId int64) ([]models.PriceChange, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	sqlStatement := `SELECT ` + priceColumns + ` FROM price_history
+	WHERE item_id = $1 AND applied_at IS NULL
+	ORDER BY effective_at, price_id`
+
+	return r.queryPriceChanges(ctx, sqlStatement, itemId)
+}
+
+// GetDue locks and returns the scheduled price changes that have become
+// effective. Rows locked by another transaction are skipped, so concurrent
+// schedulers do not apply the same change twice.
+func (r *PriceRepository) GetDue(ctx context.Context, now time.Time) ([]models.PriceChange, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	sqlStatement := `SELECT ` + priceColumns + ` FROM price_history
+	WHERE applied_at IS NULL AND effective_at <= $1
+	ORDER BY effective_at, price_id
+	FOR UPDATE SKIP LOCKED`
+
+	return r.queryPriceChanges(ctx, sqlStatement, now)
+}
+
+func (r *PriceRepository) MarkApplied(ctx context.Context, id int64, at time.Time) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	sqlStatement := `UPDATE price_history SET applied_at = $2 WHERE price_id = $1 AND applied_at IS NULL`
+
+	tag, execErr := r.db.Exec(ctx, sqlStatement, id, at)
+
+	if execErr != nil {
+		return execErr
+	}
+
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *PriceRepository) CancelScheduled(ctx context.Context, itemId int64, id int64) (int64, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	sqlStatement := `DELETE FROM price_history WHERE price_id = $1 AND item_id = $2 AND applied_at IS NULL`
+
+	tag, execErr := r.db.Exec(ctx, sqlStatement, id, itemId)
+
+	if execErr != nil {
+		return 0, execErr
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+// CancelDue deletes the item's pending changes that took effect by now.
+func (r *PriceRepository) CancelDue(ctx context.Context, itemId int64, now time.Time) (int64, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	sqlStatement := `DELETE FROM price_history WHERE item_id = $1 AND applied_at IS NULL AND effective_at <= $2`
+
+	tag, execErr := r.db.Exec(ctx, sqlStatement, itemId, now)
+
+	if execErr != nil {
+		return 0, execErr
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+// LowestSince returns the lowest price the item had at any point since the
+// given time, including the price that was already in effect then. It
+// returns ErrNotFound when the item has no price history.
+func (r *PriceRepository) LowestSince(ctx context.Context, itemId int64, since time.Time) (int64, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	sqlStatement := `SELECT MIN(price) FROM price_history
+	WHERE item_id = $1 AND applied_at IS NOT NULL
+	AND (effective_at >= $2 OR price_id = (
+		SELECT price_id FROM price_history
+		WHERE item_id = $1 AND applied_at IS NOT NULL AND effective_at < $2
+		ORDER BY effective_at DESC, price_id DESC
+		LIMIT 1
+	))`
+
+	var lowest *int64
+
+	if err := r.db.QueryRow(ctx, sqlStatement, itemId, since).Scan(&lowest); err != nil {
+		return 0, err
+	}
+
+	if lowest == nil {
+		return 0, ErrNotFound
+	}
+
+	return *lowest, nil
+}
@@ -1,19 +1,62 @@
 package repositories
 
-import "database/sql"
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var ErrNotFound = pgx.ErrNoRows
 
 type Repositories struct {
-	CategoryRepository     *CategoryRepository
-	ItemRepository         *ItemRepository
-	UserRepository         *UserRepository
-	CategoryItemRepository *CategoryItemRepository
+	CategoryRepository     CategoryRepositoryInterface
+	ItemRepository         ItemRepositoryInterface
+	UserRepository         UserRepositoryInterface
+	CategoryItemRepository CategoryItemRepositoryInterface
+	JobRunRepository       JobRunRepositoryInterface
+	AuditRepository        AuditRepositoryInterface
+	RevisionRepository     RevisionRepositoryInterface
+	PriceRepository        PriceRepositoryInterface
+	ExchangeRateRepository ExchangeRateRepositoryInterface
+	CouponRepository       CouponRepositoryInterface
+	SaleRepository         SaleRepositoryInterface
+	TaxRepository          TaxRepositoryInterface
+	ShippingRepository     ShippingRepositoryInterface
+	ReviewRepository       ReviewRepositoryInterface
+	TxManager              TxManager
+}
+
+func NewRepositories(pool *pgxpool.Pool, queryTimeout time.Duration) *Repositories {
+	r := newRepositories(pool, queryTimeout)
+	r.TxManager = NewTxManager(pool, queryTimeout)
+	return r
 }
 
-func NewRepositories(db *sql.DB) *Repositories {
+func newRepositories(db DBTX, queryTimeout time.Duration) *Repositories {
 	return &Repositories{
-		CategoryRepository:     NewCategoryRepository(db),
-		ItemRepository:         NewItemRepository(db),
-		UserRepository:         NewUserRepository(db),
-		CategoryItemRepository: NewCategoryItemRepository(db),
+		CategoryRepository:     NewCategoryRepository(db, queryTimeout),
+		ItemRepository:         NewItemRepository(db, queryTimeout),
+		UserRepository:         NewUserRepository(db, queryTimeout),
+		CategoryItemRepository: NewCategoryItemRepository(db, queryTimeout),
+		JobRunRepository:       NewJobRunRepository(db, queryTimeout),
+		AuditRepository:        NewAuditRepository(db, queryTimeout),
+		RevisionRepository:     NewRevisionRepository(db, queryTimeout),
+		PriceRepository:        NewPriceRepository(db, queryTimeout),
+		ExchangeRateRepository: NewExchangeRateRepository(db, queryTimeout),
+		CouponRepository:       NewCouponRepository(db, queryTimeout),
+		SaleRepository:         NewSaleRepository(db, queryTimeout),
+		TaxRepository:          NewTaxRepository(db, queryTimeout),
+		ShippingRepository:     NewShippingRepository(db, queryTimeout),
+		ReviewRepository:       NewReviewRepository(db, queryTimeout),
 	}
 }
+
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+
+	return context.WithTimeout(ctx, timeout)
+}
@@ -0,0 +1,218 @@
+package repositories
+
+import (
+	"context"
+	"time"
+	"training/proj/internal/api/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	ReviewsByHelpful = "helpful"
+	ReviewsByNewest  = "newest"
+)
+
+// ReviewFilter selects a page of an item's reviews. Sort is one of the
+// ReviewsBy constants.
+type ReviewFilter struct {
+	Sort   string
+	Limit  int
+	Offset int
+}
+
+type ReviewRepositoryInterface interface {
+	GetForItem(context.Context, int64, ReviewFilter) ([]models.Review, int64, error)
+	GetById(context.Context, int64) (models.Review, error)
+	Create(context.Context, *models.Review) (models.Review, error)
+	Update(context.Context, int64, *models.Review) (models.Review, error)
+	Delete(context.Context, int64) (int64, error)
+	AddVote(context.Context, int64, int64) (int64, error)
+	RemoveVote(context.Context, int64, int64) (int64, error)
+}
+
+var _ ReviewRepositoryInterface = (*ReviewRepository)(nil)
+
+// ReviewRepository keeps the rating count and sum of items up to date as
+// reviews are written, in the same statement as the write.
+type ReviewRepository struct {
+	db      DBTX
+	timeout time.Duration
+}
+
+func NewReviewRepository(db DBTX, timeout time.Duration) *ReviewRepository {
+	return &ReviewRepository{
+		db:      db,
+		timeout: timeout,
+	}
+}
+
+const reviewColumns = `review_id, item_id, user_id, rating, title, body, verified_purchase, helpful_count, created_at, updated_at`
+
+func scanReview(row pgx.Row) (models.Review, error) {
+	var review models.Review
+
+	err := row.Scan(&review.ReviewID, &review.ItemID, &review.UserID, &review.Rating, &review.Title, &review.Body,
+		&review.VerifiedPurchase, &review.HelpfulCount, &review.CreatedAt, &review.UpdatedAt)
+
+	return review, err
+}
+
+var reviewOrder = map[string]string{
+	ReviewsByHelpful: `helpful_count DESC, review_id`,
+	ReviewsByNewest:  `created_at DESC, review_id DESC`,
+}
+
+// GetForItem returns a page of the item's reviews and the total number of
+// reviews the item has.
+func (r *ReviewRepository) GetForItem(ctx context.Context, itemId int64, filter ReviewFilter) ([]models.Review, int64, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	order, ok := reviewOrder[filter.Sort]
+	if !ok {
+		order = reviewOrder[ReviewsByHelpful]
+	}
+
+	var total int64
+
+	if err := r.db.QueryRow(ctx, `SELECT count(*) FROM reviews WHERE item_id = $1`, itemId).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	reviews := make([]models.Review, 0)
+
+	sqlStatement := `SELECT ` + reviewColumns + ` FROM reviews WHERE item_id = $1 ORDER BY ` + order + ` LIMIT $2 OFFSET $3`
+
+	rows, queryErr := r.db.Query(ctx, sqlStatement, itemId, filter.Limit, filter.Offset)
+
+	if queryErr != nil {
+		return nil, 0, queryErr
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		review, scanErr := scanReview(rows)
+
+		if scanErr != nil {
+			return nil, 0, scanErr
+		}
+
+		reviews = append(reviews, review)
+	}
+
+	return reviews, total, rows.Err()
+}
+
+func (r *ReviewRepository) GetById(ctx context.Context, id int64) (models.Review, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	return scanReview(r.db.QueryRow(ctx, `SELECT `+reviewColumns+` FROM reviews WHERE review_id = $1`, id))
+}
+
+func (r *ReviewRepository) Create(ctx context.Context, review *models.Review) (models.Review, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	sqlStatement := `WITH inserted AS (
+		INSERT INTO reviews (item_id, user_id, rating, title, body, verified_purchase)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING ` + reviewColumns + `
+	), rated AS (
+		UPDATE items SET rating_count = rating_count + 1, rating_sum = rating_sum + inserted.rating
+		FROM inserted
+		WHERE items.item_id = inserted.item_id
+	)
+	SELECT ` + reviewColumns + ` FROM inserted`
+
+	return scanReview(r.db.QueryRow(ctx, sqlStatement,
+		review.ItemID, review.UserID, review.Rating, review.Title, review.Body, review.VerifiedPurchase))
+}
+
+// Update changes the rating, title and body of a review.
+func (r *ReviewRepository) Update(ctx context.Context, id int64, review *models.Review) (models.Review, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	sqlStatement := `WITH previous AS (
+		SELECT rating FROM reviews WHERE review_id = $1 FOR UPDATE
+	), updated AS (
+		UPDATE reviews SET rating = $2, title = $3, body = $4, updated_at = now()
+		WHERE review_id = $1
+		RETURNING ` + reviewColumns + `
+	), rated AS (
+		UPDATE items SET rating_sum = rating_sum + updated.rating - previous.rating
+		FROM updated, previous
+		WHERE items.item_id = updated.item_id
+	)
+	SELECT ` + reviewColumns + ` FROM updated`
+
+	return scanReview(r.db.QueryRow(ctx, sqlStatement, id, review.Rating, review.Title, review.Body))
+}
+
+func (r *ReviewRepository) Delete(ctx context.Context, id int64) (int64, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	sqlStatement := `WITH deleted AS (
+		DELETE FROM reviews WHERE review_id = $1 RETURNING item_id, rating
+	), rated AS (
+		UPDATE items SET rating_count = rating_count - 1, rating_sum = rating_sum - deleted.rating
+		FROM deleted
+		WHERE items.item_id = deleted.item_id
+	)
+	SELECT count(*) FROM deleted`
+
+	var deleted int64
+
+	err := r.db.QueryRow(ctx, sqlStatement, id).Scan(&deleted)
+
+	return deleted, err
+}
+
+// AddVote marks a review as helpful to a user. It returns 0 if the user
+// had already done so.
+func (r *ReviewRepository) AddVote(ctx context.Context, reviewId int64, userId int64) (int64, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	sqlStatement := `WITH voted AS (
+		INSERT INTO review_votes (review_id, user_id) VALUES ($1, $2)
+		ON CONFLICT DO NOTHING
+		RETURNING review_id
+	)
+	UPDATE reviews SET helpful_count = helpful_count + 1
+	WHERE review_id IN (SELECT review_id FROM voted)`
+
+	tag, execErr := r.db.Exec(ctx, sqlStatement, reviewId, userId)
+
+	if execErr != nil {
+		return 0, execErr
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+// RemoveVote withdraws a user's helpful vote. It returns 0 if there was
+// none.
+func (r *ReviewRepository) RemoveVote(ctx context.Context, reviewId int64, userId int64) (int64, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	sqlStatement := `WITH withdrawn AS (
+		DELETE FROM review_votes WHERE review_id = $1 AND user_id = $2
+		RETURNING review_id
+	)
+	UPDATE reviews SET helpful_count = helpful_count - 1
+	WHERE review_id IN (SELECT review_id FROM withdrawn)`
+
+	tag, execErr := r.db.Exec(ctx, sqlStatement, reviewId, userId)
+
+	if execErr != nil {
+		return 0, execErr
+	}
+
+	return tag.RowsAffected(), nil
+}
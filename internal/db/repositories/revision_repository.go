@@ -0,0 +1,102 @@
+package repositories
+
+import (
+	"context"
+	"time"
+	"training/proj/internal/api/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type RevisionRepositoryInterface interface {
+	Create(context.Context, *models.Revision) error
+	GetByEntity(context.Context, string, int64) ([]models.Revision, error)
+	Get(context.Context, string, int64, int64) (models.Revision, error)
+}
+
+var _ RevisionRepositoryInterface = (*RevisionRepository)(nil)
+
+type RevisionRepository struct {
+	db      DBTX
+	timeout time.Duration
+}
+
+func NewRevisionRepository(db DBTX, timeout time.Duration) *RevisionRepository {
+	return &RevisionRepository{
+		db:      db,
+		timeout: timeout,
+	}
+}
+
+const revisionColumns = `revision_id, entity_type, entity_id, revision, data, actor_id, actor, created_at`
+
+func scanRevision(row pgx.Row) (models.Revision, error) {
+	var rev models.Revision
+	var data []byte
+
+	err := row.Scan(&rev.RevisionID, &rev.EntityType, &rev.EntityID, &rev.Revision, &data, &rev.ActorID, &rev.Actor, &rev.CreatedAt)
+	rev.Data = data
+
+	return rev, err
+}
+
+// Create stores the next revision of the entity and fills in its number.
+// Call it after updating the entity in the same transaction, so that the row
+// lock taken by the update serializes concurrent revisions.
+func (r *RevisionRepository) Create(ctx context.Context, rev *models.Revision) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	sqlStatement := `INSERT INTO revisions (entity_type, entity_id, revision, data, actor_id, actor)
+	SELECT $1, $2, COALESCE(MAX(revision), 0) + 1, $3, $4, $5
+	FROM revisions WHERE entity_type = $1 AND entity_id = $2
+	RETURNING revision_id, revision, created_at`
+
+	return r.db.QueryRow(ctx, sqlStatement,
+		rev.EntityType,
+		rev.EntityID,
+		[]byte(rev.Data),
+		rev.ActorID,
+		rev.Actor).Scan(&rev.RevisionID, &rev.Revision, &rev.CreatedAt)
+}
+
+func (r *RevisionRepository) GetByEntity(ctx context.Context, entityType string, entityId int64) ([]models.Revision, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	revisions := make([]models.Revision, 0)
+
+	sqlStatement := `SELECT ` + revisionColumns + ` FROM revisions
+	WHERE entity_type = $1 AND entity_id = $2
+	ORDER BY revision DESC`
+
+	rows, queryErr := r.db.Query(ctx, sqlStatement, entityType, entityId)
+
+	if queryErr != nil {
+		return nil, queryErr
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		rev, scanErr := scanRevision(rows)
+
+		if scanErr != nil {
+			return nil, scanErr
+		}
+
+		revisions = append(revisions, rev)
+	}
+
+	return revisions, rows.Err()
+}
+
+func (r *RevisionRepository) Get(ctx context.Context, entityType string, entityId int64, revision int64) (models.Revision, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	sqlStatement := `SELECT ` + revisionColumns + ` FROM revisions
+	WHERE entity_type = $1 AND entity_id = $2 AND revision = $3`
+
+	return scanRevision(r.db.QueryRow(ctx, sqlStatement, entityType, entityId, revision))
+}
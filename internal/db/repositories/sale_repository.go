@@ -0,0 +1,156 @@
+package repositories
+
+import (
+	"context"
+	"time"
+	"training/proj/internal/api/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type SaleRepositoryInterface interface {
+	GetAll(context.Context) ([]models.Sale, error)
+	GetById(context.Context, int64) (models.Sale, error)
+	Create(context.Context, *models.Sale) (models.Sale, error)
+	Update(context.Context, int64, *models.Sale) (models.Sale, error)
+	Delete(context.Context, int64) (int64, error)
+	ActiveForItems(context.Context, []int64, time.Time) (map[int64]models.Sale, error)
+}
+
+var _ SaleRepositoryInterface = (*SaleRepository)(nil)
+
+type SaleRepository struct {
+	db      DBTX
+	timeout time.Duration
+}
+
+func NewSaleRepository(db DBTX, timeout time.Duration) *SaleRepository {
+	return &SaleRepository{
+		db:      db,
+		timeout: timeout,
+	}
+}
+
+const saleColumns = `sale_id, name, category_id, percent_off, priority, starts_at, ends_at, created_at, updated_at`
+
+func scanSale(row pgx.Row) (models.Sale, error) {
+	var sale models.Sale
+
+	err := row.Scan(&sale.SaleID, &sale.Name, &sale.CategoryID, &sale.PercentOff, &sale.Priority,
+		&sale.StartsAt, &sale.EndsAt, &sale.CreatedAt, &sale.UpdatedAt)
+
+	return sale, err
+}
+
+func (r *SaleRepository) GetAll(ctx context.Context) ([]models.Sale, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	sales := make([]models.Sale, 0)
+
+	rows, queryErr := r.db.Query(ctx, `SELECT `+saleColumns+` FROM sales ORDER BY starts_at, sale_id`)
+
+	if queryErr != nil {
+		return nil, queryErr
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		sale, scanErr := scanSale(rows)
+
+		if scanErr != nil {
+			return nil, scanErr
+		}
+
+		sales = append(sales, sale)
+	}
+
+	return sales, rows.Err()
+}
+
+func (r *SaleRepository) GetById(ctx context.Context, id int64) (models.Sale, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	return scanSale(r.db.QueryRow(ctx, `SELECT `+saleColumns+` FROM sales WHERE sale_id = $1`, id))
+}
+
+func (r *SaleRepository) Create(ctx context.Context, sale *models.Sale) (models.Sale, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	sqlStatement := `INSERT INTO sales (name, category_id, percent_off, priority, starts_at, ends_at)
+	VALUES ($1, $2, $3, $4, $5, $6) RETURNING ` + saleColumns
+
+	return scanSale(r.db.QueryRow(ctx, sqlStatement,
+		sale.Name, sale.CategoryID, sale.PercentOff, sale.Priority, sale.StartsAt, sale.EndsAt))
+}
+
+func (r *SaleRepository) Update(ctx context.Context, id int64, sale *models.Sale) (models.Sale, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	sqlStatement := `UPDATE sales SET name = $2, category_id = $3, percent_off = $4, priority = $5,
+		starts_at = $6, ends_at = $7, updated_at = now()
+	WHERE sale_id = $1
+	RETURNING ` + saleColumns
+
+	return scanSale(r.db.QueryRow(ctx, sqlStatement,
+		id, sale.Name, sale.CategoryID, sale.PercentOff, sale.Priority, sale.StartsAt, sale.EndsAt))
+}
+
+func (r *SaleRepository) Delete(ctx context.Context, id int64) (int64, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	tag, execErr := r.db.Exec(ctx, `DELETE FROM sales WHERE sale_id = $1`, id)
+
+	if execErr != nil {
+		return 0, execErr
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+// ActiveForItems returns the sale that applies to each of the items at the
+// given time, keyed by item ID. Items without a sale are left out. Sales
+// of categories in the trash do not apply.
+func (r *SaleRepository) ActiveForItems(ctx context.Context, itemIds []int64, at time.Time) (map[int64]models.Sale, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	sales := make(map[int64]models.Sale)
+
+	sqlStatement := `SELECT DISTINCT ON (ci.item_id) ci.item_id, s.sale_id, s.name, s.category_id, s.percent_off, s.priority,
+		s.starts_at, s.ends_at, s.created_at, s.updated_at
+	FROM sales s
+	INNER JOIN categories_items ci USING (category_id)
+	INNER JOIN categories c USING (category_id)
+	WHERE ci.item_id = ANY ($1) AND c.deleted_at IS NULL AND s.starts_at <= $2 AND s.ends_at > $2
+	ORDER BY ci.item_id, s.priority DESC, s.percent_off DESC, s.sale_id`
+
+	rows, queryErr := r.db.Query(ctx, sqlStatement, itemIds, at)
+
+	if queryErr != nil {
+		return nil, queryErr
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var itemId int64
+		var sale models.Sale
+
+		scanErr := rows.Scan(&itemId, &sale.SaleID, &sale.Name, &sale.CategoryID, &sale.PercentOff, &sale.Priority,
+			&sale.StartsAt, &sale.EndsAt, &sale.CreatedAt, &sale.UpdatedAt)
+
+		if scanErr != nil {
+			return nil, scanErr
+		}
+
+		sales[itemId] = sale
+	}
+
+	return sales, rows.Err()
+}
@@ -0,0 +1,229 @@
+package repositories
+
+import (
+	"context"
+	"time"
+	"training/proj/internal/api/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type ShippingRepositoryInterface interface {
+	GetAll(context.Context) ([]models.ShippingMethod, error)
+	GetById(context.Context, int64) (models.ShippingMethod, error)
+	Create(context.Context, *models.ShippingMethod) (models.ShippingMethod, error)
+	Update(context.Context, int64, *models.ShippingMethod) (models.ShippingMethod, error)
+	Delete(context.Context, int64) (int64, error)
+	ForCountry(context.Context, string) ([]models.ShippingMethod, error)
+}
+
+var _ ShippingRepositoryInterface = (*ShippingRepository)(nil)
+
+// ShippingRepository stores methods together with their rules. Create and
+// Update write several rows and should run in a transaction.
+type ShippingRepository struct {
+	db      DBTX
+	timeout time.Duration
+}
+
+func NewShippingRepository(db DBTX, timeout time.Duration) *ShippingRepository {
+	return &ShippingRepository{
+		db:      db,
+		timeout: timeout,
+	}
+}
+
+const shippingMethodColumns = `method_id, name, kind, currency, active, created_at, updated_at`
+
+const shippingRuleColumns = `rule_id, method_id, country, postal_prefix, rate, per_kg, free_over, max_weight_grams, min_days, max_days`
+
+func scanShippingMethod(row pgx.Row) (models.ShippingMethod, error) {
+	var method models.ShippingMethod
+
+	err := row.Scan(&method.MethodID, &method.Name, &method.Kind, &method.Currency, &method.Active,
+		&method.CreatedAt, &method.UpdatedAt)
+
+	method.Rules = make([]models.ShippingRule, 0)
+
+	return method, err
+}
+
+func (r *ShippingRepository) GetAll(ctx context.Context) ([]models.ShippingMethod, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	return r.query(ctx, `SELECT `+shippingMethodColumns+` FROM shipping_methods ORDER BY method_id`,
+		`SELECT `+shippingRuleColumns+` FROM shipping_rules ORDER BY method_id, country, postal_prefix`)
+}
+
+func (r *ShippingRepository) GetById(ctx context.Context, id int64) (models.ShippingMethod, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	return r.get(ctx, id)
+}
+
+// ForCountry returns the active methods that have a rule for the country,
+// with only the rules for that country.
+func (r *ShippingRepository) ForCountry(ctx context.Context, country string) ([]models.ShippingMethod, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	methodsStatement := `SELECT ` + shippingMethodColumns + ` FROM shipping_methods m
+	WHERE active AND EXISTS (SELECT 1 FROM shipping_rules sr WHERE sr.method_id = m.method_id AND sr.country = $1)
+	ORDER BY method_id`
+
+	rulesStatement := `SELECT ` + shippingRuleColumns + ` FROM shipping_rules
+	WHERE country = $1
+	ORDER BY method_id, postal_prefix`
+
+	return r.query(ctx, methodsStatement, rulesStatement, country)
+}
+
+func (r *ShippingRepository) Create(ctx context.Context, method *models.ShippingMethod) (models.ShippingMethod, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	sqlStatement := `INSERT INTO shipping_methods (name, kind, currency, active) VALUES ($1, $2, $3, $4)
+	RETURNING method_id`
+
+	var id int64
+
+	err := r.db.QueryRow(ctx, sqlStatement, method.Name, method.Kind, method.Currency, method.Active).Scan(&id)
+	if err != nil {
+		return models.ShippingMethod{}, err
+	}
+
+	if err := r.insertRules(ctx, id, method.Rules); err != nil {
+		return models.ShippingMethod{}, err
+	}
+
+	return r.get(ctx, id)
+}
+
+// Update replaces the method and all of its rules.
+func (r *ShippingRepository) Update(ctx context.Context, id int64, method *models.ShippingMethod) (models.ShippingMethod, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	sqlStatement := `UPDATE shipping_methods SET name = $2, kind = $3, currency = $4, active = $5, updated_at = now()
+	WHERE method_id = $1`
+
+	tag, execErr := r.db.Exec(ctx, sqlStatement, id, method.Name, method.Kind, method.Currency, method.Active)
+	if execErr != nil {
+		return models.ShippingMethod{}, execErr
+	}
+
+	if tag.RowsAffected() == 0 {
+		return models.ShippingMethod{}, ErrNotFound
+	}
+
+	if _, err := r.db.Exec(ctx, `DELETE FROM shipping_rules WHERE method_id = $1`, id); err != nil {
+		return models.ShippingMethod{}, err
+	}
+
+	if err := r.insertRules(ctx, id, method.Rules); err != nil {
+		return models.ShippingMethod{}, err
+	}
+
+	return r.get(ctx, id)
+}
+
+func (r *ShippingRepository) Delete(ctx context.Context, id int64) (int64, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	tag, execErr := r.db.Exec(ctx, `DELETE FROM shipping_methods WHERE method_id = $1`, id)
+
+	if execErr != nil {
+		return 0, execErr
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+func (r *ShippingRepository) insertRules(ctx context.Context, methodId int64, rules []models.ShippingRule) error {
+	sqlStatement := `INSERT INTO shipping_rules (method_id, country, postal_prefix, rate, per_kg, free_over, max_weight_grams, min_days, max_days)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
+
+	for _, rule := range rules {
+		_, err := r.db.Exec(ctx, sqlStatement, methodId, rule.Country, rule.PostalPrefix, rule.Rate, rule.PerKg,
+			rule.FreeOver, rule.MaxWeightGrams, rule.MinDays, rule.MaxDays)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *ShippingRepository) get(ctx context.Context, id int64) (models.ShippingMethod, error) {
+	methods, err := r.query(ctx, `SELECT `+shippingMethodColumns+` FROM shipping_methods WHERE method_id = $1`,
+		`SELECT `+shippingRuleColumns+` FROM shipping_rules WHERE method_id = $1 ORDER BY country, postal_prefix`, id)
+	if err != nil {
+		return models.ShippingMethod{}, err
+	}
+
+	if len(methods) == 0 {
+		return models.ShippingMethod{}, ErrNotFound
+	}
+
+	return methods[0], nil
+}
+
+// query loads the methods selected by methodsStatement and attaches the
+// rules selected by rulesStatement. Both take the same arguments.
+func (r *ShippingRepository) query(ctx context.Context, methodsStatement string, rulesStatement string, args ...any) ([]models.ShippingMethod, error) {
+	methods := make([]models.ShippingMethod, 0)
+	index := make(map[int64]int)
+
+	rows, queryErr := r.db.Query(ctx, methodsStatement, args...)
+
+	if queryErr != nil {
+		return nil, queryErr
+	}
+
+	for rows.Next() {
+		method, scanErr := scanShippingMethod(rows)
+
+		if scanErr != nil {
+			rows.Close()
+			return nil, scanErr
+		}
+
+		index[method.MethodID] = len(methods)
+		methods = append(methods, method)
+	}
+
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, queryErr = r.db.Query(ctx, rulesStatement, args...)
+
+	if queryErr != nil {
+		return nil, queryErr
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var rule models.ShippingRule
+		var methodId int64
+
+		scanErr := rows.Scan(&rule.RuleID, &methodId, &rule.Country, &rule.PostalPrefix, &rule.Rate, &rule.PerKg,
+			&rule.FreeOver, &rule.MaxWeightGrams, &rule.MinDays, &rule.MaxDays)
+
+		if scanErr != nil {
+			return nil, scanErr
+		}
+
+		if i, ok := index[methodId]; ok {
+			methods[i].Rules = append(methods[i].Rules, rule)
+		}
+	}
+
+	return methods, rows.Err()
+}
@@ -0,0 +1,315 @@
+package repositories
+
+import (
+	"context"
+	"time"
+	"training/proj/internal/api/models"
+)
+
+type TaxRepositoryInterface interface {
+	GetZones(context.Context) ([]models.TaxZone, error)
+	UpsertZone(context.Context, *models.TaxZone) error
+	DeleteZone(context.Context, string) (int64, error)
+	GetClasses(context.Context) ([]models.TaxClass, error)
+	UpsertClass(context.Context, *models.TaxClass) error
+	DeleteClass(context.Context, string) (int64, error)
+	GetRates(context.Context, string) ([]models.TaxRate, error)
+	CreateRate(context.Context, *models.TaxRate) error
+	DeleteRate(context.Context, int64) (int64, error)
+	SetItemClass(context.Context, int64, *string) (int64, error)
+	SetCategoryClass(context.Context, int64, *string) (int64, error)
+	ItemClasses(context.Context, []int64) (map[int64]string, error)
+	RatesAt(context.Context, string, time.Time) (map[string]string, error)
+}
+
+var _ TaxRepositoryInterface = (*TaxRepository)(nil)
+
+type TaxRepository struct {
+	db      DBTX
+	timeout time.Duration
+}
+
+func NewTaxRepository(db DBTX, timeout time.Duration) *TaxRepository {
+	return &TaxRepository{
+		db:      db,
+		timeout: timeout,
+	}
+}
+
+func (r *TaxRepository) GetZones(ctx context.Context) ([]models.TaxZone, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	zones := make([]models.TaxZone, 0)
+
+	rows, queryErr := r.db.Query(ctx, `SELECT code, name FROM tax_zones ORDER BY code`)
+
+	if queryErr != nil {
+		return nil, queryErr
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var zone models.TaxZone
+
+		if scanErr := rows.Scan(&zone.Code, &zone.Name); scanErr != nil {
+			return nil, scanErr
+		}
+
+		zones = append(zones, zone)
+	}
+
+	return zones, rows.Err()
+}
+
+func (r *TaxRepository) UpsertZone(ctx context.Context, zone *models.TaxZone) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	sqlStatement := `INSERT INTO tax_zones (code, name) VALUES ($1, $2)
+	ON CONFLICT (code) DO UPDATE SET name = EXCLUDED.name`
+
+	_, execErr := r.db.Exec(ctx, sqlStatement, zone.Code, zone.Name)
+
+	return execErr
+}
+
+// DeleteZone removes the zone together with its rates.
+func (r *TaxRepository) DeleteZone(ctx context.Context, code string) (int64, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	tag, execErr := r.db.Exec(ctx, `DELETE FROM tax_zones WHERE code = $1`, code)
+
+	if execErr != nil {
+		return 0, execErr
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+func (r *TaxRepository) GetClasses(ctx context.Context) ([]models.TaxClass, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	classes := make([]models.TaxClass, 0)
+
+	rows, queryErr := r.db.Query(ctx, `SELECT code, name FROM tax_classes ORDER BY code`)
+
+	if queryErr != nil {
+		return nil, queryErr
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var class models.TaxClass
+
+		if scanErr := rows.Scan(&class.Code, &class.Name); scanErr != nil {
+			return nil, scanErr
+		}
+
+		classes = append(classes, class)
+	}
+
+	return classes, rows.Err()
+}
+
+func (r *TaxRepository) UpsertClass(ctx context.Context, class *models.TaxClass) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	sqlStatement := `INSERT INTO tax_classes (code, name) VALUES ($1, $2)
+	ON CONFLICT (code) DO UPDATE SET name = EXCLUDED.name`
+
+	_, execErr := r.db.Exec(ctx, sqlStatement, class.Code, class.Name)
+
+	return execErr
+}
+
+// DeleteClass removes the class and its rates. Items and categories that
+// had the class are left without one.
+func (r *TaxRepository) DeleteClass(ctx context.Context, code string) (int64, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	tag, execErr := r.db.Exec(ctx, `DELETE FROM tax_classes WHERE code = $1`, code)
+
+	if execErr != nil {
+		return 0, execErr
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+// GetRates returns the rates of a zone, or of every zone if zone is empty,
+// newest first.
+func (r *TaxRepository) GetRates(ctx context.Context, zone string) ([]models.TaxRate, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	rates := make([]models.TaxRate, 0)
+
+	sqlStatement := `SELECT rate_id, zone, tax_class, rate::text, effective_from, created_at FROM tax_rates
+	WHERE $1 = '' OR zone = $1
+	ORDER BY zone, tax_class, effective_from DESC`
+
+	rows, queryErr := r.db.Query(ctx, sqlStatement, zone)
+
+	if queryErr != nil {
+		return nil, queryErr
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var rate models.TaxRate
+
+		scanErr := rows.Scan(&rate.RateID, &rate.Zone, &rate.TaxClass, &rate.Rate, &rate.EffectiveFrom, &rate.CreatedAt)
+
+		if scanErr != nil {
+			return nil, scanErr
+		}
+
+		rates = append(rates, rate)
+	}
+
+	return rates, rows.Err()
+}
+
+func (r *TaxRepository) CreateRate(ctx context.Context, rate *models.TaxRate) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	sqlStatement := `INSERT INTO tax_rates (zone, tax_class, rate, effective_from) VALUES ($1, $2, $3::numeric, $4)
+	RETURNING rate_id, rate::text, created_at`
+
+	return r.db.QueryRow(ctx, sqlStatement, rate.Zone, rate.TaxClass, rate.Rate, rate.EffectiveFrom).
+		Scan(&rate.RateID, &rate.Rate, &rate.CreatedAt)
+}
+
+func (r *TaxRepository) DeleteRate(ctx context.Context, id int64) (int64, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	tag, execErr := r.db.Exec(ctx, `DELETE FROM tax_rates WHERE rate_id = $1`, id)
+
+	if execErr != nil {
+		return 0, execErr
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+// SetItemClass sets or, with a nil class, clears the item's tax class.
+func (r *TaxRepository) SetItemClass(ctx context.Context, itemId int64, class *string) (int64, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	tag, execErr := r.db.Exec(ctx, `UPDATE items SET tax_class = $2 WHERE item_id = $1 AND deleted_at IS NULL`, itemId, class)
+
+	if execErr != nil {
+		return 0, execErr
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+// SetCategoryClass sets or, with a nil class, clears the category's tax
+// class.
+func (r *TaxRepository) SetCategoryClass(ctx context.Context, categoryId int64, class *string) (int64, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	tag, execErr := r.db.Exec(ctx, `UPDATE categories SET tax_class = $2 WHERE category_id = $1 AND deleted_at IS NULL`, categoryId, class)
+
+	if execErr != nil {
+		return 0, execErr
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+// ItemClasses returns the tax class of each item: its own, else that of
+// the first of its categories by ID that has one, else the default class.
+func (r *TaxRepository) ItemClasses(ctx context.Context, itemIds []int64) (map[int64]string, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	classes := make(map[int64]string, len(itemIds))
+
+	sqlStatement := `SELECT i.item_id, COALESCE(i.tax_class, (
+		SELECT c.tax_class FROM categories_items ci
+		INNER JOIN categories c USING (category_id)
+		WHERE ci.item_id = i.item_id AND c.deleted_at IS NULL AND c.tax_class IS NOT NULL
+		ORDER BY c.category_id
+		LIMIT 1
+	), $2)
+	FROM items i
+	WHERE i.item_id = ANY ($1)`
+
+	rows, queryErr := r.db.Query(ctx, sqlStatement, itemIds, models.DefaultTaxClass)
+
+	if queryErr != nil {
+		return nil, queryErr
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var itemId int64
+		var class string
+
+		if scanErr := rows.Scan(&itemId, &class); scanErr != nil {
+			return nil, scanErr
+		}
+
+		classes[itemId] = class
+	}
+
+	return classes, rows.Err()
+}
+
+// RatesAt returns the rate in effect at the given time for each tax class
+// of the zone. It returns ErrNotFound if the zone does not exist.
+func (r *TaxRepository) RatesAt(ctx context.Context, zone string, at time.Time) (map[string]string, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	var exists bool
+
+	if err := r.db.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM tax_zones WHERE code = $1)`, zone).Scan(&exists); err != nil {
+		return nil, err
+	}
+
+	if !exists {
+		return nil, ErrNotFound
+	}
+
+	rates := make(map[string]string)
+
+	sqlStatement := `SELECT DISTINCT ON (tax_class) tax_class, rate::text FROM tax_rates
+	WHERE zone = $1 AND effective_from <= $2
+	ORDER BY tax_class, effective_from DESC`
+
+	rows, queryErr := r.db.Query(ctx, sqlStatement, zone, at)
+
+	if queryErr != nil {
+		return nil, queryErr
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var class, rate string
+
+		if scanErr := rows.Scan(&class, &rate); scanErr != nil {
+			return nil, scanErr
+		}
+
+		rates[class] = rate
+	}
+
+	return rates, rows.Err()
+}
@@ -0,0 +1,200 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type DBTX interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	CopyFrom(ctx context.Context, tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error)
+}
+
+type IsolationLevel int
+
+const (
+	ReadCommitted IsolationLevel = iota
+	RepeatableRead
+	Serializable
+)
+
+type TxOptions struct {
+	Isolation  IsolationLevel
+	ReadOnly   bool
+	MaxRetries int
+}
+
+type TxOption func(*TxOptions)
+
+func WithIsolation(level IsolationLevel) TxOption {
+	return func(o *TxOptions) {
+		o.Isolation = level
+	}
+}
+
+func WithReadOnly() TxOption {
+	return func(o *TxOptions) {
+		o.ReadOnly = true
+	}
+}
+
+func WithMaxRetries(n int) TxOption {
+	return func(o *TxOptions) {
+		o.MaxRetries = n
+	}
+}
+
+func NewTxOptions(opts ...TxOption) TxOptions {
+	o := TxOptions{
+		Isolation:  ReadCommitted,
+		MaxRetries: 3,
+	}
+
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return o
+}
+
+type TxFunc func(ctx context.Context, r *Repositories) error
+
+type TxManager interface {
+	WithinTx(ctx context.Context, fn TxFunc, opts ...TxOption) error
+}
+
+type PgTxManager struct {
+	pool    *pgxpool.Pool
+	timeout time.Duration
+}
+
+var _ TxManager = (*PgTxManager)(nil)
+
+func NewTxManager(pool *pgxpool.Pool, queryTimeout time.Duration) *PgTxManager {
+	return &PgTxManager{
+		pool:    pool,
+		timeout: queryTimeout,
+	}
+}
+
+func (m *PgTxManager) WithinTx(ctx context.Context, fn TxFunc, opts ...TxOption) error {
+	o := NewTxOptions(opts...)
+
+	for attempt := 0; ; attempt++ {
+		err := m.run(ctx, o, fn)
+
+		if err == nil || !IsRetryable(err) || attempt >= o.MaxRetries {
+			return err
+		}
+
+		if sleepErr := sleepBackoff(ctx, attempt); sleepErr != nil {
+			return err
+		}
+	}
+}
+
+func (m *PgTxManager) run(ctx context.Context, o TxOptions, fn TxFunc) (err error) {
+	txOptions := pgx.TxOptions{
+		IsoLevel:   o.Isolation.pgxLevel(),
+		AccessMode: pgx.ReadWrite,
+	}
+
+	if o.ReadOnly {
+		txOptions.AccessMode = pgx.ReadOnly
+	}
+
+	tx, beginErr := m.pool.BeginTx(ctx, txOptions)
+
+	if beginErr != nil {
+		return beginErr
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback(context.WithoutCancel(ctx))
+			panic(p)
+		}
+
+		if err != nil {
+			_ = tx.Rollback(context.WithoutCancel(ctx))
+		}
+	}()
+
+	if err = fn(ctx, newTxRepositories(tx, m.timeout)); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func newTxRepositories(tx DBTX, queryTimeout time.Duration) *Repositories {
+	r := newRepositories(tx, queryTimeout)
+	r.TxManager = joinedTx{repositories: r}
+	return r
+}
+
+// joinedTx runs nested WithinTx calls inside the already open transaction.
+type joinedTx struct {
+	repositories *Repositories
+}
+
+func (j joinedTx) WithinTx(ctx context.Context, fn TxFunc, opts ...TxOption) error {
+	return fn(ctx, j.repositories)
+}
+
+func (l IsolationLevel) pgxLevel() pgx.TxIsoLevel {
+	switch l {
+	case RepeatableRead:
+		return pgx.RepeatableRead
+	case Serializable:
+		return pgx.Serializable
+	default:
+		return pgx.ReadCommitted
+	}
+}
+
+func (l IsolationLevel) String() string {
+	switch l {
+	case RepeatableRead:
+		return "repeatable read"
+	case Serializable:
+		return "serializable"
+	default:
+		return "read committed"
+	}
+}
+
+func IsRetryable(err error) bool {
+	var pgErr *pgconn.PgError
+
+	if !errors.As(err, &pgErr) {
+		return false
+	}
+
+	return pgErr.Code == pgerrcode.SerializationFailure || pgErr.Code == pgerrcode.DeadlockDetected
+}
+
+func sleepBackoff(ctx context.Context, attempt int) error {
+	base := 10 * time.Millisecond << attempt
+	delay := base + rand.N(base)
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("transaction retry aborted: %w", ctx.Err())
+	case <-timer.C:
+		return nil
+	}
+}
@@ -1,36 +1,42 @@
 package repositories
 
 import (
-	"database/sql"
-	"errors"
+	"context"
+	"time"
 	"training/proj/internal/api/models"
-
-	"github.com/jackc/pgx/v5/pgconn"
 )
 
 type UserRepositoryInterface interface {
-	Create(*models.User, []byte) (models.User, *pgconn.PgError)
-	GetByEmail(string) (models.User, error)
-	GetByUsername(string) (models.User, error)
+	Create(context.Context, *models.User, []byte) (models.User, error)
+	GetByEmail(context.Context, string) (models.User, error)
+	GetByUsername(context.Context, string) (models.User, error)
+	SetAdmin(context.Context, string, bool) (int64, error)
 }
 
+var _ UserRepositoryInterface = (*UserRepository)(nil)
+
 type UserRepository struct {
-	db *sql.DB
+	db      DBTX
+	timeout time.Duration
 }
 
-func NewUserRepository(db *sql.DB) *UserRepository {
+func NewUserRepository(db DBTX, timeout time.Duration) *UserRepository {
 	return &UserRepository{
-		db: db,
+		db:      db,
+		timeout: timeout,
 	}
 }
 
-func (r *UserRepository) Create(userReq *models.User, hashedPassword []byte) (models.User, *pgconn.PgError) {
+func (r *UserRepository) Create(ctx context.Context, userReq *models.User, hashedPassword []byte) (models.User, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
 	var userResp models.User
 
 	sqlStatement := `INSERT INTO users (email, first_name, last_name, password, username)
-	VALUES ($1, $2, $3, $4, $5) RETURNING user_id, email, first_name, last_name, username`
+	VALUES ($1, $2, $3, $4, $5) RETURNING user_id, email, first_name, last_name, username, is_admin`
 
-	row := r.db.QueryRow(sqlStatement,
+	row := r.db.QueryRow(ctx, sqlStatement,
 		userReq.Email,
 		userReq.FirstName,
 		userReq.LastName,
@@ -41,44 +47,67 @@ func (r *UserRepository) Create(userReq *models.User, hashedPassword []byte) (mo
 		&userResp.Email,
 		&userResp.FirstName,
 		&userResp.LastName,
-		&userResp.Username)
-
-	var pgErr *pgconn.PgError
-	errors.As(err, &pgErr)
+		&userResp.Username,
+		&userResp.IsAdmin)
 
-	return userResp, pgErr
+	return userResp, err
 }
 
-func (r *UserRepository) GetByEmail(email string) (models.User, error) {
+func (r *UserRepository) GetByEmail(ctx context.Context, email string) (models.User, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
 	var userResp models.User
 
-	sqlStatement := `SELECT * FROM users WHERE email = $1`
+	sqlStatement := `SELECT user_id, email, first_name, last_name, password, username, is_admin
+	FROM users WHERE email = $1`
 
-	row := r.db.QueryRow(sqlStatement, email)
+	row := r.db.QueryRow(ctx, sqlStatement, email)
 	err := row.Scan(
 		&userResp.UserID,
 		&userResp.Email,
 		&userResp.FirstName,
 		&userResp.LastName,
 		&userResp.Password,
-		&userResp.Username)
+		&userResp.Username,
+		&userResp.IsAdmin)
 
 	return userResp, err
 }
 
-func (r *UserRepository) GetByUsername(username string) (models.User, error) {
+func (r *UserRepository) GetByUsername(ctx context.Context, username string) (models.User, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
 	var userResp models.User
 
-	sqlStatement := `SELECT * FROM users WHERE username = $1`
+	sqlStatement := `SELECT user_id, email, first_name, last_name, password, username, is_admin
+	FROM users WHERE username = $1`
 
-	row := r.db.QueryRow(sqlStatement, username)
+	row := r.db.QueryRow(ctx, sqlStatement, username)
 	err := row.Scan(
 		&userResp.UserID,
 		&userResp.Email,
 		&userResp.FirstName,
 		&userResp.LastName,
 		&userResp.Password,
-		&userResp.Username)
+		&userResp.Username,
+		&userResp.IsAdmin)
 
 	return userResp, err
 }
+
+func (r *UserRepository) SetAdmin(ctx context.Context, username string, isAdmin bool) (int64, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	sqlStatement := `UPDATE users SET is_admin = $2 WHERE username = $1`
+
+	tag, execErr := r.db.Exec(ctx, sqlStatement, username, isAdmin)
+
+	if execErr != nil {
+		return 0, execErr
+	}
+
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,22 @@
+package importer
+
+import "net/http"
+
+const emojiHubURL = "https://emojihub.yurace.pro/api/all"
+
+func init() {
+	Register("emoji", NewEmoji)
+}
+
+// NewEmoji imports emojis from EmojiHub, using each emoji's category as the
+// item category.
+func NewEmoji(cfg SourceConfig, client *http.Client) (Importer, error) {
+	if cfg.URL == "" {
+		cfg.URL = emojiHubURL
+	}
+
+	cfg.Items = ""
+	cfg.Fields = FieldMapping{Name: "name", Category: "category", Price: "price"}
+
+	return NewHTTPJSON(cfg, client)
+}
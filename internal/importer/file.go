@@ -0,0 +1,173 @@
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func init() {
+	Register("file", NewFile)
+}
+
+type FileImporter struct {
+	name   string
+	path   string
+	format string
+	fields FieldMapping
+	prune  bool
+}
+
+func NewFile(cfg SourceConfig, _ *http.Client) (Importer, error) {
+	if cfg.Path == "" {
+		return nil, errors.New("path is required")
+	}
+
+	format := cfg.Format
+	if format == "" {
+		switch strings.ToLower(filepath.Ext(cfg.Path)) {
+		case ".csv":
+			format = "csv"
+		case ".ndjson", ".jsonl":
+			format = "ndjson"
+		default:
+			return nil, fmt.Errorf("cannot infer the format of %s, set format to csv or ndjson", cfg.Path)
+		}
+	}
+
+	return &FileImporter{
+		name:   cfg.Name,
+		path:   cfg.Path,
+		format: format,
+		fields: cfg.Fields.withDefaults(),
+		prune:  cfg.Prune,
+	}, nil
+}
+
+func (i *FileImporter) Name() string {
+	return i.name
+}
+
+func (i *FileImporter) Fetch(ctx context.Context) (*Batch, error) {
+	f, err := os.Open(i.path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	switch i.format {
+	case "csv":
+		return i.readCSV(ctx, f)
+	case "ndjson":
+		return i.readNDJSON(ctx, f)
+	default:
+		return nil, fmt.Errorf("unsupported format %q", i.format)
+	}
+}
+
+func (i *FileImporter) readCSV(ctx context.Context, r io.Reader) (*Batch, error) {
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+	cr.FieldsPerRecord = -1
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("read csv header: %w", err)
+	}
+
+	batch := &Batch{Prune: i.prune}
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		row, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		// FieldPos is only valid after a successful read, so a malformed row
+		// is located from the parse error instead.
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			line := parseErr.StartLine
+			if line == 0 {
+				line = parseErr.Line
+			}
+			batch.fail(i.name, fmt.Sprintf("%s:%d", i.path, line), parseErr.Err)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		line, _ := cr.FieldPos(0)
+		ref := fmt.Sprintf("%s:%d", i.path, line)
+
+		obj := make(map[string]any, len(header))
+		for n, column := range header {
+			if n < len(row) {
+				obj[column] = row[n]
+			}
+		}
+
+		record, err := recordFromObject(obj, i.fields)
+		if err != nil {
+			batch.fail(i.name, ref, err)
+			continue
+		}
+		batch.Records = append(batch.Records, record)
+	}
+
+	return batch, nil
+}
+
+func (i *FileImporter) readNDJSON(ctx context.Context, r io.Reader) (*Batch, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64<<10), 1<<20)
+
+	batch := &Batch{Prune: i.prune}
+	for line := 1; scanner.Scan(); line++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		data := bytes.TrimSpace(scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.UseNumber()
+
+		ref := fmt.Sprintf("%s:%d", i.path, line)
+
+		var obj map[string]any
+		if err := dec.Decode(&obj); err != nil {
+			batch.fail(i.name, ref, err)
+			continue
+		}
+
+		record, err := recordFromObject(obj, i.fields)
+		if err != nil {
+			batch.fail(i.name, ref, err)
+			continue
+		}
+		batch.Records = append(batch.Records, record)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return batch, nil
+}
@@ -0,0 +1,87 @@
+package importer
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+var fixtureFields = FieldMapping{Name: "title", Category: "group", Price: "cost"}
+
+func fetchFixture(t *testing.T, path string) *Batch {
+	t.Helper()
+
+	imp, err := NewFile(SourceConfig{Name: "file", Path: path, Fields: fixtureFields}, nil)
+	if err != nil {
+		t.Fatalf("NewFile: %v", err)
+	}
+
+	batch, err := imp.Fetch(context.Background())
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+
+	return batch
+}
+
+func failureRefs(batch *Batch) []string {
+	refs := make([]string, 0, len(batch.Failures))
+	for _, f := range batch.Failures {
+		refs = append(refs, f.Ref)
+	}
+	return refs
+}
+
+func TestFileCSV(t *testing.T) {
+	batch := fetchFixture(t, "testdata/products.csv")
+
+	want := []Record{
+		{Name: "Apple", Category: "Fruit", Price: 120},
+		{Name: "Pear", Category: "Fruit", Price: 100},
+		{Name: "Kale", Category: "Vegetables"},
+	}
+	if !reflect.DeepEqual(batch.Records, want) {
+		t.Errorf("records = %+v, want %+v", batch.Records, want)
+	}
+
+	if refs := failureRefs(batch); !reflect.DeepEqual(refs, []string{"testdata/products.csv:4"}) {
+		t.Errorf("failures = %v, want the nameless row on line 4", refs)
+	}
+}
+
+func TestFileNDJSON(t *testing.T) {
+	batch := fetchFixture(t, "testdata/products.ndjson")
+
+	want := []Record{
+		{Name: "Apple", Category: "Fruit", Price: 120},
+		{Name: "Pear", Category: "Fruit", Price: 100, Currency: "USD"},
+		{Name: "Kale", Category: "Vegetables"},
+	}
+	if !reflect.DeepEqual(batch.Records, want) {
+		t.Errorf("records = %+v, want %+v", batch.Records, want)
+	}
+
+	if refs := failureRefs(batch); !reflect.DeepEqual(refs, []string{"testdata/products.ndjson:4", "testdata/products.ndjson:6"}) {
+		t.Errorf("failures = %v, want lines 4 and 6", refs)
+	}
+}
+
+func TestFileInfersFormat(t *testing.T) {
+	if _, err := NewFile(SourceConfig{Name: "file", Path: "testdata/products.txt"}, nil); err == nil {
+		t.Fatal("NewFile accepted a path without a known extension")
+	}
+}
+
+func TestFileCSVSkipsMalformedRows(t *testing.T) {
+	batch := fetchFixture(t, "testdata/malformed.csv")
+
+	want := []Record{{Name: "Apple", Category: "Fruit", Price: 120}}
+	if !reflect.DeepEqual(batch.Records, want) {
+		t.Errorf("records = %+v, want %+v", batch.Records, want)
+	}
+
+	refs := failureRefs(batch)
+	if !reflect.DeepEqual(refs, []string{"testdata/malformed.csv:2", "testdata/malformed.csv:4"}) {
+		t.Errorf("failures = %v, want lines 2 and 4", refs)
+	}
+}
@@ -0,0 +1,116 @@
+package importer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+func init() {
+	Register("http_json", NewHTTPJSON)
+}
+
+type HTTPJSONImporter struct {
+	name    string
+	url     string
+	headers map[string]string
+	items   string
+	fields  FieldMapping
+	prune   bool
+	client  *http.Client
+}
+
+func NewHTTPJSON(cfg SourceConfig, client *http.Client) (Importer, error) {
+	if cfg.URL == "" {
+		return nil, errors.New("url is required")
+	}
+
+	if _, err := url.ParseRequestURI(cfg.URL); err != nil {
+		return nil, fmt.Errorf("invalid url: %w", err)
+	}
+
+	if cfg.Timeout > 0 {
+		c := *client
+		c.Timeout = cfg.Timeout
+		client = &c
+	}
+
+	return &HTTPJSONImporter{
+		name:    cfg.Name,
+		url:     cfg.URL,
+		headers: cfg.Headers,
+		items:   cfg.Items,
+		fields:  cfg.Fields.withDefaults(),
+		prune:   cfg.Prune,
+		client:  client,
+	}, nil
+}
+
+func (i *HTTPJSONImporter) Name() string {
+	return i.name
+}
+
+func (i *HTTPJSONImporter) Fetch(ctx context.Context) (*Batch, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, i.url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Accept", "application/json")
+	for k, v := range i.headers {
+		req.Header.Set(k, v)
+	}
+
+	res, err := i.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		_, _ = io.Copy(io.Discard, io.LimitReader(res.Body, 64<<10))
+		return nil, &StatusError{URL: i.url, StatusCode: res.StatusCode, Status: res.Status}
+	}
+
+	dec := json.NewDecoder(res.Body)
+	dec.UseNumber()
+
+	var body any
+	if err := dec.Decode(&body); err != nil {
+		return nil, fmt.Errorf("decode response: %w", err)
+	}
+
+	raw, ok := lookup(body, i.items)
+	if !ok {
+		return nil, fmt.Errorf("response has no %q field", i.items)
+	}
+
+	list, ok := raw.([]any)
+	if !ok {
+		return nil, fmt.Errorf("expected a JSON array of items, got %T", raw)
+	}
+
+	batch := &Batch{Records: make([]Record, 0, len(list)), Prune: i.prune}
+	for n, entry := range list {
+		ref := fmt.Sprintf("item %d", n)
+
+		obj, ok := entry.(map[string]any)
+		if !ok {
+			batch.fail(i.name, ref, fmt.Errorf("expected an object, got %T", entry))
+			continue
+		}
+
+		record, err := recordFromObject(obj, i.fields)
+		if err != nil {
+			batch.fail(i.name, ref, err)
+			continue
+		}
+		batch.Records = append(batch.Records, record)
+	}
+
+	return batch, nil
+}
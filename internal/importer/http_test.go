@@ -0,0 +1,97 @@
+package importer
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func newHTTPJSONTest(t *testing.T, cfg SourceConfig, handler http.HandlerFunc) Importer {
+	t.Helper()
+
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	cfg.Name = "feed"
+	cfg.URL = srv.URL
+
+	imp, err := NewHTTPJSON(cfg, srv.Client())
+	if err != nil {
+		t.Fatalf("NewHTTPJSON: %v", err)
+	}
+
+	return imp
+}
+
+func TestHTTPJSONMapsNestedFields(t *testing.T) {
+	imp := newHTTPJSONTest(t, SourceConfig{
+		Items:   "data.products",
+		Fields:  FieldMapping{Name: "title", Category: "category.name", Price: "pricing.amount"},
+		Headers: map[string]string{"X-Api-Key": "secret"},
+		Prune:   true,
+	}, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Api-Key") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"data": {"products": [
+			{"title": "Apple", "category": {"name": "Fruit"}, "pricing": {"amount": 120}},
+			{"title": "Pear", "category": {"name": "Fruit"}, "pricing": {"amount": "99.6"}},
+			{"title": "Kale", "category": {"name": "Vegetables"}},
+			{"title": "Nameless"},
+			"not an object"
+		]}}`))
+	})
+
+	batch, err := imp.Fetch(context.Background())
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+
+	want := []Record{
+		{Name: "Apple", Category: "Fruit", Price: 120},
+		{Name: "Pear", Category: "Fruit", Price: 100},
+		{Name: "Kale", Category: "Vegetables"},
+	}
+	if !reflect.DeepEqual(batch.Records, want) {
+		t.Errorf("records = %+v, want %+v", batch.Records, want)
+	}
+
+	if len(batch.Failures) != 2 || batch.Failures[0].Ref != "item 3" || batch.Failures[1].Ref != "item 4" {
+		t.Errorf("failures = %+v, want items 3 and 4", batch.Failures)
+	}
+
+	if !batch.Prune {
+		t.Error("batch is not marked for pruning")
+	}
+}
+
+func TestHTTPJSONReportsStatus(t *testing.T) {
+	imp := newHTTPJSONTest(t, SourceConfig{}, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	_, err := imp.Fetch(context.Background())
+
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("Fetch = %v, want a 503 StatusError", err)
+	}
+
+	if !IsTransient(err) {
+		t.Error("a 503 is not treated as transient")
+	}
+}
+
+func TestHTTPJSONRejectsMissingItems(t *testing.T) {
+	imp := newHTTPJSONTest(t, SourceConfig{Items: "products"}, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"items": []}`))
+	})
+
+	if _, err := imp.Fetch(context.Background()); err == nil {
+		t.Fatal("Fetch succeeded without the items field")
+	}
+}
@@ -0,0 +1,158 @@
+package importer
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+// Record is one item listed by a source. Currency is empty when the source
+// does not give one; its price is then in the importer's currency.
+type Record struct {
+	Name     string
+	Category string
+	Price    int64
+	Currency string
+}
+
+// Failure describes a single record that was skipped. Ref locates it in the
+// source, e.g. "item 12" or "products.csv:40".
+type Failure struct {
+	Source string `json:"source"`
+	Ref    string `json:"ref,omitempty"`
+	Name   string `json:"name,omitempty"`
+	Error  string `json:"error"`
+}
+
+// Batch holds the records of one fetch. Records that could not be parsed are
+// reported in Failures instead of failing the whole fetch. Prune marks the
+// batch as the complete listing of the source, so items previously imported
+// from it but missing from the batch may be deleted.
+type Batch struct {
+	Records  []Record
+	Failures []Failure
+	Prune    bool
+}
+
+func (b *Batch) fail(source string, ref string, err error) {
+	b.Failures = append(b.Failures, Failure{Source: source, Ref: ref, Error: err.Error()})
+}
+
+type Importer interface {
+	Name() string
+	Fetch(ctx context.Context) (*Batch, error)
+}
+
+type SourceConfig struct {
+	Name    string            `yaml:"name" toml:"name" validate:"required"`
+	Type    string            `yaml:"type" toml:"type" validate:"required"`
+	Enabled bool              `yaml:"enabled" toml:"enabled"`
+	URL     string            `yaml:"url" toml:"url"`
+	Headers map[string]string `yaml:"headers" toml:"headers"`
+	Path    string            `yaml:"path" toml:"path"`
+	Format  string            `yaml:"format" toml:"format" validate:"omitempty,oneof=csv ndjson"`
+	Items   string            `yaml:"items" toml:"items"`
+	Fields  FieldMapping      `yaml:"fields" toml:"fields"`
+	Timeout time.Duration     `yaml:"timeout" toml:"timeout" validate:"gte=0"`
+	Prune   bool              `yaml:"prune" toml:"prune"`
+	Retry   RetryConfig       `yaml:"retry" toml:"retry"`
+	Breaker BreakerConfig     `yaml:"breaker" toml:"breaker"`
+}
+
+// FieldMapping names the source fields that hold each record attribute.
+// Nested JSON fields are addressed with dots, e.g. "category.title".
+type FieldMapping struct {
+	Name     string `yaml:"name" toml:"name"`
+	Category string `yaml:"category" toml:"category"`
+	Price    string `yaml:"price" toml:"price"`
+	Currency string `yaml:"currency" toml:"currency"`
+}
+
+func (m FieldMapping) withDefaults() FieldMapping {
+	if m.Name == "" {
+		m.Name = "name"
+	}
+	if m.Category == "" {
+		m.Category = "category"
+	}
+	if m.Price == "" {
+		m.Price = "price"
+	}
+	if m.Currency == "" {
+		m.Currency = "currency"
+	}
+	return m
+}
+
+type Factory func(cfg SourceConfig, client *http.Client) (Importer, error)
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]Factory{}
+)
+
+func Register(kind string, factory Factory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if _, ok := registry[kind]; ok {
+		panic(fmt.Sprintf("importer: type %q registered twice", kind))
+	}
+	registry[kind] = factory
+}
+
+func Types() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	kinds := make([]string, 0, len(registry))
+	for kind := range registry {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+
+	return kinds
+}
+
+func New(cfg SourceConfig, client *http.Client) (Importer, error) {
+	registryMu.RLock()
+	factory, ok := registry[cfg.Type]
+	registryMu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("importer %q: unknown type %q (available: %v)", cfg.Name, cfg.Type, Types())
+	}
+
+	imp, err := factory(cfg, client)
+	if err != nil {
+		return nil, fmt.Errorf("importer %q: %w", cfg.Name, err)
+	}
+
+	return imp, nil
+}
+
+// Build creates an importer for every enabled source, wrapped so that
+// transient failures are retried and a failing upstream trips a breaker.
+func Build(sources []SourceConfig) ([]Importer, error) {
+	client := &http.Client{Transport: otelhttp.NewTransport(http.DefaultTransport)}
+	importers := make([]Importer, 0, len(sources))
+
+	for _, src := range sources {
+		if !src.Enabled {
+			continue
+		}
+
+		imp, err := New(src, client)
+		if err != nil {
+			return nil, err
+		}
+		importers = append(importers, WithRetry(WithBreaker(imp, src.Breaker), src.Retry))
+	}
+
+	return importers, nil
+}
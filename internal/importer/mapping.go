@@ -0,0 +1,118 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"training/proj/internal/money"
+)
+
+func lookup(v any, path string) (any, bool) {
+	if path == "" {
+		return v, true
+	}
+
+	for _, key := range strings.Split(path, ".") {
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+
+		v, ok = obj[key]
+		if !ok {
+			return nil, false
+		}
+	}
+
+	return v, true
+}
+
+func recordFromObject(obj map[string]any, fields FieldMapping) (Record, error) {
+	name, err := stringField(obj, fields.Name)
+	if err != nil {
+		return Record{}, err
+	}
+
+	category, err := stringField(obj, fields.Category)
+	if err != nil {
+		return Record{}, err
+	}
+
+	record := Record{Name: name, Category: category}
+
+	if raw, ok := lookup(obj, fields.Price); ok && raw != nil {
+		record.Price, err = parsePrice(raw)
+		if err != nil {
+			return Record{}, fmt.Errorf("field %q: %w", fields.Price, err)
+		}
+	}
+
+	if raw, ok := lookup(obj, fields.Currency); ok && raw != nil && raw != "" {
+		code, ok := raw.(string)
+		if !ok {
+			return Record{}, fmt.Errorf("field %q must be a string, got %T", fields.Currency, raw)
+		}
+
+		record.Currency, err = money.Normalize(code)
+		if err != nil {
+			return Record{}, fmt.Errorf("field %q: %w", fields.Currency, err)
+		}
+	}
+
+	return record, nil
+}
+
+func stringField(obj map[string]any, path string) (string, error) {
+	raw, ok := lookup(obj, path)
+	if !ok || raw == nil {
+		return "", fmt.Errorf("field %q is missing", path)
+	}
+
+	var s string
+	switch v := raw.(type) {
+	case string:
+		s = v
+	case json.Number:
+		s = v.String()
+	case bool:
+		s = strconv.FormatBool(v)
+	default:
+		return "", fmt.Errorf("field %q must be a string, got %T", path, raw)
+	}
+
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return "", fmt.Errorf("field %q is empty", path)
+	}
+
+	return s, nil
+}
+
+func parsePrice(raw any) (int64, error) {
+	var s string
+	switch v := raw.(type) {
+	case json.Number:
+		s = v.String()
+	case string:
+		s = strings.TrimSpace(v)
+	default:
+		return 0, fmt.Errorf("price must be a number, got %T", raw)
+	}
+
+	if s == "" {
+		return 0, nil
+	}
+
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return n, nil
+	}
+
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid price %q", s)
+	}
+
+	return int64(math.Round(f)), nil
+}
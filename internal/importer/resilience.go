@@ -0,0 +1,193 @@
+package importer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+	"training/proj/internal/metrics"
+)
+
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+type RetryConfig struct {
+	MaxAttempts    int           `yaml:"max_attempts" toml:"max_attempts" validate:"gte=0"`
+	InitialBackoff time.Duration `yaml:"initial_backoff" toml:"initial_backoff" validate:"gte=0"`
+	MaxBackoff     time.Duration `yaml:"max_backoff" toml:"max_backoff" validate:"gte=0"`
+}
+
+func (c RetryConfig) withDefaults() RetryConfig {
+	if c.MaxAttempts == 0 {
+		c.MaxAttempts = 3
+	}
+	if c.InitialBackoff == 0 {
+		c.InitialBackoff = 500 * time.Millisecond
+	}
+	if c.MaxBackoff == 0 {
+		c.MaxBackoff = 10 * time.Second
+	}
+	return c
+}
+
+type BreakerConfig struct {
+	FailureThreshold int           `yaml:"failure_threshold" toml:"failure_threshold" validate:"gte=0"`
+	Cooldown         time.Duration `yaml:"cooldown" toml:"cooldown" validate:"gte=0"`
+}
+
+func (c BreakerConfig) withDefaults() BreakerConfig {
+	if c.FailureThreshold == 0 {
+		c.FailureThreshold = 5
+	}
+	if c.Cooldown == 0 {
+		c.Cooldown = time.Minute
+	}
+	return c
+}
+
+type StatusError struct {
+	URL        string
+	StatusCode int
+	Status     string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("GET %s: unexpected status %s", e.URL, e.Status)
+}
+
+// IsTransient reports whether a fetch error is worth retrying: network
+// failures, timeouts, throttling and upstream 5xx responses.
+func IsTransient(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, ErrCircuitOpen) {
+		return false
+	}
+
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode == http.StatusTooManyRequests || statusErr.StatusCode >= 500
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+
+	return errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+type retrying struct {
+	Importer
+	cfg RetryConfig
+}
+
+func WithRetry(imp Importer, cfg RetryConfig) Importer {
+	return &retrying{Importer: imp, cfg: cfg.withDefaults()}
+}
+
+func (r *retrying) Fetch(ctx context.Context) (*Batch, error) {
+	for attempt := 1; ; attempt++ {
+		batch, err := r.Importer.Fetch(ctx)
+		if err == nil || attempt >= r.cfg.MaxAttempts || !IsTransient(err) {
+			return batch, err
+		}
+
+		metrics.ImporterRetriesTotal.WithLabelValues(r.Name()).Inc()
+
+		if sleepErr := sleep(ctx, backoff(r.cfg, attempt)); sleepErr != nil {
+			return nil, errors.Join(err, sleepErr)
+		}
+	}
+}
+
+// backoff doubles the delay on every attempt and picks a random point in the
+// upper half of it so that instances do not retry in lockstep.
+func backoff(cfg RetryConfig, attempt int) time.Duration {
+	d := cfg.InitialBackoff << (attempt - 1)
+	if d <= 0 || d > cfg.MaxBackoff {
+		d = cfg.MaxBackoff
+	}
+
+	half := d / 2
+	return half + rand.N(half+1)
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+type breaker struct {
+	Importer
+	cfg BreakerConfig
+	now func() time.Time
+
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+	probing   bool
+}
+
+func WithBreaker(imp Importer, cfg BreakerConfig) Importer {
+	return &breaker{Importer: imp, cfg: cfg.withDefaults(), now: time.Now}
+}
+
+func (b *breaker) Fetch(ctx context.Context) (*Batch, error) {
+	if err := b.allow(); err != nil {
+		return nil, err
+	}
+
+	batch, err := b.Importer.Fetch(ctx)
+	b.record(err)
+
+	return batch, err
+}
+
+func (b *breaker) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.failures < b.cfg.FailureThreshold {
+		return nil
+	}
+
+	if b.probing || b.now().Before(b.openUntil) {
+		return fmt.Errorf("%w until %s", ErrCircuitOpen, b.openUntil.Format(time.RFC3339))
+	}
+
+	b.probing = true
+	return nil
+}
+
+func (b *breaker) record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+
+	// Only upstream failures count; a cancelled run or malformed data says
+	// nothing about whether the source is healthy.
+	if err != nil && IsTransient(err) {
+		b.failures++
+		if b.failures >= b.cfg.FailureThreshold {
+			b.openUntil = b.now().Add(b.cfg.Cooldown)
+			metrics.ImporterCircuitOpen.WithLabelValues(b.Name()).Set(1)
+		}
+		return
+	}
+
+	if err == nil {
+		b.failures = 0
+		metrics.ImporterCircuitOpen.WithLabelValues(b.Name()).Set(0)
+	}
+}
@@ -0,0 +1,140 @@
+package importer
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var fastRetry = RetryConfig{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: 2 * time.Millisecond}
+
+func TestRetryRecoversFromTransientFailures(t *testing.T) {
+	var calls atomic.Int32
+
+	imp := newHTTPJSONTest(t, SourceConfig{}, func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte(`[{"name": "Apple", "category": "Fruit", "price": 1}]`))
+	})
+
+	batch, err := WithRetry(imp, fastRetry).Fetch(context.Background())
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+
+	if calls.Load() != 3 || len(batch.Records) != 1 {
+		t.Errorf("got %d calls and %d records, want 3 calls and 1 record", calls.Load(), len(batch.Records))
+	}
+}
+
+func TestRetryGivesUpOnPermanentFailures(t *testing.T) {
+	var calls atomic.Int32
+
+	imp := newHTTPJSONTest(t, SourceConfig{}, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	if _, err := WithRetry(imp, fastRetry).Fetch(context.Background()); err == nil {
+		t.Fatal("Fetch succeeded on a 404")
+	}
+
+	if calls.Load() != 1 {
+		t.Errorf("got %d calls, want 1", calls.Load())
+	}
+}
+
+func TestRetryStopsAfterMaxAttempts(t *testing.T) {
+	var calls atomic.Int32
+
+	imp := newHTTPJSONTest(t, SourceConfig{}, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusTooManyRequests)
+	})
+
+	if _, err := WithRetry(imp, fastRetry).Fetch(context.Background()); err == nil {
+		t.Fatal("Fetch succeeded on a 429")
+	}
+
+	if calls.Load() != 3 {
+		t.Errorf("got %d calls, want 3", calls.Load())
+	}
+}
+
+func TestBreakerOpensAndProbesAfterCooldown(t *testing.T) {
+	var calls atomic.Int32
+	var healthy atomic.Bool
+
+	imp := newHTTPJSONTest(t, SourceConfig{}, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte(`[]`))
+	})
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	b := WithBreaker(imp, BreakerConfig{FailureThreshold: 2, Cooldown: time.Minute}).(*breaker)
+	b.now = func() time.Time { return now }
+
+	ctx := context.Background()
+
+	for range 2 {
+		if _, err := b.Fetch(ctx); err == nil || errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("Fetch = %v, want the upstream error", err)
+		}
+	}
+
+	if _, err := b.Fetch(ctx); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("Fetch = %v, want ErrCircuitOpen", err)
+	}
+
+	if calls.Load() != 2 {
+		t.Fatalf("open breaker let a call through: %d calls", calls.Load())
+	}
+
+	now = now.Add(time.Minute)
+	healthy.Store(true)
+
+	if _, err := b.Fetch(ctx); err != nil {
+		t.Fatalf("probe after cooldown: %v", err)
+	}
+
+	if _, err := b.Fetch(ctx); err != nil {
+		t.Fatalf("Fetch after recovery: %v", err)
+	}
+
+	if calls.Load() != 4 {
+		t.Errorf("got %d calls, want 4", calls.Load())
+	}
+}
+
+func TestBreakerIgnoresCanceledFetches(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	imp, err := NewHTTPJSON(SourceConfig{Name: "feed", URL: srv.URL}, srv.Client())
+	if err != nil {
+		t.Fatalf("NewHTTPJSON: %v", err)
+	}
+
+	b := WithBreaker(imp, BreakerConfig{FailureThreshold: 1, Cooldown: time.Minute})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := b.Fetch(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Fetch = %v, want context.Canceled", err)
+	}
+
+	if _, err := b.Fetch(ctx); errors.Is(err, ErrCircuitOpen) {
+		t.Fatal("a canceled fetch opened the breaker")
+	}
+}
@@ -0,0 +1,114 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"training/proj/internal/api/models"
+	"training/proj/internal/db/repositories"
+	"training/proj/internal/money"
+)
+
+type ExchangeRateStats struct {
+	Base    string `json:"base"`
+	Updated int    `json:"updated"`
+	Skipped int    `json:"skipped"`
+}
+
+// ratesResponse is the common shape of exchange rate feeds, e.g.
+// {"base": "EUR", "rates": {"USD": 1.0891, "GBP": 0.8567}}.
+type ratesResponse struct {
+	Base  string                 `json:"base"`
+	Rates map[string]json.Number `json:"rates"`
+}
+
+// ImportExchangeRates returns a job that fetches exchange rates from url and
+// stores them against the feed's base currency. Currencies the catalog
+// does not support are skipped.
+func ImportExchangeRates(tm repositories.TxManager, client *http.Client, url string) Func {
+	return func(ctx context.Context) (any, error) {
+		feed, err := fetchRates(ctx, client, url)
+		if err != nil {
+			return nil, err
+		}
+
+		base, err := money.Normalize(feed.Base)
+		if err != nil {
+			return nil, fmt.Errorf("feed base currency: %w", err)
+		}
+
+		quotes := make([]string, 0, len(feed.Rates))
+		for quote := range feed.Rates {
+			quotes = append(quotes, quote)
+		}
+		sort.Strings(quotes)
+
+		var stats ExchangeRateStats
+
+		err = tm.WithinTx(ctx, func(ctx context.Context, repos *repositories.Repositories) error {
+			stats = ExchangeRateStats{Base: base}
+
+			for _, raw := range quotes {
+				quote, err := money.Normalize(raw)
+				if err != nil || quote == base {
+					stats.Skipped++
+					continue
+				}
+
+				value, err := money.ParseRate(feed.Rates[raw].String())
+				if err != nil {
+					stats.Skipped++
+					continue
+				}
+
+				rate := &models.ExchangeRate{Base: base, Quote: quote, Rate: value.FloatString(12), Source: "import"}
+				if err := repos.ExchangeRateRepository.Upsert(ctx, rate); err != nil {
+					return fmt.Errorf("store rate %s/%s: %w", base, quote, err)
+				}
+
+				stats.Updated++
+			}
+
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		return stats, nil
+	}
+}
+
+func fetchRates(ctx context.Context, client *http.Client, url string) (*ratesResponse, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Accept", "application/json")
+
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		_, _ = io.Copy(io.Discard, io.LimitReader(res.Body, 64<<10))
+		return nil, fmt.Errorf("fetch exchange rates from %s: %s", url, res.Status)
+	}
+
+	var feed ratesResponse
+
+	dec := json.NewDecoder(res.Body)
+	dec.UseNumber()
+
+	if err := dec.Decode(&feed); err != nil {
+		return nil, fmt.Errorf("decode exchange rates: %w", err)
+	}
+
+	return &feed, nil
+}
@@ -0,0 +1,104 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+	"training/proj/internal/api/models"
+	"training/proj/internal/db/repositories"
+)
+
+type PriceStats struct {
+	Applied int `json:"applied"`
+	Pending int `json:"pending"`
+}
+
+// ApplyScheduledPrices returns a job that applies scheduled price changes
+// whose effective time has passed, recording each one as a revision and an
+// audit entry by the scheduler. Changes for items in the trash stay pending
+// and are dropped when the item is restored.
+func ApplyScheduledPrices(tm repositories.TxManager) Func {
+	return func(ctx context.Context) (any, error) {
+		var stats PriceStats
+
+		err := tm.WithinTx(ctx, func(ctx context.Context, repos *repositories.Repositories) error {
+			stats = PriceStats{}
+			now := time.Now()
+
+			due, err := repos.PriceRepository.GetDue(ctx, now)
+			if err != nil {
+				return fmt.Errorf("load due prices: %w", err)
+			}
+
+			for _, change := range due {
+				item, err := repos.ItemRepository.GetById(ctx, change.ItemID)
+				if errors.Is(err, repositories.ErrNotFound) {
+					stats.Pending++
+					continue
+				}
+				if err != nil {
+					return fmt.Errorf("load item %d: %w", change.ItemID, err)
+				}
+
+				before := item
+				item.Price = change.Price
+
+				updated, err := repos.ItemRepository.Update(ctx, item.ItemID, &item)
+				if err != nil {
+					return fmt.Errorf("update price of item %d: %w", item.ItemID, err)
+				}
+
+				data, err := json.Marshal(models.NewItemSnapshot(updated))
+				if err != nil {
+					return err
+				}
+
+				rev := &models.Revision{EntityType: "item", EntityID: updated.ItemID, Data: data, Actor: "scheduler"}
+				if err := repos.RevisionRepository.Create(ctx, rev); err != nil {
+					return fmt.Errorf("record revision of item %d: %w", item.ItemID, err)
+				}
+
+				if err := recordAudit(ctx, repos, before, updated); err != nil {
+					return fmt.Errorf("audit price of item %d: %w", item.ItemID, err)
+				}
+
+				if err := repos.PriceRepository.MarkApplied(ctx, change.PriceID, now); err != nil {
+					return fmt.Errorf("mark price %d applied: %w", change.PriceID, err)
+				}
+
+				stats.Applied++
+			}
+
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		return stats, nil
+	}
+}
+
+func recordAudit(ctx context.Context, repos *repositories.Repositories, before models.Item, after models.Item) error {
+	entry := &models.AuditEntry{
+		Actor:      "scheduler",
+		Action:     "update",
+		EntityType: "item",
+		EntityID:   strconv.FormatInt(after.ItemID, 10),
+	}
+
+	var err error
+
+	if entry.Before, err = json.Marshal(before); err != nil {
+		return err
+	}
+
+	if entry.After, err = json.Marshal(after); err != nil {
+		return err
+	}
+
+	return repos.AuditRepository.Create(ctx, entry)
+}
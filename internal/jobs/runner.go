@@ -0,0 +1,309 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+	"time"
+	"training/proj/internal/api/models"
+	"training/proj/internal/db/repositories"
+	"training/proj/internal/metrics"
+
+	"github.com/robfig/cron/v3"
+	"go.uber.org/zap"
+)
+
+const (
+	StatusRunning   = "running"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+	StatusCancelled = "cancelled"
+
+	TriggerSchedule = "schedule"
+	TriggerManual   = "manual"
+)
+
+var (
+	ErrUnknownJob = errors.New("unknown job")
+	ErrJobRunning = errors.New("job is already running")
+	ErrNotStarted = errors.New("job runner is not running")
+)
+
+// Func runs a job. The returned stats are stored as JSON with the run.
+type Func func(ctx context.Context) (stats any, err error)
+
+type Job struct {
+	Name     string
+	Schedule string
+	Jitter   time.Duration
+	Timeout  time.Duration
+	Run      Func
+}
+
+type Info struct {
+	Name     string         `json:"name"`
+	Schedule string         `json:"schedule"`
+	Running  bool           `json:"running"`
+	NextRun  *time.Time     `json:"next_run,omitempty"`
+	LastRun  *models.JobRun `json:"last_run,omitempty"`
+}
+
+type entry struct {
+	job      Job
+	schedule cron.Schedule
+	running  atomic.Bool
+
+	mu   sync.Mutex
+	next time.Time
+}
+
+type Runner struct {
+	Repository repositories.JobRunRepositoryInterface
+	Logger     *zap.SugaredLogger
+	Wg         *sync.WaitGroup
+
+	mu      sync.Mutex
+	ctx     context.Context
+	entries map[string]*entry
+	order   []string
+}
+
+func NewRunner(repo repositories.JobRunRepositoryInterface, l *zap.SugaredLogger, wg *sync.WaitGroup) *Runner {
+	return &Runner{
+		Repository: repo,
+		Logger:     l,
+		Wg:         wg,
+		entries:    make(map[string]*entry),
+	}
+}
+
+func (r *Runner) Register(job Job) error {
+	schedule, err := cron.ParseStandard(job.Schedule)
+	if err != nil {
+		return fmt.Errorf("job %s: invalid schedule %q: %w", job.Name, job.Schedule, err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.entries[job.Name]; ok {
+		return fmt.Errorf("job %s is already registered", job.Name)
+	}
+
+	r.entries[job.Name] = &entry{job: job, schedule: schedule}
+	r.order = append(r.order, job.Name)
+
+	return nil
+}
+
+// Start schedules every registered job until ctx is cancelled. Runs in
+// progress get the cancellation through their context and are tracked in
+// Wg, so waiting on it after cancelling drains them.
+func (r *Runner) Start(ctx context.Context) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.ctx = ctx
+
+	for _, name := range r.order {
+		e := r.entries[name]
+
+		r.Wg.Add(1)
+		go func() {
+			defer r.Wg.Done()
+			r.loop(ctx, e)
+		}()
+	}
+}
+
+func (r *Runner) loop(ctx context.Context, e *entry) {
+	for {
+		next := e.schedule.Next(time.Now())
+		if e.job.Jitter > 0 {
+			next = next.Add(rand.N(e.job.Jitter))
+		}
+
+		e.mu.Lock()
+		e.next = next
+		e.mu.Unlock()
+
+		timer := time.NewTimer(time.Until(next))
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		if !e.running.CompareAndSwap(false, true) {
+			r.Logger.Warnw("Skipping job run, previous run still in progress", "job", e.job.Name)
+			metrics.JobRunsTotal.WithLabelValues(e.job.Name, "skipped").Inc()
+			continue
+		}
+
+		run := r.begin(ctx, e, TriggerSchedule)
+		r.execute(ctx, e, run)
+	}
+}
+
+// Trigger starts a run outside the schedule and returns without waiting for
+// it to finish.
+func (r *Runner) Trigger(name string) (models.JobRun, error) {
+	r.mu.Lock()
+	ctx := r.ctx
+	e, ok := r.entries[name]
+	r.mu.Unlock()
+
+	if !ok {
+		return models.JobRun{}, ErrUnknownJob
+	}
+
+	if ctx == nil || ctx.Err() != nil {
+		return models.JobRun{}, ErrNotStarted
+	}
+
+	if !e.running.CompareAndSwap(false, true) {
+		return models.JobRun{}, ErrJobRunning
+	}
+
+	run := r.begin(ctx, e, TriggerManual)
+
+	r.Wg.Add(1)
+	go func() {
+		defer r.Wg.Done()
+		r.execute(ctx, e, run)
+	}()
+
+	return run, nil
+}
+
+// begin records the start of a run. A failure to record it is logged but
+// does not stop the job from running.
+func (r *Runner) begin(ctx context.Context, e *entry, trigger string) models.JobRun {
+	run, err := r.Repository.Create(context.WithoutCancel(ctx), e.job.Name, trigger)
+	if err != nil {
+		r.Logger.Errorw("Failed to record job run", "job", e.job.Name, "error", err)
+		return models.JobRun{Job: e.job.Name, TriggeredBy: trigger, Status: StatusRunning, StartedAt: time.Now()}
+	}
+
+	return run
+}
+
+func (r *Runner) execute(ctx context.Context, e *entry, run models.JobRun) {
+	defer e.running.Store(false)
+
+	if e.job.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, e.job.Timeout)
+		defer cancel()
+	}
+
+	r.Logger.Infow("Job started", "job", e.job.Name, "job_run_id", run.JobRunID, "triggered_by", run.TriggeredBy)
+	start := time.Now()
+
+	stats, err := r.call(ctx, e.job.Run)
+
+	status := StatusSucceeded
+	errMsg := ""
+	switch {
+	case err != nil && errors.Is(err, context.Canceled):
+		status = StatusCancelled
+		errMsg = err.Error()
+	case err != nil:
+		status = StatusFailed
+		errMsg = err.Error()
+	}
+
+	metrics.JobRunsTotal.WithLabelValues(e.job.Name, status).Inc()
+	metrics.JobRunDuration.WithLabelValues(e.job.Name).Observe(time.Since(start).Seconds())
+
+	log := r.Logger.Infow
+	if status != StatusSucceeded {
+		log = r.Logger.Warnw
+	}
+	log("Job finished", "job", e.job.Name, "job_run_id", run.JobRunID, "status", status, "duration", time.Since(start).String(), "error", errMsg)
+
+	if run.JobRunID == 0 {
+		return
+	}
+
+	var statsJSON []byte
+	if stats != nil {
+		statsJSON, err = json.Marshal(stats)
+		if err != nil {
+			r.Logger.Errorw("Failed to encode job stats", "job", e.job.Name, "error", err)
+		}
+	}
+
+	finishCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+
+	if _, err := r.Repository.Finish(finishCtx, run.JobRunID, status, statsJSON, errMsg); err != nil {
+		r.Logger.Errorw("Failed to record job result", "job", e.job.Name, "job_run_id", run.JobRunID, "error", err)
+	}
+}
+
+func (r *Runner) call(ctx context.Context, fn Func) (stats any, err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("job panicked: %v", p)
+		}
+	}()
+
+	return fn(ctx)
+}
+
+func (r *Runner) Jobs(ctx context.Context) ([]Info, error) {
+	r.mu.Lock()
+	entries := make([]*entry, 0, len(r.order))
+	for _, name := range r.order {
+		entries = append(entries, r.entries[name])
+	}
+	r.mu.Unlock()
+
+	infos := make([]Info, 0, len(entries))
+
+	for _, e := range entries {
+		info := Info{
+			Name:     e.job.Name,
+			Schedule: e.job.Schedule,
+			Running:  e.running.Load(),
+		}
+
+		e.mu.Lock()
+		if !e.next.IsZero() {
+			next := e.next
+			info.NextRun = &next
+		}
+		e.mu.Unlock()
+
+		runs, err := r.Repository.GetByJob(ctx, e.job.Name, 1)
+		if err != nil {
+			return nil, err
+		}
+		if len(runs) > 0 {
+			info.LastRun = &runs[0]
+		}
+
+		infos = append(infos, info)
+	}
+
+	return infos, nil
+}
+
+func (r *Runner) Runs(ctx context.Context, name string, limit int) ([]models.JobRun, error) {
+	r.mu.Lock()
+	_, ok := r.entries[name]
+	r.mu.Unlock()
+
+	if !ok {
+		return nil, ErrUnknownJob
+	}
+
+	return r.Repository.GetByJob(ctx, name, limit)
+}
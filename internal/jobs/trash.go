@@ -0,0 +1,43 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"training/proj/internal/db/repositories"
+)
+
+type PurgeStats struct {
+	Before     time.Time `json:"before"`
+	Items      int64     `json:"items"`
+	Categories int64     `json:"categories"`
+}
+
+// PurgeTrash returns a job that permanently deletes items and categories that
+// have been in the trash for longer than retention.
+func PurgeTrash(tm repositories.TxManager, retention time.Duration) Func {
+	return func(ctx context.Context) (any, error) {
+		stats := PurgeStats{Before: time.Now().Add(-retention)}
+
+		err := tm.WithinTx(ctx, func(ctx context.Context, repos *repositories.Repositories) error {
+			var err error
+
+			stats.Items, err = repos.ItemRepository.Purge(ctx, stats.Before)
+			if err != nil {
+				return fmt.Errorf("purge items: %w", err)
+			}
+
+			stats.Categories, err = repos.CategoryRepository.Purge(ctx, stats.Before)
+			if err != nil {
+				return fmt.Errorf("purge categories: %w", err)
+			}
+
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		return stats, nil
+	}
+}
@@ -1,19 +1,120 @@
 package logger
 
 import (
+	"fmt"
 	"log"
+	"os"
+	"time"
 
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/natefinch/lumberjack.v2"
 )
 
-var Logger *zap.SugaredLogger
+var (
+	Logger *zap.SugaredLogger
+
+	// Level is shared by every logger built here so that the level can be
+	// changed at runtime without rebuilding the logger.
+	Level = zap.NewAtomicLevelAt(zap.InfoLevel)
+)
+
+type Options struct {
+	Level    string
+	Format   string
+	Sampling bool
+	Caller   bool
+
+	File       string
+	MaxSizeMB  int
+	MaxBackups int
+	MaxAgeDays int
+	Compress   bool
+}
+
+func DefaultOptions() Options {
+	return Options{
+		Level:    "info",
+		Format:   "json",
+		Sampling: true,
+		Caller:   true,
+	}
+}
 
 func InitLogger() {
-	logger, err := zap.NewProduction()
-	if err != nil {
+	if err := Configure(DefaultOptions()); err != nil {
 		log.Fatal(err)
 	}
-	Logger = logger.Sugar()
+}
+
+// Configure replaces the global logger. Loggers already handed out keep
+// writing to their previous outputs, so call it before wiring components.
+func Configure(opts Options) error {
+	if err := SetLevel(opts.Level); err != nil {
+		return err
+	}
+
+	encoder, err := newEncoder(opts.Format)
+	if err != nil {
+		return err
+	}
+
+	var core zapcore.Core = zapcore.NewCore(encoder, zapcore.Lock(os.Stderr), Level)
+
+	if opts.File != "" {
+		file := zapcore.AddSync(&lumberjack.Logger{
+			Filename:   opts.File,
+			MaxSize:    opts.MaxSizeMB,
+			MaxBackups: opts.MaxBackups,
+			MaxAge:     opts.MaxAgeDays,
+			Compress:   opts.Compress,
+		})
+		core = zapcore.NewTee(core, zapcore.NewCore(encoder.Clone(), file, Level))
+	}
+
+	if opts.Sampling {
+		core = zapcore.NewSamplerWithOptions(core, time.Second, 100, 100)
+	}
+
+	zapOpts := []zap.Option{zap.AddStacktrace(zapcore.ErrorLevel)}
+	if opts.Caller {
+		zapOpts = append(zapOpts, zap.AddCaller())
+	}
+
+	previous := Logger
+	Logger = zap.New(core, zapOpts...).Sugar()
+
+	if previous != nil {
+		_ = previous.Sync()
+	}
+
+	return nil
+}
+
+func newEncoder(format string) (zapcore.Encoder, error) {
+	switch format {
+	case "json", "":
+		cfg := zap.NewProductionEncoderConfig()
+		cfg.EncodeTime = zapcore.ISO8601TimeEncoder
+		return zapcore.NewJSONEncoder(cfg), nil
+	case "console":
+		cfg := zap.NewDevelopmentEncoderConfig()
+		cfg.EncodeTime = zapcore.TimeEncoderOfLayout("15:04:05.000")
+		return zapcore.NewConsoleEncoder(cfg), nil
+	default:
+		return nil, fmt.Errorf("unknown log format %q", format)
+	}
+}
+
+func SetLevel(level string) error {
+	l, err := zapcore.ParseLevel(level)
+	if err != nil {
+		return err
+	}
+
+	Level.SetLevel(l)
+
+	return nil
 }
 
 func CloseLogger() {
@@ -0,0 +1,126 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "market"
+
+var (
+	HttpRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "Total number of HTTP requests by route pattern, method and status.",
+	}, []string{"method", "route", "status"})
+
+	HttpRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "HTTP request latency by route pattern, method and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	SchedulerLastRun = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "scheduler",
+		Name:      "last_run_timestamp_seconds",
+		Help:      "Unix time of the last external db fill run.",
+	})
+
+	SchedulerRunDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "scheduler",
+		Name:      "run_duration_seconds",
+		Help:      "Duration of external db fill runs.",
+		Buckets:   []float64{1, 5, 10, 30, 60, 120, 300, 600},
+	})
+
+	SchedulerItemsCreated = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "scheduler",
+		Name:      "items_created_total",
+		Help:      "Items created by the external db fill.",
+	})
+
+	SchedulerErrors = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "scheduler",
+		Name:      "errors_total",
+		Help:      "Errors encountered by the external db fill.",
+	})
+
+	JobRunsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "jobs",
+		Name:      "runs_total",
+		Help:      "Background job runs by job and final status.",
+	}, []string{"job", "status"})
+
+	JobRunDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "jobs",
+		Name:      "run_duration_seconds",
+		Help:      "Duration of background job runs.",
+		Buckets:   []float64{1, 5, 10, 30, 60, 120, 300, 600, 1800},
+	}, []string{"job"})
+
+	ImporterRetriesTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "importer",
+		Name:      "retries_total",
+		Help:      "Fetch attempts retried after a transient failure, by importer.",
+	}, []string{"importer"})
+
+	ImporterCircuitOpen = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "importer",
+		Name:      "circuit_open",
+		Help:      "Whether the importer's circuit breaker is open (1) or closed (0).",
+	}, []string{"importer"})
+
+	ImporterRecordFailuresTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "importer",
+		Name:      "record_failures_total",
+		Help:      "Records skipped because they could not be parsed or stored, by importer.",
+	}, []string{"importer"})
+
+	SignupsTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "signups_total",
+		Help:      "Successful user signups.",
+	})
+
+	LoginsTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "logins_total",
+		Help:      "Successful user logins.",
+	})
+
+	FailedLoginsTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "failed_logins_total",
+		Help:      "Login attempts rejected because of invalid credentials.",
+	})
+
+	ItemsCreatedTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "items_created_total",
+		Help:      "Items created through the API.",
+	})
+)
+
+func RegisterDBStats(pool *pgxpool.Pool, dbName string) {
+	prometheus.MustRegister(newPoolStatsCollector(pool, dbName))
+}
+
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
@@ -0,0 +1,67 @@
+package metrics
+
+import (
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type poolStatsCollector struct {
+	pool *pgxpool.Pool
+
+	acquireCount            *prometheus.Desc
+	acquireDuration         *prometheus.Desc
+	acquiredConns           *prometheus.Desc
+	canceledAcquireCount    *prometheus.Desc
+	constructingConns       *prometheus.Desc
+	emptyAcquireCount       *prometheus.Desc
+	idleConns               *prometheus.Desc
+	maxConns                *prometheus.Desc
+	totalConns              *prometheus.Desc
+	newConnsCount           *prometheus.Desc
+	maxLifetimeDestroyCount *prometheus.Desc
+	maxIdleDestroyCount     *prometheus.Desc
+}
+
+func newPoolStatsCollector(pool *pgxpool.Pool, dbName string) *poolStatsCollector {
+	labels := prometheus.Labels{"db_name": dbName}
+	desc := func(name string, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "db_pool", name), help, nil, labels)
+	}
+
+	return &poolStatsCollector{
+		pool:                    pool,
+		acquireCount:            desc("acquire_total", "Cumulative count of successful connection acquires."),
+		acquireDuration:         desc("acquire_duration_seconds_total", "Total time spent waiting for a connection."),
+		acquiredConns:           desc("acquired_conns", "Number of currently acquired connections."),
+		canceledAcquireCount:    desc("canceled_acquire_total", "Cumulative count of acquires canceled by a context."),
+		constructingConns:       desc("constructing_conns", "Number of connections being established."),
+		emptyAcquireCount:       desc("empty_acquire_total", "Cumulative count of acquires that waited for a connection."),
+		idleConns:               desc("idle_conns", "Number of currently idle connections."),
+		maxConns:                desc("max_conns", "Maximum size of the pool."),
+		totalConns:              desc("total_conns", "Total number of connections in the pool."),
+		newConnsCount:           desc("new_conns_total", "Cumulative count of new connections opened."),
+		maxLifetimeDestroyCount: desc("max_lifetime_destroy_total", "Cumulative count of connections closed because of MaxConnLifetime."),
+		maxIdleDestroyCount:     desc("max_idle_destroy_total", "Cumulative count of connections closed because of MaxConnIdleTime."),
+	}
+}
+
+func (c *poolStatsCollector) Describe(ch chan<- *prometheus.Desc) {
+	prometheus.DescribeByCollect(c, ch)
+}
+
+func (c *poolStatsCollector) Collect(ch chan<- prometheus.Metric) {
+	stat := c.pool.Stat()
+
+	ch <- prometheus.MustNewConstMetric(c.acquireCount, prometheus.CounterValue, float64(stat.AcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.acquireDuration, prometheus.CounterValue, stat.AcquireDuration().Seconds())
+	ch <- prometheus.MustNewConstMetric(c.acquiredConns, prometheus.GaugeValue, float64(stat.AcquiredConns()))
+	ch <- prometheus.MustNewConstMetric(c.canceledAcquireCount, prometheus.CounterValue, float64(stat.CanceledAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.constructingConns, prometheus.GaugeValue, float64(stat.ConstructingConns()))
+	ch <- prometheus.MustNewConstMetric(c.emptyAcquireCount, prometheus.CounterValue, float64(stat.EmptyAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.idleConns, prometheus.GaugeValue, float64(stat.IdleConns()))
+	ch <- prometheus.MustNewConstMetric(c.maxConns, prometheus.GaugeValue, float64(stat.MaxConns()))
+	ch <- prometheus.MustNewConstMetric(c.totalConns, prometheus.GaugeValue, float64(stat.TotalConns()))
+	ch <- prometheus.MustNewConstMetric(c.newConnsCount, prometheus.CounterValue, float64(stat.NewConnsCount()))
+	ch <- prometheus.MustNewConstMetric(c.maxLifetimeDestroyCount, prometheus.CounterValue, float64(stat.MaxLifetimeDestroyCount()))
+	ch <- prometheus.MustNewConstMetric(c.maxIdleDestroyCount, prometheus.CounterValue, float64(stat.MaxIdleDestroyCount()))
+}
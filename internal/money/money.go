@@ -0,0 +1,190 @@
+package money
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+var (
+	ErrUnknownCurrency = errors.New("unknown currency")
+	ErrNoRate          = errors.New("no exchange rate")
+	ErrOverflow        = errors.New("amount out of range")
+)
+
+// Money is an amount in the minor unit of its ISO 4217 currency, e.g. cents
+// for USD.
+type Money struct {
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+func (m Money) String() string {
+	return fmt.Sprintf("%d %s", m.Amount, m.Currency)
+}
+
+// Format writes the amount in major units, e.g. "12.50 EUR".
+func (m Money) Format() string {
+	digits, ok := minorUnits[m.Currency]
+	if !ok || digits == 0 {
+		return fmt.Sprintf("%d %s", m.Amount, m.Currency)
+	}
+
+	amount := new(big.Rat).SetFrac(big.NewInt(m.Amount), pow10(digits))
+
+	return fmt.Sprintf("%s %s", amount.FloatString(digits), m.Currency)
+}
+
+// minorUnits maps the supported ISO 4217 codes to the number of digits of
+// their minor unit.
+var minorUnits = map[string]int{
+	"AED": 2, "AUD": 2, "BGN": 2, "BHD": 3, "BRL": 2, "CAD": 2, "CHF": 2, "CLP": 0,
+	"CNY": 2, "CZK": 2, "DKK": 2, "EUR": 2, "GBP": 2, "HKD": 2, "HUF": 2, "IDR": 2,
+	"ILS": 2, "INR": 2, "ISK": 0, "JOD": 3, "JPY": 0, "KRW": 0, "KWD": 3, "MXN": 2,
+	"MYR": 2, "NOK": 2, "NZD": 2, "OMR": 3, "PHP": 2, "PLN": 2, "RON": 2, "RUB": 2,
+	"SAR": 2, "SEK": 2, "SGD": 2, "THB": 2, "TND": 3, "TRY": 2, "UAH": 2, "USD": 2,
+	"VND": 0, "ZAR": 2,
+}
+
+// Normalize upper-cases a currency code and checks that it is supported.
+func Normalize(code string) (string, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+
+	if _, ok := minorUnits[code]; !ok {
+		return "", fmt.Errorf("%w %q", ErrUnknownCurrency, code)
+	}
+
+	return code, nil
+}
+
+// ParseRate parses a positive decimal exchange rate such as "0.9183".
+func ParseRate(s string) (*big.Rat, error) {
+	rate, ok := new(big.Rat).SetString(strings.TrimSpace(s))
+	if !ok || rate.Sign() <= 0 {
+		return nil, fmt.Errorf("invalid exchange rate %q", s)
+	}
+
+	return rate, nil
+}
+
+// Convert converts m at rate, the number of units of to per unit of
+// m.Currency. The result is rounded to the minor unit of to, with ties
+// going to the even amount so that rounding does not drift in either
+// direction.
+func Convert(m Money, to string, rate *big.Rat) (Money, error) {
+	fromDigits, ok := minorUnits[m.Currency]
+	if !ok {
+		return Money{}, fmt.Errorf("%w %q", ErrUnknownCurrency, m.Currency)
+	}
+
+	toDigits, ok := minorUnits[to]
+	if !ok {
+		return Money{}, fmt.Errorf("%w %q", ErrUnknownCurrency, to)
+	}
+
+	amount := new(big.Rat).SetInt64(m.Amount)
+	amount.Mul(amount, rate)
+	amount.Mul(amount, new(big.Rat).SetFrac(pow10(toDigits), pow10(fromDigits)))
+
+	rounded := roundHalfEven(amount)
+	if !rounded.IsInt64() {
+		return Money{}, ErrOverflow
+	}
+
+	return Money{Amount: rounded.Int64(), Currency: to}, nil
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+func roundHalfEven(r *big.Rat) *big.Int {
+	num, den := r.Num(), r.Denom()
+
+	// Euclidean division keeps the remainder non-negative, so q is the floor.
+	q, rem := new(big.Int).DivMod(num, den, new(big.Int))
+
+	switch new(big.Int).Lsh(rem, 1).Cmp(den) {
+	case 1:
+		q.Add(q, big.NewInt(1))
+	case 0:
+		if q.Bit(0) == 1 {
+			q.Add(q, big.NewInt(1))
+		}
+	}
+
+	return q
+}
+
+// Rates looks up exchange rates, deriving inverse and cross rates from the
+// pairs it has been given.
+type Rates struct {
+	pairs map[[2]string]*big.Rat
+}
+
+func NewRates() *Rates {
+	return &Rates{pairs: make(map[[2]string]*big.Rat)}
+}
+
+// Add records that one unit of base is worth rate units of quote.
+func (r *Rates) Add(base string, quote string, rate *big.Rat) {
+	r.pairs[[2]string{base, quote}] = rate
+}
+
+// Rate returns the number of units of to per unit of from. A direct rate is
+// preferred, then the inverse of the opposite pair, then a cross rate
+// through one intermediate currency, choosing the alphabetically first so
+// the result does not depend on map order.
+func (r *Rates) Rate(from string, to string) (*big.Rat, error) {
+	if rate, ok := r.lookup(from, to); ok {
+		return rate, nil
+	}
+
+	var via string
+	for pair := range r.pairs {
+		for _, c := range pair {
+			if c == from || c == to || (via != "" && c >= via) {
+				continue
+			}
+			_, okFrom := r.lookup(from, c)
+			_, okTo := r.lookup(c, to)
+			if okFrom && okTo {
+				via = c
+			}
+		}
+	}
+
+	if via != "" {
+		first, _ := r.lookup(from, via)
+		second, _ := r.lookup(via, to)
+		return new(big.Rat).Mul(first, second), nil
+	}
+
+	return nil, fmt.Errorf("%w from %s to %s", ErrNoRate, from, to)
+}
+
+func (r *Rates) lookup(from string, to string) (*big.Rat, bool) {
+	if from == to {
+		return big.NewRat(1, 1), true
+	}
+
+	if rate, ok := r.pairs[[2]string{from, to}]; ok {
+		return rate, true
+	}
+
+	if rate, ok := r.pairs[[2]string{to, from}]; ok {
+		return new(big.Rat).Inv(rate), true
+	}
+
+	return nil, false
+}
+
+func (r *Rates) Convert(m Money, to string) (Money, error) {
+	rate, err := r.Rate(m.Currency, to)
+	if err != nil {
+		return Money{}, err
+	}
+
+	return Convert(m, to, rate)
+}
@@ -0,0 +1,447 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"time"
+	"training/proj/internal/api/models"
+	"training/proj/internal/db/repositories"
+	"training/proj/internal/importer"
+)
+
+type Report struct {
+	DryRun            bool         `json:"dry_run"`
+	CategoriesCreated []string     `json:"categories_created"`
+	ItemsCreated      []string     `json:"items_created"`
+	ItemsUpdated      []ItemUpdate `json:"items_updated"`
+	ItemsDeleted      []string     `json:"items_deleted"`
+	ItemsSkipped      []string     `json:"items_skipped"`
+	LinksCreated      []Link       `json:"links_created"`
+	LinksRemoved      []Link       `json:"links_removed"`
+	Unchanged         int          `json:"unchanged"`
+}
+
+type ItemUpdate struct {
+	Item     string `json:"item"`
+	OldPrice int64  `json:"old_price"`
+	NewPrice int64  `json:"new_price"`
+}
+
+type Link struct {
+	Item     string `json:"item"`
+	Category string `json:"category"`
+}
+
+func newReport(dryRun bool) *Report {
+	return &Report{
+		DryRun:            dryRun,
+		CategoriesCreated: []string{},
+		ItemsCreated:      []string{},
+		ItemsUpdated:      []ItemUpdate{},
+		ItemsDeleted:      []string{},
+		ItemsSkipped:      []string{},
+		LinksCreated:      []Link{},
+		LinksRemoved:      []Link{},
+	}
+}
+
+type sourceBatch struct {
+	source string
+	batch  *importer.Batch
+}
+
+// desiredItem is the state an item should have after the import, merged from
+// every record with the same name.
+// currency is the currency of price, or empty for the importer's currency.
+type desiredItem struct {
+	name       string
+	price      int64
+	currency   string
+	source     string
+	categories []string
+}
+
+// reconciler compares the desired state with the database and applies the
+// difference. In dry-run mode it only records what it would do; rows it
+// would create get negative placeholder ids.
+type reconciler struct {
+	repos    *repositories.Repositories
+	report   *Report
+	dryRun   bool
+	currency string
+
+	categories map[string]models.Category
+	items      map[string]models.Item
+	links      map[models.CategoryItem]bool
+
+	// trashed holds the items imported earlier that an admin moved to the
+	// trash. They are left there rather than imported again.
+	trashed map[string]models.Item
+
+	placeholder int64
+}
+
+func (s *Scheduler) reconcile(ctx context.Context, batches []sourceBatch, opts RunOptions) (*Report, error) {
+	ctx, span := tracer.Start(ctx, "Scheduler.reconcile")
+	defer span.End()
+
+	desired := desiredState(batches)
+
+	var report *Report
+
+	txOpts := []repositories.TxOption{repositories.WithIsolation(repositories.RepeatableRead)}
+	if opts.DryRun {
+		txOpts = append(txOpts, repositories.WithReadOnly())
+	}
+
+	err := s.TxManager.WithinTx(ctx, func(ctx context.Context, repos *repositories.Repositories) error {
+		rc := &reconciler{repos: repos, report: newReport(opts.DryRun), dryRun: opts.DryRun, currency: s.Currency}
+
+		if err := rc.load(ctx, batches); err != nil {
+			return err
+		}
+
+		for _, d := range desired {
+			if err := rc.apply(ctx, d); err != nil {
+				return err
+			}
+		}
+
+		for _, sb := range batches {
+			if !sb.batch.Prune {
+				continue
+			}
+
+			if len(sb.batch.Failures) > 0 {
+				s.Logger.Warnw("Not pruning importer with skipped records", "importer", sb.source, "skipped", len(sb.batch.Failures))
+				continue
+			}
+
+			if err := rc.prune(ctx, sb.source, desired); err != nil {
+				return err
+			}
+		}
+
+		report = rc.report
+		return nil
+	}, txOpts...)
+
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return report, nil
+}
+
+func desiredState(batches []sourceBatch) []*desiredItem {
+	desired := []*desiredItem{}
+	byName := map[string]*desiredItem{}
+
+	for _, sb := range batches {
+		for _, r := range sb.batch.Records {
+			d, ok := byName[r.Name]
+			if !ok {
+				d = &desiredItem{name: r.Name, source: sb.source}
+				byName[r.Name] = d
+				desired = append(desired, d)
+			}
+
+			if r.Price > 0 {
+				d.price = r.Price
+				d.currency = r.Currency
+			}
+
+			if !slices.Contains(d.categories, r.Category) {
+				d.categories = append(d.categories, r.Category)
+			}
+		}
+	}
+
+	return desired
+}
+
+func (rc *reconciler) load(ctx context.Context, batches []sourceBatch) error {
+	categories, err := rc.repos.CategoryRepository.GetAll(ctx)
+	if err != nil {
+		return fmt.Errorf("load categories: %w", err)
+	}
+
+	items, err := rc.repos.ItemRepository.GetAll(ctx)
+	if err != nil {
+		return fmt.Errorf("load items: %w", err)
+	}
+
+	links, err := rc.repos.CategoryItemRepository.GetAll(ctx)
+	if err != nil {
+		return fmt.Errorf("load category links: %w", err)
+	}
+
+	rc.categories = make(map[string]models.Category, len(categories))
+	for _, c := range categories {
+		if _, ok := rc.categories[c.Category]; !ok {
+			rc.categories[c.Category] = c
+		}
+	}
+
+	rc.items = make(map[string]models.Item, len(items))
+	for _, i := range items {
+		if _, ok := rc.items[i.Item]; !ok {
+			rc.items[i.Item] = i
+		}
+	}
+
+	rc.links = make(map[models.CategoryItem]bool, len(links))
+	for _, l := range links {
+		rc.links[l] = true
+	}
+
+	rc.trashed = map[string]models.Item{}
+	for _, sb := range batches {
+		trashed, err := rc.repos.ItemRepository.GetDeletedBySource(ctx, sb.source)
+		if err != nil {
+			return fmt.Errorf("load trashed items imported from %s: %w", sb.source, err)
+		}
+
+		for _, i := range trashed {
+			if _, live := rc.items[i.Item]; !live {
+				rc.trashed[i.Item] = i
+			}
+		}
+	}
+
+	return nil
+}
+
+func (rc *reconciler) apply(ctx context.Context, d *desiredItem) error {
+	if _, ok := rc.trashed[d.name]; ok {
+		rc.report.ItemsSkipped = append(rc.report.ItemsSkipped, d.name)
+		return nil
+	}
+
+	item, changed, err := rc.ensureItem(ctx, d)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range d.categories {
+		category, err := rc.ensureCategory(ctx, name, d.source)
+		if err != nil {
+			return err
+		}
+
+		l := models.CategoryItem{CategoryID: category.CategoryID, ItemID: item.ItemID}
+		if rc.links[l] {
+			continue
+		}
+
+		if !rc.dryRun {
+			if err := rc.repos.CategoryItemRepository.Create(ctx, l.CategoryID, l.ItemID); err != nil {
+				return fmt.Errorf("link item %q to category %q: %w", d.name, name, err)
+			}
+			if err := rc.repos.CategoryItemRepository.SetSource(ctx, l.CategoryID, l.ItemID, d.source); err != nil {
+				return fmt.Errorf("record source of link of item %q to category %q: %w", d.name, name, err)
+			}
+		}
+
+		rc.links[l] = true
+		rc.report.LinksCreated = append(rc.report.LinksCreated, Link{Item: d.name, Category: name})
+		changed = true
+	}
+
+	if !changed {
+		rc.report.Unchanged++
+	}
+
+	return nil
+}
+
+func (rc *reconciler) ensureItem(ctx context.Context, d *desiredItem) (models.Item, bool, error) {
+	existing, ok := rc.items[d.name]
+
+	currency := d.currency
+	if currency == "" {
+		currency = rc.currency
+	}
+
+	if !ok {
+		item := models.Item{Item: d.name, Price: d.price, Currency: currency}
+		if item.Price <= 0 {
+			item.Price = rand.Int64N(99900) + 1000
+		}
+
+		if rc.dryRun {
+			rc.placeholder--
+			item.ItemID = rc.placeholder
+		} else {
+			created, err := rc.repos.ItemRepository.Create(ctx, &item)
+			if err != nil {
+				return models.Item{}, false, fmt.Errorf("create item %q: %w", d.name, err)
+			}
+			if err := rc.repos.ItemRepository.SetSource(ctx, created.ItemID, d.source); err != nil {
+				return models.Item{}, false, fmt.Errorf("record source of item %q: %w", d.name, err)
+			}
+			if err := rc.recordRevision(ctx, "item", created.ItemID, models.NewItemSnapshot(created), d.source); err != nil {
+				return models.Item{}, false, fmt.Errorf("record revision of item %q: %w", d.name, err)
+			}
+			if err := rc.recordPrice(ctx, created, d.source); err != nil {
+				return models.Item{}, false, fmt.Errorf("record price of item %q: %w", d.name, err)
+			}
+			item = created
+		}
+
+		rc.items[d.name] = item
+		rc.report.ItemsCreated = append(rc.report.ItemsCreated, d.name)
+		return item, true, nil
+	}
+
+	// Imported prices are not converted, so they are not applied to items
+	// priced in another currency.
+	if d.price <= 0 || d.price == existing.Price || existing.Currency != currency {
+		return existing, false, nil
+	}
+
+	update := ItemUpdate{Item: d.name, OldPrice: existing.Price, NewPrice: d.price}
+	existing.Price = d.price
+
+	if !rc.dryRun {
+		updated, err := rc.repos.ItemRepository.Update(ctx, existing.ItemID, &existing)
+		if err != nil {
+			return models.Item{}, false, fmt.Errorf("update item %q: %w", d.name, err)
+		}
+		if err := rc.recordRevision(ctx, "item", updated.ItemID, models.NewItemSnapshot(updated), d.source); err != nil {
+			return models.Item{}, false, fmt.Errorf("record revision of item %q: %w", d.name, err)
+		}
+		if err := rc.recordPrice(ctx, updated, d.source); err != nil {
+			return models.Item{}, false, fmt.Errorf("record price of item %q: %w", d.name, err)
+		}
+		existing = updated
+	}
+
+	rc.items[d.name] = existing
+	rc.report.ItemsUpdated = append(rc.report.ItemsUpdated, update)
+	return existing, true, nil
+}
+
+func (rc *reconciler) ensureCategory(ctx context.Context, name string, source string) (models.Category, error) {
+	if existing, ok := rc.categories[name]; ok {
+		return existing, nil
+	}
+
+	category := models.Category{Category: name}
+
+	if rc.dryRun {
+		rc.placeholder--
+		category.CategoryID = rc.placeholder
+	} else {
+		created, err := rc.repos.CategoryRepository.Create(ctx, &category)
+		if err != nil {
+			return models.Category{}, fmt.Errorf("create category %q: %w", name, err)
+		}
+		if err := rc.recordRevision(ctx, "category", created.CategoryID, models.NewCategorySnapshot(created), source); err != nil {
+			return models.Category{}, fmt.Errorf("record revision of category %q: %w", name, err)
+		}
+		category = created
+	}
+
+	rc.categories[name] = category
+	rc.report.CategoriesCreated = append(rc.report.CategoriesCreated, name)
+	return category, nil
+}
+
+// recordRevision stores the imported state of an entity as its next
+// revision, attributed to the importer.
+func (rc *reconciler) recordRevision(ctx context.Context, entityType string, id int64, data any, source string) error {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	return rc.repos.RevisionRepository.Create(ctx, &models.Revision{
+		EntityType: entityType,
+		EntityID:   id,
+		Data:       raw,
+		Actor:      "import:" + source,
+	})
+}
+
+func (rc *reconciler) recordPrice(ctx context.Context, item models.Item, source string) error {
+	now := time.Now()
+
+	return rc.repos.PriceRepository.Create(ctx, &models.PriceChange{
+		ItemID:      item.ItemID,
+		Price:       item.Price,
+		EffectiveAt: now,
+		AppliedAt:   &now,
+		Actor:       "import:" + source,
+	})
+}
+
+// prune deletes items imported from the source that are no longer listed and
+// unlinks the remaining ones from categories the source no longer puts
+// them in. Only links the source made are removed; links an admin added by
+// hand are kept.
+func (rc *reconciler) prune(ctx context.Context, source string, desired []*desiredItem) error {
+	wanted := make(map[string]*desiredItem, len(desired))
+	for _, d := range desired {
+		wanted[d.name] = d
+	}
+
+	owned, err := rc.repos.ItemRepository.GetBySource(ctx, source)
+	if err != nil {
+		return fmt.Errorf("load items imported from %s: %w", source, err)
+	}
+
+	categoryNames := make(map[int64]string, len(rc.categories))
+	for name, c := range rc.categories {
+		categoryNames[c.CategoryID] = name
+	}
+
+	sourceLinks, err := rc.repos.CategoryItemRepository.GetBySource(ctx, source)
+	if err != nil {
+		return fmt.Errorf("load category links imported from %s: %w", source, err)
+	}
+
+	itemLinks := map[int64][]int64{}
+	for _, l := range sourceLinks {
+		itemLinks[l.ItemID] = append(itemLinks[l.ItemID], l.CategoryID)
+	}
+
+	for _, item := range owned {
+		d, listed := wanted[item.Item]
+
+		if !listed {
+			if !rc.dryRun {
+				if _, err := rc.repos.ItemRepository.Delete(ctx, item.ItemID); err != nil {
+					return fmt.Errorf("delete item %q: %w", item.Item, err)
+				}
+			}
+			rc.report.ItemsDeleted = append(rc.report.ItemsDeleted, item.Item)
+			continue
+		}
+
+		categoryIds := itemLinks[item.ItemID]
+		slices.Sort(categoryIds)
+
+		for _, categoryId := range categoryIds {
+			l := models.CategoryItem{CategoryID: categoryId, ItemID: item.ItemID}
+			if slices.Contains(d.categories, categoryNames[categoryId]) {
+				continue
+			}
+
+			if !rc.dryRun {
+				if _, err := rc.repos.CategoryItemRepository.Delete(ctx, l.CategoryID, l.ItemID); err != nil {
+					return fmt.Errorf("unlink item %q from category %q: %w", item.Item, categoryNames[l.CategoryID], err)
+				}
+			}
+
+			delete(rc.links, l)
+			rc.report.LinksRemoved = append(rc.report.LinksRemoved, Link{Item: item.Item, Category: categoryNames[l.CategoryID]})
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,139 @@
+package scheduler
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"training/proj/internal/api/models"
+	"training/proj/internal/db/memory"
+	"training/proj/internal/importer"
+)
+
+func TestReconcileLeavesTrashedItemsInTrash(t *testing.T) {
+	ctx := context.Background()
+	repos := memory.NewRepositories(memory.NewStore())
+	s := newTestScheduler(repos, &staticImporter{name: "feed", batch: importer.Batch{
+		Records: []importer.Record{{Name: "apple", Category: "fruit", Price: 100}},
+	}})
+
+	if _, err := s.ExternalDbFill(ctx, RunOptions{}); err != nil {
+		t.Fatalf("ExternalDbFill: %v", err)
+	}
+
+	apple, err := repos.ItemRepository.GetByName(ctx, "apple")
+	if err != nil {
+		t.Fatalf("GetByName: %v", err)
+	}
+	if _, err := repos.ItemRepository.Delete(ctx, apple.ItemID); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	res, err := s.ExternalDbFill(ctx, RunOptions{})
+	if err != nil {
+		t.Fatalf("ExternalDbFill: %v", err)
+	}
+
+	if !slices.Equal(res.Report.ItemsSkipped, []string{"apple"}) {
+		t.Errorf("skipped = %v, want [apple]", res.Report.ItemsSkipped)
+	}
+	if len(res.Report.ItemsCreated) != 0 {
+		t.Errorf("created = %v, want none", res.Report.ItemsCreated)
+	}
+
+	items, err := repos.ItemRepository.GetAll(ctx)
+	if err != nil {
+		t.Fatalf("GetAll: %v", err)
+	}
+	if len(items) != 0 {
+		t.Errorf("got %d live items, want 0", len(items))
+	}
+}
+
+func TestReconcilePruneKeepsManualLinks(t *testing.T) {
+	ctx := context.Background()
+	repos := memory.NewRepositories(memory.NewStore())
+	feed := &staticImporter{name: "feed", batch: importer.Batch{
+		Prune:   true,
+		Records: []importer.Record{{Name: "apple", Category: "fruit", Price: 100}},
+	}}
+	s := newTestScheduler(repos, feed)
+
+	if _, err := s.ExternalDbFill(ctx, RunOptions{}); err != nil {
+		t.Fatalf("ExternalDbFill: %v", err)
+	}
+
+	apple, err := repos.ItemRepository.GetByName(ctx, "apple")
+	if err != nil {
+		t.Fatalf("GetByName: %v", err)
+	}
+	favourites, err := repos.CategoryRepository.Create(ctx, &models.Category{Category: "favourites"})
+	if err != nil {
+		t.Fatalf("Create category: %v", err)
+	}
+	if err := repos.CategoryItemRepository.Create(ctx, favourites.CategoryID, apple.ItemID); err != nil {
+		t.Fatalf("Create link: %v", err)
+	}
+
+	feed.batch.Records = []importer.Record{{Name: "apple", Category: "snacks", Price: 100}}
+
+	res, err := s.ExternalDbFill(ctx, RunOptions{})
+	if err != nil {
+		t.Fatalf("ExternalDbFill: %v", err)
+	}
+
+	if !slices.Equal(res.Report.LinksRemoved, []Link{{Item: "apple", Category: "fruit"}}) {
+		t.Errorf("removed = %v, want only apple from fruit", res.Report.LinksRemoved)
+	}
+
+	categories, err := repos.ItemRepository.GetItemCategories(ctx, apple.ItemID)
+	if err != nil {
+		t.Fatalf("GetItemCategories: %v", err)
+	}
+
+	names := []string{}
+	for _, c := range categories {
+		names = append(names, c.Category)
+	}
+	slices.Sort(names)
+
+	if !slices.Equal(names, []string{"favourites", "snacks"}) {
+		t.Errorf("categories = %v, want [favourites snacks]", names)
+	}
+}
+
+func TestReconcilePricesItemsInRecordCurrency(t *testing.T) {
+	ctx := context.Background()
+	repos := memory.NewRepositories(memory.NewStore())
+	feed := &staticImporter{name: "feed", batch: importer.Batch{
+		Records: []importer.Record{
+			{Name: "apple", Category: "fruit", Price: 100, Currency: "USD"},
+			{Name: "pear", Category: "fruit", Price: 200},
+		},
+	}}
+	s := newTestScheduler(repos, feed)
+
+	if _, err := s.ExternalDbFill(ctx, RunOptions{}); err != nil {
+		t.Fatalf("ExternalDbFill: %v", err)
+	}
+
+	for name, want := range map[string]string{"apple": "USD", "pear": "EUR"} {
+		item, err := repos.ItemRepository.GetByName(ctx, name)
+		if err != nil {
+			t.Fatalf("GetByName(%s): %v", name, err)
+		}
+		if item.Currency != want {
+			t.Errorf("%s currency = %s, want %s", name, item.Currency, want)
+		}
+	}
+
+	// A price in the importer's currency is not applied to the USD item.
+	feed.batch.Records = []importer.Record{{Name: "apple", Category: "fruit", Price: 150}}
+
+	res, err := s.ExternalDbFill(ctx, RunOptions{})
+	if err != nil {
+		t.Fatalf("ExternalDbFill: %v", err)
+	}
+	if len(res.Report.ItemsUpdated) != 0 {
+		t.Errorf("updated = %v, want none", res.Report.ItemsUpdated)
+	}
+}
@@ -1,122 +1,243 @@
 package scheduler
 
 import (
-	"database/sql"
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
-	"io"
-	"math/rand/v2"
-	"net/http"
 	"sync"
-	"training/proj/internal/api/models"
+	"time"
 	"training/proj/internal/db/repositories"
+	"training/proj/internal/importer"
+	"training/proj/internal/metrics"
 
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
 	"go.uber.org/zap"
 )
 
+var tracer = otel.Tracer("training/proj/internal/scheduler")
+
 type Scheduler struct {
-	ItemRepository         *repositories.ItemRepository
-	CategoryRepository     *repositories.CategoryRepository
-	CategoryItemRepository *repositories.CategoryItemRepository
+	ItemRepository         repositories.ItemRepositoryInterface
+	CategoryRepository     repositories.CategoryRepositoryInterface
+	CategoryItemRepository repositories.CategoryItemRepositoryInterface
+	TxManager              repositories.TxManager
 	Logger                 *zap.SugaredLogger
 	Wg                     *sync.WaitGroup
+	Importers              []importer.Importer
+
+	// Currency is the currency imported prices are in. Items the importer
+	// creates are priced in it.
+	Currency string
+
+	mu     sync.Mutex
+	status Status
 }
 
-func NewScheduler(r *repositories.Repositories, l *zap.SugaredLogger, wg *sync.WaitGroup) *Scheduler {
+type Status struct {
+	Running      bool       `json:"running"`
+	LastStarted  *time.Time `json:"last_started,omitempty"`
+	LastFinished *time.Time `json:"last_finished,omitempty"`
+	LastError    string     `json:"last_error,omitempty"`
+	LastResult   *Result    `json:"last_result,omitempty"`
+}
+
+type Result struct {
+	Fetched  int                `json:"fetched"`
+	Failures []importer.Failure `json:"failures,omitempty"`
+	Report   *Report            `json:"report,omitempty"`
+}
+
+type Summary struct {
+	DryRun       bool `json:"dry_run"`
+	Fetched      int  `json:"fetched"`
+	Failures     int  `json:"failures"`
+	Created      int  `json:"created"`
+	Updated      int  `json:"updated"`
+	Deleted      int  `json:"deleted"`
+	Skipped      int  `json:"skipped"`
+	LinksCreated int  `json:"links_created"`
+	LinksRemoved int  `json:"links_removed"`
+	Unchanged    int  `json:"unchanged"`
+}
+
+// Summary condenses the result into counts, which is what job history keeps.
+func (r Result) Summary() Summary {
+	s := Summary{Fetched: r.Fetched, Failures: len(r.Failures)}
+
+	if r.Report != nil {
+		s.DryRun = r.Report.DryRun
+		s.Created = len(r.Report.ItemsCreated)
+		s.Updated = len(r.Report.ItemsUpdated)
+		s.Deleted = len(r.Report.ItemsDeleted)
+		s.Skipped = len(r.Report.ItemsSkipped)
+		s.LinksCreated = len(r.Report.LinksCreated)
+		s.LinksRemoved = len(r.Report.LinksRemoved)
+		s.Unchanged = r.Report.Unchanged
+	}
+
+	return s
+}
+
+type RunOptions struct {
+	// DryRun computes the reconciliation report without changing the database.
+	DryRun bool
+}
+
+func NewScheduler(r *repositories.Repositories, importers []importer.Importer, l *zap.SugaredLogger, wg *sync.WaitGroup) *Scheduler {
 	return &Scheduler{
 		ItemRepository:         r.ItemRepository,
 		CategoryRepository:     r.CategoryRepository,
 		CategoryItemRepository: r.CategoryItemRepository,
+		TxManager:              r.TxManager,
 		Logger:                 l,
 		Wg:                     wg,
+		Importers:              importers,
 	}
 }
 
-type ExternalItem struct {
-	Name     string `json:"name"`
-	Category string `json:"category"`
-}
-
-func (s *Scheduler) ExternalDbFill() {
+// ExternalDbFill imports records from every importer and reconciles them with
+// the database in a single transaction. Records that cannot be parsed are
+// reported in the result and skipped; the returned error is set when an
+// importer failed or the changes could not be applied.
+func (s *Scheduler) ExternalDbFill(ctx context.Context, opts RunOptions) (res Result, err error) {
 	s.Wg.Add(1)
 	defer s.Wg.Done()
+	start := time.Now()
+	metrics.SchedulerLastRun.Set(float64(start.Unix()))
+	s.setStarted(start)
+
+	ctx, span := tracer.Start(ctx, "Scheduler.ExternalDbFill")
+	defer span.End()
+
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("import panicked: %v", p)
+		}
+
+		if err != nil {
+			metrics.SchedulerErrors.Inc()
+			span.SetStatus(codes.Error, err.Error())
+		}
+
+		metrics.SchedulerRunDuration.Observe(time.Since(start).Seconds())
+		s.setFinished(time.Now(), res, err)
+	}()
+
 	s.Logger.Info("Start filling db")
-	externalItems := s.parse()
-	s.fillTables(externalItems)
-	s.Logger.Info("Finish filling db")
-}
 
-func (s *Scheduler) parse() []ExternalItem {
-	requestURL := "https://emojihub.yurace.pro/api/all"
-	res, httpErr := http.Get(requestURL)
-	if httpErr != nil {
-		s.Logger.Fatalf(httpErr.Error())
-	}
+	batches, fetchErr := s.parse(ctx, &res)
 
-	resBody, ioErr := io.ReadAll(res.Body)
-	if ioErr != nil {
-		s.Logger.Fatalf(ioErr.Error())
+	// Only reconcile sources that were fetched; a failed importer keeps its
+	// items as they are.
+	report, reconcileErr := s.reconcile(ctx, batches, opts)
+	if reconcileErr != nil {
+		return res, errors.Join(fetchErr, fmt.Errorf("reconcile: %w", reconcileErr))
 	}
-	items := []ExternalItem{}
-	unmarshallErr := json.Unmarshal(resBody, &items)
-	if unmarshallErr != nil {
-		s.Logger.Fatalf(unmarshallErr.Error())
+	res.Report = report
+
+	if !opts.DryRun {
+		metrics.SchedulerItemsCreated.Add(float64(len(report.ItemsCreated)))
 	}
-	return items
+
+	span.SetAttributes(
+		attribute.Bool("import.dry_run", opts.DryRun),
+		attribute.Int("import.fetched", res.Fetched),
+		attribute.Int("import.created", len(report.ItemsCreated)),
+		attribute.Int("import.updated", len(report.ItemsUpdated)),
+		attribute.Int("import.deleted", len(report.ItemsDeleted)),
+		attribute.Int("import.failures", len(res.Failures)),
+	)
+	s.Logger.Infow("Finish filling db",
+		"dry_run", opts.DryRun,
+		"fetched", res.Fetched,
+		"created", len(report.ItemsCreated),
+		"updated", len(report.ItemsUpdated),
+		"deleted", len(report.ItemsDeleted),
+		"skipped", len(report.ItemsSkipped),
+		"links_created", len(report.LinksCreated),
+		"links_removed", len(report.LinksRemoved),
+		"unchanged", report.Unchanged,
+		"failures", len(res.Failures),
+	)
+
+	return res, fetchErr
 }
 
-func (s *Scheduler) fillTables(items []ExternalItem) {
+func (s *Scheduler) Status() Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-	for _, v := range items {
-		dbItem, itemErr := s.createItemIfAbsent(v)
-		dbCategory, catErr := s.createCategoryIfAbsent(v)
+	return s.status
+}
 
-		if itemErr != nil || catErr != nil {
-			s.CategoryItemRepository.Create(dbCategory.CategoryID, dbItem.ItemID)
-		}
-	}
+func (s *Scheduler) setStarted(t time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
+	s.status.Running = true
+	s.status.LastStarted = &t
 }
 
-func (s *Scheduler) createCategoryIfAbsent(item ExternalItem) (models.Category, error) {
-	dbCategory, getCatErr := s.CategoryRepository.GetByName(item.Category)
+func (s *Scheduler) setFinished(t time.Time, res Result, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-	if getCatErr == sql.ErrNoRows {
-		newCategory := models.Category{
-			Category: item.Category,
-		}
-		newDbCategory, createErr := s.CategoryRepository.Create(&newCategory)
+	s.status.Running = false
+	s.status.LastFinished = &t
+	s.status.LastResult = &res
+	s.status.LastError = ""
+	if err != nil {
+		s.status.LastError = err.Error()
+	}
+}
 
-		if createErr != nil {
-			s.Logger.Fatalf(createErr.Error())
-		}
+func (s *Scheduler) Check(ctx context.Context) (interface{}, error) {
+	status := s.Status()
 
-		return newDbCategory, nil
-	} else if getCatErr != nil {
-		s.Logger.Fatalf(getCatErr.Error())
+	if status.LastError != "" {
+		return status, fmt.Errorf("last run failed: %s", status.LastError)
 	}
 
-	return dbCategory, fmt.Errorf("category already exists")
+	return status, nil
 }
 
-func (s *Scheduler) createItemIfAbsent(item ExternalItem) (models.Item, error) {
-	dbItem, getItemErr := s.ItemRepository.GetByName(item.Name)
+func (s *Scheduler) parse(ctx context.Context, res *Result) ([]sourceBatch, error) {
+	ctx, span := tracer.Start(ctx, "Scheduler.parse")
+	defer span.End()
+
+	batches := []sourceBatch{}
+	var errs []error
 
-	if getItemErr == sql.ErrNoRows {
-		newItem := models.Item{
-			Item:  item.Name,
-			Price: rand.Int64N(99900) + 1000,
+	for _, imp := range s.Importers {
+		batch, err := imp.Fetch(ctx)
+		if err != nil {
+			s.Logger.Warnw("Importer failed", "importer", imp.Name(), "error", err)
+			span.RecordError(err)
+			errs = append(errs, fmt.Errorf("importer %s: %w", imp.Name(), err))
+			continue
 		}
-		newDbItem, createErr := s.ItemRepository.Create(&newItem)
 
-		if createErr != nil {
-			s.Logger.Fatalf(createErr.Error())
+		for _, f := range batch.Failures {
+			s.recordFailure(res, f)
 		}
 
-		return newDbItem, nil
-	} else if getItemErr != nil {
-		s.Logger.Fatalf(getItemErr.Error())
+		res.Fetched += len(batch.Records)
+		batches = append(batches, sourceBatch{source: imp.Name(), batch: batch})
+
+		s.Logger.Infow("Fetched records", "importer", imp.Name(), "count", len(batch.Records), "skipped", len(batch.Failures))
+	}
+
+	if len(errs) > 0 {
+		span.SetStatus(codes.Error, "one or more importers failed")
 	}
 
-	return dbItem, fmt.Errorf("item already exists")
+	return batches, errors.Join(errs...)
+}
+
+func (s *Scheduler) recordFailure(res *Result, f importer.Failure) {
+	metrics.ImporterRecordFailuresTotal.WithLabelValues(f.Source).Inc()
+	s.Logger.Debugw("Skipped record", "importer", f.Source, "ref", f.Ref, "name", f.Name, "error", f.Error)
+	res.Failures = append(res.Failures, f)
 }
@@ -0,0 +1,73 @@
+package scheduler
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"training/proj/internal/db/memory"
+	"training/proj/internal/db/repositories"
+	"training/proj/internal/importer"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.uber.org/zap"
+)
+
+var spans = tracetest.NewInMemoryExporter()
+
+func TestMain(m *testing.M) {
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(spans)))
+	m.Run()
+}
+
+// staticImporter returns the same batch on every fetch.
+type staticImporter struct {
+	name  string
+	batch importer.Batch
+}
+
+func (i *staticImporter) Name() string {
+	return i.name
+}
+
+func (i *staticImporter) Fetch(ctx context.Context) (*importer.Batch, error) {
+	batch := i.batch
+	batch.Records = append([]importer.Record(nil), i.batch.Records...)
+	return &batch, nil
+}
+
+func newTestScheduler(repos *repositories.Repositories, importers ...importer.Importer) *Scheduler {
+	s := NewScheduler(repos, importers, zap.NewNop().Sugar(), &sync.WaitGroup{})
+	s.Currency = "EUR"
+	return s
+}
+
+func TestExternalDbFillSpans(t *testing.T) {
+	spans.Reset()
+
+	repos := memory.NewRepositories(memory.NewStore())
+	s := newTestScheduler(repos, &staticImporter{name: "feed", batch: importer.Batch{
+		Records: []importer.Record{{Name: "apple", Category: "fruit", Price: 100}},
+	}})
+
+	if _, err := s.ExternalDbFill(context.Background(), RunOptions{}); err != nil {
+		t.Fatalf("ExternalDbFill: %v", err)
+	}
+
+	parents := map[string]string{}
+	ids := map[string]string{}
+	for _, span := range spans.GetSpans() {
+		ids[span.Name] = span.SpanContext.SpanID().String()
+		parents[span.Name] = span.Parent.SpanID().String()
+	}
+
+	for _, name := range []string{"Scheduler.parse", "Scheduler.reconcile"} {
+		if _, ok := ids[name]; !ok {
+			t.Fatalf("no %s span", name)
+		}
+		if parents[name] != ids["Scheduler.ExternalDbFill"] {
+			t.Errorf("%s is not a child of Scheduler.ExternalDbFill", name)
+		}
+	}
+}
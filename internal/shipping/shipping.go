@@ -0,0 +1,220 @@
+package shipping
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+	"training/proj/internal/api/models"
+	"training/proj/internal/money"
+)
+
+// volumetricDivisor is the number of cubic millimetres billed as one gram,
+// the common courier figure of 5000 cm³ per kilogram.
+const volumetricDivisor = 5000
+
+// Line is a basket entry. The item's price, or its sale price if it has
+// one, is in the item's own currency.
+type Line struct {
+	Item     models.Item
+	Quantity int64
+}
+
+// NormalizePostalCode upper-cases a postal code and drops spaces and dashes
+// so that prefixes match however the code was typed.
+func NormalizePostalCode(code string) string {
+	return strings.Map(func(r rune) rune {
+		if r == ' ' || r == '-' {
+			return -1
+		}
+		return r
+	}, strings.ToUpper(strings.TrimSpace(code)))
+}
+
+// Weight returns the billable weight of the lines in grams: for each unit
+// the greater of its weight and its volumetric weight. It also returns the
+// IDs of the items whose weight is unknown, which are left out of the
+// total.
+func Weight(lines []Line) (int64, []int64) {
+	var total int64
+	var unknown []int64
+
+	for _, line := range lines {
+		item := line.Item
+		if item.WeightGrams == nil {
+			unknown = append(unknown, item.ItemID)
+			continue
+		}
+
+		unit := *item.WeightGrams
+
+		if item.LengthMM != nil && item.WidthMM != nil && item.HeightMM != nil {
+			volume := *item.LengthMM * *item.WidthMM * *item.HeightMM
+			if volumetric := (volume + volumetricDivisor - 1) / volumetricDivisor; volumetric > unit {
+				unit = volumetric
+			}
+		}
+
+		total += unit * line.Quantity
+	}
+
+	return total, unknown
+}
+
+// Quote offers the methods that can deliver the lines to the postal code.
+// Each method is priced with its rule that has the longest postal prefix
+// matching the code, in currency if it is set and in the method's own
+// currency otherwise. Methods that cannot deliver the basket are listed in
+// Unavailable with the reason. Options are sorted by price, then by
+// delivery time.
+func Quote(country string, postalCode string, lines []Line, methods []models.ShippingMethod, currency string, rates *money.Rates, now time.Time) models.ShippingQuote {
+	weight, unknown := Weight(lines)
+
+	quote := models.ShippingQuote{
+		Country:     country,
+		PostalCode:  postalCode,
+		WeightGrams: weight,
+		Options:     make([]models.ShippingOption, 0),
+		Unavailable: make([]models.UnavailableShipping, 0),
+	}
+
+	for _, method := range methods {
+		option, err := price(method, postalCode, lines, weight, unknown, currency, rates)
+		if err != nil {
+			quote.Unavailable = append(quote.Unavailable, models.UnavailableShipping{
+				MethodID: method.MethodID,
+				Name:     method.Name,
+				Reason:   err.Error(),
+			})
+			continue
+		}
+
+		option.EarliestArrival = addBusinessDays(now, option.MinDays).Format(time.DateOnly)
+		option.LatestArrival = addBusinessDays(now, option.MaxDays).Format(time.DateOnly)
+		quote.Options = append(quote.Options, option)
+	}
+
+	sort.SliceStable(quote.Options, func(i, j int) bool {
+		a, b := quote.Options[i], quote.Options[j]
+		if a.Price != b.Price {
+			return a.Price < b.Price
+		}
+		return a.MaxDays < b.MaxDays
+	})
+
+	return quote
+}
+
+func price(method models.ShippingMethod, postalCode string, lines []Line, weight int64, unknown []int64, currency string, rates *money.Rates) (models.ShippingOption, error) {
+	rule, ok := matchRule(method.Rules, postalCode)
+	if !ok {
+		return models.ShippingOption{}, fmt.Errorf("does not deliver to postal code %s", postalCode)
+	}
+
+	needsWeight := method.Kind == models.ShippingWeight || rule.MaxWeightGrams != nil
+	if needsWeight && len(unknown) > 0 {
+		return models.ShippingOption{}, fmt.Errorf("weight of item %d is unknown", unknown[0])
+	}
+
+	if rule.MaxWeightGrams != nil && weight > *rule.MaxWeightGrams {
+		return models.ShippingOption{}, fmt.Errorf("basket weighs %d g, more than the %d g this method carries", weight, *rule.MaxWeightGrams)
+	}
+
+	amount := rule.Rate
+
+	switch method.Kind {
+	case models.ShippingWeight:
+		kilograms := (weight + 999) / 1000
+
+		total := new(big.Int).Mul(big.NewInt(rule.PerKg), big.NewInt(kilograms))
+		total.Add(total, big.NewInt(rule.Rate))
+		if !total.IsInt64() {
+			return models.ShippingOption{}, money.ErrOverflow
+		}
+		amount = total.Int64()
+
+	case models.ShippingFreeOver:
+		subtotal, err := subtotal(lines, method.Currency, rates)
+		if err != nil {
+			return models.ShippingOption{}, err
+		}
+		if rule.FreeOver != nil && subtotal >= *rule.FreeOver {
+			amount = 0
+		}
+	}
+
+	cost := money.Money{Amount: amount, Currency: method.Currency}
+
+	if currency != "" && currency != method.Currency {
+		var err error
+		if cost, err = rates.Convert(cost, currency); err != nil {
+			return models.ShippingOption{}, err
+		}
+	}
+
+	return models.ShippingOption{
+		MethodID: method.MethodID,
+		Name:     method.Name,
+		Kind:     method.Kind,
+		Price:    cost.Amount,
+		Currency: cost.Currency,
+		MinDays:  rule.MinDays,
+		MaxDays:  rule.MaxDays,
+	}, nil
+}
+
+// matchRule picks the rule with the longest postal prefix that the postal
+// code starts with.
+func matchRule(rules []models.ShippingRule, postalCode string) (models.ShippingRule, bool) {
+	var best models.ShippingRule
+	found := false
+
+	for _, rule := range rules {
+		if !strings.HasPrefix(postalCode, rule.PostalPrefix) {
+			continue
+		}
+		if !found || len(rule.PostalPrefix) > len(best.PostalPrefix) {
+			best, found = rule, true
+		}
+	}
+
+	return best, found
+}
+
+// subtotal adds up the lines at their sale prices where they have one, in
+// currency.
+func subtotal(lines []Line, currency string, rates *money.Rates) (int64, error) {
+	var total int64
+
+	for _, line := range lines {
+		unit := line.Item.Price
+		if line.Item.SalePrice != nil {
+			unit = *line.Item.SalePrice
+		}
+
+		converted, err := rates.Convert(money.Money{Amount: unit * line.Quantity, Currency: line.Item.Currency}, currency)
+		if err != nil {
+			return 0, err
+		}
+
+		total += converted.Amount
+	}
+
+	return total, nil
+}
+
+// addBusinessDays counts days forward from t, skipping Saturdays and
+// Sundays.
+func addBusinessDays(t time.Time, days int64) time.Time {
+	t = t.UTC()
+
+	for days > 0 {
+		t = t.AddDate(0, 0, 1)
+		if t.Weekday() != time.Saturday && t.Weekday() != time.Sunday {
+			days--
+		}
+	}
+
+	return t
+}
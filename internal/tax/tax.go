@@ -0,0 +1,101 @@
+package tax
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+	"training/proj/internal/api/models"
+	"training/proj/internal/db/repositories"
+	"training/proj/internal/money"
+)
+
+var (
+	ErrUnknownRegion = errors.New("unknown tax region")
+	ErrNoRate        = errors.New("no tax rate")
+)
+
+// Line is a net amount to be taxed under a tax class.
+type Line struct {
+	Amount   money.Money
+	TaxClass string
+}
+
+// Calculator works out the tax on net amounts for a region. The results are
+// in the same order as the lines.
+type Calculator interface {
+	Calculate(ctx context.Context, region string, lines []Line) ([]models.TaxAmounts, error)
+}
+
+var _ Calculator = (*TableCalculator)(nil)
+
+// TableCalculator applies the rates stored in the tax tables.
+type TableCalculator struct {
+	TaxRepository repositories.TaxRepositoryInterface
+}
+
+func NewTableCalculator(repo repositories.TaxRepositoryInterface) *TableCalculator {
+	return &TableCalculator{
+		TaxRepository: repo,
+	}
+}
+
+// Calculate rounds the tax on each line to the minor unit of its currency,
+// with ties going to the even amount, and adds it to the net amount to get
+// the gross amount.
+func (c *TableCalculator) Calculate(ctx context.Context, region string, lines []Line) ([]models.TaxAmounts, error) {
+	rates, err := c.TaxRepository.RatesAt(ctx, region, time.Now())
+	if errors.Is(err, repositories.ErrNotFound) {
+		return nil, fmt.Errorf("%w %q", ErrUnknownRegion, region)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	amounts := make([]models.TaxAmounts, 0, len(lines))
+
+	for _, line := range lines {
+		rate, ok := rates[line.TaxClass]
+		if !ok {
+			return nil, fmt.Errorf("%w for tax class %q in %s", ErrNoRate, line.TaxClass, region)
+		}
+
+		amount, err := Apply(line.Amount, rate)
+		if err != nil {
+			return nil, err
+		}
+
+		amount.Region = region
+		amount.TaxClass = line.TaxClass
+		amounts = append(amounts, amount)
+	}
+
+	return amounts, nil
+}
+
+// Apply charges the percentage rate, a decimal such as "19.0000", on the
+// net amount.
+func Apply(net money.Money, rate string) (models.TaxAmounts, error) {
+	percent, ok := new(big.Rat).SetString(rate)
+	if !ok {
+		return models.TaxAmounts{}, fmt.Errorf("invalid tax rate %q", rate)
+	}
+
+	tax, err := money.Convert(net, net.Currency, percent.Quo(percent, big.NewRat(100, 1)))
+	if err != nil {
+		return models.TaxAmounts{}, err
+	}
+
+	gross := net.Amount + tax.Amount
+	if (gross > net.Amount) != (tax.Amount > 0) {
+		return models.TaxAmounts{}, money.ErrOverflow
+	}
+
+	return models.TaxAmounts{
+		Rate:  rate,
+		Net:   net.Amount,
+		Tax:   tax.Amount,
+		Gross: gross,
+	}, nil
+}